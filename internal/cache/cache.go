@@ -34,12 +34,22 @@ type Cache struct {
 	storage  storage.Storage
 	repoInfo *domain.RepoInfo
 	repo     git.Repository
+	// worktree names the linked git worktree this cache syncs, "" for the
+	// main checkout; it selects the LAST_SYNCED marker
+	worktree string
 }
 
+// nestedNameSeparator replaces "/" in nested repository names in local
+// cache paths. It is not a valid repo name character, so it cannot collide.
+const nestedNameSeparator = "+"
+
 // NewCache creates a new cache for the given repository
 func NewCache(repoInfo *domain.RepoInfo, store storage.Storage) (*Cache, error) {
 	baseDir := constants.DefaultCacheDir()
-	cachePath := filepath.Join(baseDir, repoInfo.Owner, repoInfo.Name)
+	cachePath := cacheDir(baseDir, repoInfo)
+	if err := migrateNestedCache(baseDir, repoInfo, cachePath); err != nil {
+		return nil, err
+	}
 
 	gitRepo, err := git.NewGoGitRepository(cachePath)
 	if err != nil {
@@ -54,6 +64,35 @@ func NewCache(repoInfo *domain.RepoInfo, store storage.Storage) (*Cache, error)
 	}, nil
 }
 
+// cacheDir returns the local cache directory for a repository: the first
+// segment of its cache path, then the rest with slashes escaped. Nested
+// names (owner/name/services/api) and host-qualified identities
+// (github.com/owner/name) thus never put one cache inside another's
+// working tree.
+func cacheDir(baseDir string, repoInfo *domain.RepoInfo) string {
+	first, rest, _ := strings.Cut(repoInfo.CachePath(), "/")
+	return filepath.Join(baseDir, first, strings.ReplaceAll(rest, "/", nestedNameSeparator))
+}
+
+// migrateNestedCache moves a nested repository's cache from the unescaped
+// path used by older versions, keeping this machine's sync baseline
+func migrateNestedCache(baseDir string, repoInfo *domain.RepoInfo, cachePath string) error {
+	if repoInfo.Host != "" || !strings.Contains(repoInfo.Name, "/") {
+		return nil
+	}
+	legacy := filepath.Join(baseDir, repoInfo.Owner, repoInfo.Name)
+	if _, err := os.Stat(filepath.Join(legacy, ".git")); err != nil {
+		return nil
+	}
+	if _, err := os.Stat(cachePath); err == nil {
+		return nil
+	}
+	if err := os.Rename(legacy, cachePath); err != nil {
+		return domain.Errorf(domain.ErrGitError, "failed to move cache from %s: %v", legacy, err)
+	}
+	return nil
+}
+
 // NewCacheWithRepo creates a cache with a custom repository implementation (for testing)
 func NewCacheWithRepo(repoInfo *domain.RepoInfo, store storage.Storage, repo git.Repository, basePath string) *Cache {
 	return &Cache{
@@ -524,12 +563,17 @@ func (c *Cache) DeleteRemote(ctx context.Context) error {
 		}
 	}
 
-	// Also clean up any legacy flat files that might remain
+	// Also clean up any legacy flat files that might remain, leaving nested
+	// repositories (owner/name/services/api) under this prefix alone
 	files, err := c.storage.List(ctx, prefix+"/")
 	if err != nil {
 		return err
 	}
+	nested := nestedRepoDirs(files)
 	for _, file := range files {
+		if isInNestedRepo(file, prefix, nested) {
+			continue
+		}
 		if err := c.storage.Delete(ctx, file); err != nil {
 			return err
 		}
@@ -538,6 +582,37 @@ func (c *Cache) DeleteRemote(ctx context.Context) error {
 	return nil
 }
 
+// nestedRepoDirs returns the directories of the HEAD objects in files,
+// each of which is the root of a repository
+func nestedRepoDirs(files []string) map[string]bool {
+	dirs := make(map[string]bool)
+	for _, f := range files {
+		if dir, ok := strings.CutSuffix(f, "/HEAD"); ok {
+			dirs[dir] = true
+		}
+	}
+	return dirs
+}
+
+// isInNestedRepo reports whether file belongs to a repository nested below
+// prefix, one of the nested repository roots
+func isInNestedRepo(file, prefix string, nested map[string]bool) bool {
+	dir := file
+	for {
+		i := strings.LastIndex(dir, "/")
+		if i < 0 {
+			return false
+		}
+		dir = dir[:i]
+		if len(dir) <= len(prefix) {
+			return false
+		}
+		if nested[dir] {
+			return true
+		}
+	}
+}
+
 // CacheHealth represents the health status of the cache
 type CacheHealth struct {
 	// Exists indicates if the cache directory exists
@@ -597,11 +672,22 @@ func (c *Cache) Validate() CacheHealth {
 	return health
 }
 
+// SetWorktree selects the LAST_SYNCED marker of a linked git worktree of
+// the project. Worktrees share one cache, but each working tree is synced
+// on its own, so each needs its own baseline.
+func (c *Cache) SetWorktree(id string) {
+	c.worktree = id
+}
+
 // lastSyncedPath returns the absolute path to this cache's LAST_SYNCED file.
 // Stored inside .git/ so worktree operations (e.g. go-git's force-checkout
 // during pull --ref) leave it alone.
 func (c *Cache) lastSyncedPath() string {
-	return filepath.Join(c.baseDir, ".git", LastSyncedFileName)
+	name := LastSyncedFileName
+	if c.worktree != "" {
+		name += "-" + c.worktree
+	}
+	return filepath.Join(c.baseDir, ".git", name)
 }
 
 // ReadLastSynced returns the commit hash this machine last successfully synced
@@ -466,6 +466,53 @@ func TestDeleteRemote_IncludesFormat(t *testing.T) {
 	require.Equal(t, 0, mockStorage.Count())
 }
 
+func TestDeleteRemote_KeepsNestedRepos(t *testing.T) {
+	mockStorage := storage.NewMockStorage()
+	mockRepo := git.NewMockRepository()
+	mockRepo.Init()
+
+	repoInfo := &domain.RepoInfo{Owner: "owner", Name: "repo"}
+	c := NewCacheWithRepo(repoInfo, mockStorage, mockRepo, t.TempDir())
+
+	ctx := context.Background()
+	mockStorage.SetData("owner/repo/objects.pack", []byte("pack"))
+	mockStorage.SetData("owner/repo/HEAD", []byte("head"))
+	mockStorage.SetData("owner/repo/config/.env.age", []byte("legacy"))
+	mockStorage.SetData("owner/repo/services/api/objects.pack", []byte("pack"))
+	mockStorage.SetData("owner/repo/services/api/HEAD", []byte("head"))
+
+	require.NoError(t, c.DeleteRemote(ctx))
+
+	require.Equal(t, 2, mockStorage.Count())
+	_, ok := mockStorage.GetData("owner/repo/services/api/HEAD")
+	require.True(t, ok)
+}
+
+func TestCacheDir_EscapesNestedNames(t *testing.T) {
+	base := t.TempDir()
+	parent := cacheDir(base, &domain.RepoInfo{Owner: "acme", Name: "mono"})
+	nested := cacheDir(base, &domain.RepoInfo{Owner: "acme", Name: "mono/services/api"})
+
+	require.Equal(t, filepath.Join(base, "acme", "mono"), parent)
+	require.Equal(t, filepath.Join(base, "acme", "mono+services+api"), nested)
+}
+
+func TestMigrateNestedCache(t *testing.T) {
+	base := t.TempDir()
+	info := &domain.RepoInfo{Owner: "acme", Name: "mono/services/api"}
+	legacy := filepath.Join(base, "acme", "mono", "services", "api")
+	require.NoError(t, os.MkdirAll(filepath.Join(legacy, ".git"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(legacy, ".git", LastSyncedFileName), []byte("abc"), 0600))
+
+	target := cacheDir(base, info)
+	require.NoError(t, migrateNestedCache(base, info, target))
+
+	require.NoDirExists(t, legacy)
+	data, err := os.ReadFile(filepath.Join(target, ".git", LastSyncedFileName))
+	require.NoError(t, err)
+	require.Equal(t, "abc", string(data))
+}
+
 func TestSyncFromStorage_EmptyRepo_NoVersionCheck(t *testing.T) {
 	mockRepo := git.NewMockRepository()
 	mockRepo.Init()
@@ -534,6 +581,27 @@ func TestCache_LastSynced_RoundTrip(t *testing.T) {
 	require.False(t, mtime.IsZero(), "mtime must be populated for present marker")
 }
 
+func TestCache_LastSynced_PerWorktree(t *testing.T) {
+	mockRepo := git.NewMockRepository()
+	mockRepo.Init()
+	mockStorage := storage.NewMockStorage()
+	repoInfo := &domain.RepoInfo{Owner: "owner", Name: "repo"}
+	dir := t.TempDir()
+	main := NewCacheWithRepo(repoInfo, mockStorage, mockRepo, dir)
+	feature := NewCacheWithRepo(repoInfo, mockStorage, mockRepo, dir)
+	feature.SetWorktree("feature")
+
+	require.NoError(t, main.WriteLastSynced(strings.Repeat("a", 40)))
+	require.NoError(t, feature.WriteLastSynced(strings.Repeat("b", 40)))
+
+	got, _, err := main.ReadLastSynced()
+	require.NoError(t, err)
+	require.Equal(t, strings.Repeat("a", 40), got)
+	got, _, err = feature.ReadLastSynced()
+	require.NoError(t, err)
+	require.Equal(t, strings.Repeat("b", 40), got)
+}
+
 func TestCache_LastSynced_MissingIsEmpty(t *testing.T) {
 	mockRepo := git.NewMockRepository()
 	mockRepo.Init()
@@ -0,0 +1,115 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charliek/envsecrets/internal/constants"
+	"github.com/charliek/envsecrets/internal/domain"
+	limitedio "github.com/charliek/envsecrets/internal/io"
+	"github.com/charliek/envsecrets/internal/storage"
+)
+
+// MoveRemote moves a repository's objects in storage from one identity's
+// prefix to another's. HEAD is copied last and deleted first, so the
+// repository exists under exactly one prefix at every point a reader can
+// see. Repositories nested below the old prefix are left alone. It fails
+// if the new prefix already holds a repository.
+func MoveRemote(ctx context.Context, store storage.Storage, from, to *domain.RepoInfo) error {
+	oldPrefix := from.CachePath()
+	newPrefix := to.CachePath()
+
+	exists, err := store.Exists(ctx, newPrefix+"/HEAD")
+	if err != nil {
+		return err
+	}
+	if exists {
+		return domain.Errorf(domain.ErrConflict, "%s already exists in storage", newPrefix)
+	}
+
+	files, err := store.List(ctx, oldPrefix+"/")
+	if err != nil {
+		return err
+	}
+	nested := nestedRepoDirs(files)
+	headPath := oldPrefix + "/HEAD"
+	var moved []string
+	hasHead := false
+	for _, file := range files {
+		if isInNestedRepo(file, oldPrefix, nested) {
+			continue
+		}
+		if file == headPath {
+			hasHead = true
+			continue
+		}
+		moved = append(moved, file)
+	}
+	if !hasHead {
+		return domain.Errorf(domain.ErrRepoNotFound, "%s not found in storage", oldPrefix)
+	}
+	moved = append(moved, headPath)
+
+	for _, file := range moved {
+		if err := copyObject(ctx, store, file, newPrefix+strings.TrimPrefix(file, oldPrefix)); err != nil {
+			return err
+		}
+	}
+
+	// Delete HEAD first so a failure part way leaves only the new copy visible
+	for i := len(moved) - 1; i >= 0; i-- {
+		if err := store.Delete(ctx, moved[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyObject copies one storage object
+func copyObject(ctx context.Context, store storage.Storage, from, to string) error {
+	r, err := store.Download(ctx, from)
+	if err != nil {
+		return err
+	}
+	data, err := limitedio.LimitedReadAll(r, MaxPackfileSize, from)
+	r.Close()
+	if err != nil {
+		return domain.Errorf(domain.ErrDownloadFailed, "failed to read %s: %v", from, err)
+	}
+	if err := store.Upload(ctx, to, bytes.NewReader(data)); err != nil {
+		return domain.Errorf(domain.ErrUploadFailed, "failed to upload %s: %v", to, err)
+	}
+	return nil
+}
+
+// MoveLocal renames a repository's local cache directory to the one used by
+// another identity, keeping this machine's sync baseline. It does nothing
+// when there is no cache to move.
+func MoveLocal(from, to *domain.RepoInfo) error {
+	return moveLocal(constants.DefaultCacheDir(), from, to)
+}
+
+func moveLocal(baseDir string, from, to *domain.RepoInfo) error {
+	oldDir := cacheDir(baseDir, from)
+	newDir := cacheDir(baseDir, to)
+
+	if _, err := os.Stat(oldDir); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return domain.Errorf(domain.ErrGitError, "failed to read cache %s: %v", oldDir, err)
+	}
+	if _, err := os.Stat(newDir); err == nil {
+		return domain.Errorf(domain.ErrConflict, "cache %s already exists", newDir)
+	}
+	if err := os.MkdirAll(filepath.Dir(newDir), 0700); err != nil {
+		return domain.Errorf(domain.ErrGitError, "failed to create cache directory: %v", err)
+	}
+	if err := os.Rename(oldDir, newDir); err != nil {
+		return domain.Errorf(domain.ErrGitError, "failed to move cache to %s: %v", newDir, err)
+	}
+	return nil
+}
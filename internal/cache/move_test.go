@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charliek/envsecrets/internal/domain"
+	"github.com/charliek/envsecrets/internal/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMoveRemote(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMockStorage()
+	store.SetData("acme/api/objects.pack", []byte("pack"))
+	store.SetData("acme/api/refs", []byte("refs"))
+	store.SetData("acme/api/HEAD", []byte("head"))
+	store.SetData("acme/api/services/web/HEAD", []byte("nested"))
+
+	from := &domain.RepoInfo{Owner: "acme", Name: "api"}
+	to := &domain.RepoInfo{Host: "github.com", Owner: "acme", Name: "api"}
+	require.NoError(t, MoveRemote(ctx, store, from, to))
+
+	data, ok := store.GetData("github.com/acme/api/objects.pack")
+	require.True(t, ok)
+	require.Equal(t, "pack", string(data))
+	_, ok = store.GetData("github.com/acme/api/HEAD")
+	require.True(t, ok)
+	_, ok = store.GetData("acme/api/HEAD")
+	require.False(t, ok)
+
+	// Nested repositories keep their own prefix
+	_, ok = store.GetData("acme/api/services/web/HEAD")
+	require.True(t, ok)
+	require.Equal(t, 4, store.Count())
+}
+
+func TestMoveRemote_TargetExists(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMockStorage()
+	store.SetData("acme/api/HEAD", []byte("head"))
+	store.SetData("github.com/acme/api/HEAD", []byte("other"))
+
+	from := &domain.RepoInfo{Owner: "acme", Name: "api"}
+	to := &domain.RepoInfo{Host: "github.com", Owner: "acme", Name: "api"}
+	require.ErrorIs(t, MoveRemote(ctx, store, from, to), domain.ErrConflict)
+
+	_, ok := store.GetData("acme/api/HEAD")
+	require.True(t, ok)
+}
+
+func TestMoveLocal(t *testing.T) {
+	base := t.TempDir()
+	from := &domain.RepoInfo{Owner: "acme", Name: "api"}
+	to := &domain.RepoInfo{Host: "github.com", Owner: "acme", Name: "api"}
+	require.NoError(t, os.MkdirAll(filepath.Join(cacheDir(base, from), ".git"), 0700))
+
+	require.NoError(t, moveLocal(base, from, to))
+
+	require.Equal(t, filepath.Join(base, "github.com", "acme+api"), cacheDir(base, to))
+	require.DirExists(t, filepath.Join(cacheDir(base, to), ".git"))
+	require.NoDirExists(t, cacheDir(base, from))
+
+	// Nothing to move is not an error
+	require.NoError(t, moveLocal(base, from, to))
+}
@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charliek/envsecrets/internal/domain"
+	"github.com/charliek/envsecrets/internal/project"
+	"github.com/charliek/envsecrets/internal/sync"
+	"github.com/charliek/envsecrets/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	addDryRun bool
+	addPush   bool
+)
+
+var addCmd = &cobra.Command{
+	Use:   "add <file>...",
+	Short: "Start tracking files",
+	Long: `Start tracking one or more files (or glob patterns).
+
+Each path is validated and appended to .envsecrets. If the project's
+.gitignore does not already ignore the file, it is added to the managed
+"# envsecrets" block so the plaintext never reaches git. Files already
+committed to the project's git are refused; untrack them first with
+'git rm --cached <file>'.
+
+Paths are relative to the current directory. Use --push to upload the new
+files immediately.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runAdd,
+}
+
+func init() {
+	addCmd.Flags().BoolVar(&addDryRun, "dry-run", false, "show what would change without changing anything")
+	addCmd.Flags().BoolVar(&addPush, "push", false, "push after adding")
+}
+
+func runAdd(cmd *cobra.Command, args []string) error {
+	ctx, cancel := signalContext()
+	defer cancel()
+	out := GetOutput()
+
+	if addDryRun {
+		out.PrintDryRunHeader()
+	}
+
+	discovery, err := project.NewDiscovery("")
+	if err != nil {
+		return err
+	}
+
+	// Validate everything before touching any file
+	entries := make([]string, 0, len(args))
+	for _, arg := range args {
+		entry, err := discovery.RelPath(arg)
+		if err != nil {
+			return err
+		}
+		if err := project.ValidateTrackedEntry(entry); err != nil {
+			return err
+		}
+		committed, err := discovery.CommittedFiles(entry)
+		if err != nil {
+			return err
+		}
+		if len(committed) > 0 {
+			return domain.Errorf(domain.ErrPermissionDenied,
+				"%s is committed to git; remove it from the index first with: git rm --cached %s",
+				strings.Join(committed, ", "), strings.Join(committed, " "))
+		}
+		if !project.IsGlobPattern(entry) && !discovery.FileExists(entry) {
+			out.Warn("%s does not exist yet", entry)
+		}
+		entries = append(entries, entry)
+	}
+
+	for _, entry := range entries {
+		// Outside git there is no .gitignore to enforce
+		ignored := true
+		if discovery.IsGitRepo() {
+			if ignored, err = discovery.IsIgnored(entry); err != nil {
+				return err
+			}
+		}
+
+		if addDryRun {
+			out.Printf("Would track %s\n", entry)
+			if !ignored {
+				out.Printf("Would add %s to .gitignore\n", entry)
+			}
+			continue
+		}
+
+		if err := project.AddToTracked(discovery.EnvSecretsFile(), entry); err != nil {
+			return err
+		}
+		if !ignored {
+			if err := project.AddToGitignoreMarker(discovery.GitignorePath(), entry); err != nil {
+				return err
+			}
+			out.Printf("Added %s to .gitignore\n", entry)
+		}
+		out.Printf("Tracking %s\n", entry)
+	}
+
+	if addDryRun || !addPush {
+		return nil
+	}
+
+	pc, err := NewProjectContext(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer pc.Close()
+
+	syncer := sync.NewSyncer(pc.Discovery, pc.RepoInfo, pc.Storage, pc.Encrypter, pc.Cache)
+	result, err := syncer.Push(ctx, sync.PushOptions{
+		Message: "Add " + strings.Join(entries, ", "),
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrNothingToCommit) {
+			out.Println("Nothing to push")
+			return nil
+		}
+		return fmt.Errorf("files are tracked but push failed: %w; run 'envsecrets push' to retry", err)
+	}
+
+	out.Printf("Pushed commit %s\n", ui.TruncateHash(result.CommitHash))
+	if result.Warning != "" {
+		out.Warn("%s", result.Warning)
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charliek/envsecrets/internal/domain"
+	"github.com/charliek/envsecrets/internal/export"
+	"github.com/charliek/envsecrets/internal/sync"
+	"github.com/charliek/envsecrets/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// defaultGitLabDotenv is the dotenv report ci-export writes for GitLab
+const defaultGitLabDotenv = "envsecrets.env"
+
+var (
+	ciProvider string
+	ciRef      string
+	ciOutput   string
+)
+
+var ciExportCmd = &cobra.Command{
+	Use:   "ci-export [file]...",
+	Short: "Load secrets into the environment of later CI steps",
+	Long: `Load the variables of tracked env files into a CI job's environment.
+
+github   prints an ::add-mask:: command for every value, so the runner hides
+         it in logs, then appends the variables to $GITHUB_ENV for the
+         following steps of the job
+gitlab   writes a dotenv report (default envsecrets.env) to declare under
+         artifacts:reports:dotenv; GitLab cannot mask values set at runtime
+
+The provider is detected from GITHUB_ACTIONS or GITLAB_CI when --provider is
+not given. Files are decrypted from the remote HEAD (or --ref); without
+arguments every tracked file is loaded, in .envsecrets order, and later files
+win. Prompts are disabled, so a missing passphrase fails the step.`,
+	Example: `  envsecrets ci-export
+  envsecrets ci-export --provider gitlab .env .env.ci`,
+	RunE: runCIExport,
+}
+
+func init() {
+	ciExportCmd.Flags().StringVar(&ciProvider, "provider", "", "CI provider: "+strings.Join(export.Providers, ", ")+" (default: detected)")
+	ciExportCmd.Flags().StringVar(&ciRef, "ref", "", "load a specific version (commit hash)")
+	ciExportCmd.Flags().StringVarP(&ciOutput, "output", "o", "", "file to write (default: $GITHUB_ENV, or "+defaultGitLabDotenv+" for gitlab)")
+}
+
+func runCIExport(cmd *cobra.Command, args []string) error {
+	// CI has no terminal to prompt on
+	ui.SetNonInteractive(true)
+
+	ctx, cancel := signalContext()
+	defer cancel()
+	out := GetOutput()
+
+	provider, err := detectCIProvider(ciProvider)
+	if err != nil {
+		return err
+	}
+	path, err := ciOutputPath(provider)
+	if err != nil {
+		return err
+	}
+
+	pc, err := NewProjectContext(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer pc.Close()
+
+	loaded, err := readCIFiles(ctx, pc, out, args)
+	if err != nil {
+		return err
+	}
+	if len(loaded) == 0 {
+		return domain.Errorf(domain.ErrFileNotFound, "none of the tracked files have been pushed")
+	}
+	vars := mergeEnvVars(loaded)
+
+	var buf bytes.Buffer
+	switch provider {
+	case export.ProviderGitHub:
+		// Masks go out before anything else could print a value
+		if err := export.WriteGitHubMasks(os.Stdout, vars); err != nil {
+			return err
+		}
+		if err := export.WriteGitHubEnv(&buf, vars); err != nil {
+			return err
+		}
+		if err := appendFile(path, buf.Bytes()); err != nil {
+			return err
+		}
+	case export.ProviderGitLab:
+		if err := export.WriteGitLabDotenv(&buf, vars); err != nil {
+			return err
+		}
+		if err := writePrivateFile(path, buf.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	if out.IsJSON() {
+		keys := make([]string, len(vars))
+		for i, v := range vars {
+			keys[i] = v.Key
+		}
+		return out.JSON(map[string]any{"provider": provider, "output": path, "keys": keys})
+	}
+	out.Printf("Exported %d variable(s) from %d file(s) to %s\n", len(vars), len(loaded), path)
+	return nil
+}
+
+// readCIFiles loads the requested tracked files, or every tracked file, from
+// the cache at --ref or the remote HEAD. The cache is synced before the files
+// are resolved, since a CI checkout has none of them locally.
+func readCIFiles(ctx context.Context, pc *ProjectContext, out *ui.Output, args []string) ([]envFile, error) {
+	files, err := resolveTrackedFiles(ctx, pc, args, true)
+	if err != nil {
+		return nil, err
+	}
+	syncer := sync.NewSyncer(pc.Discovery, pc.RepoInfo, pc.Storage, pc.Encrypter, pc.Cache)
+	return loadEnvFiles(out, files, len(args) > 0, func(file string) ([]byte, error) {
+		return syncer.ReadFileAt(file, ciRef)
+	})
+}
+
+// detectCIProvider validates --provider, or detects the provider from the
+// variables each CI sets
+func detectCIProvider(provider string) (string, error) {
+	switch {
+	case provider == export.ProviderGitHub, provider == export.ProviderGitLab:
+		return provider, nil
+	case provider != "":
+		return "", domain.Errorf(domain.ErrInvalidArgs, "unknown provider %q (supported: %s)", provider, strings.Join(export.Providers, ", "))
+	case os.Getenv("GITHUB_ACTIONS") == "true":
+		return export.ProviderGitHub, nil
+	case os.Getenv("GITLAB_CI") == "true":
+		return export.ProviderGitLab, nil
+	}
+	return "", domain.Errorf(domain.ErrInvalidArgs, "could not detect the CI provider; use --provider %s", strings.Join(export.Providers, "|"))
+}
+
+// ciOutputPath returns --output, or the provider's default file
+func ciOutputPath(provider string) (string, error) {
+	if ciOutput != "" {
+		return ciOutput, nil
+	}
+	if provider == export.ProviderGitLab {
+		return defaultGitLabDotenv, nil
+	}
+	path := os.Getenv("GITHUB_ENV")
+	if path == "" {
+		return "", domain.Errorf(domain.ErrInvalidArgs, "GITHUB_ENV is not set; run inside a GitHub Actions step or use --output")
+	}
+	return path, nil
+}
+
+// appendFile appends data to path, creating it with mode 0600 if needed
+func appendFile(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charliek/envsecrets/internal/domain"
+	"github.com/charliek/envsecrets/internal/export"
+	"github.com/charliek/envsecrets/internal/ui"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectCIProvider(t *testing.T) {
+	t.Setenv("GITHUB_ACTIONS", "")
+	t.Setenv("GITLAB_CI", "")
+
+	_, err := detectCIProvider("")
+	require.ErrorIs(t, err, domain.ErrInvalidArgs)
+	_, err = detectCIProvider("jenkins")
+	require.ErrorIs(t, err, domain.ErrInvalidArgs)
+
+	provider, err := detectCIProvider(export.ProviderGitLab)
+	require.NoError(t, err)
+	require.Equal(t, export.ProviderGitLab, provider)
+
+	t.Setenv("GITLAB_CI", "true")
+	provider, err = detectCIProvider("")
+	require.NoError(t, err)
+	require.Equal(t, export.ProviderGitLab, provider)
+
+	t.Setenv("GITHUB_ACTIONS", "true")
+	provider, err = detectCIProvider("")
+	require.NoError(t, err)
+	require.Equal(t, export.ProviderGitHub, provider)
+}
+
+func TestCIOutputPath(t *testing.T) {
+	t.Setenv("GITHUB_ENV", "")
+	_, err := ciOutputPath(export.ProviderGitHub)
+	require.ErrorIs(t, err, domain.ErrInvalidArgs)
+
+	t.Setenv("GITHUB_ENV", "/tmp/github_env")
+	path, err := ciOutputPath(export.ProviderGitHub)
+	require.NoError(t, err)
+	require.Equal(t, "/tmp/github_env", path)
+
+	path, err = ciOutputPath(export.ProviderGitLab)
+	require.NoError(t, err)
+	require.Equal(t, defaultGitLabDotenv, path)
+}
+
+func TestAppendFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "github_env")
+	require.NoError(t, appendFile(path, []byte("A=1\n")))
+	require.NoError(t, appendFile(path, []byte("B=2\n")))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "A=1\nB=2\n", string(content))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestReadCIFiles_GlobWithEmptyCache(t *testing.T) {
+	remote := newTestRemote()
+	remote.push(t, remote.newMachine(t, map[string]string{
+		".envsecrets":     "config/*.env\n",
+		".gitignore":      "*.env\n",
+		"config/base.env": "A=1\nB=1\n",
+		"config/prod.env": "B=prod\n",
+	}))
+
+	// A CI checkout: only .envsecrets is committed and the cache is empty
+	pc := remote.newMachine(t, map[string]string{".envsecrets": "config/*.env\n"})
+	var buf bytes.Buffer
+	out := ui.NewOutputWithWriters(&buf, &buf, false, false)
+
+	loaded, err := readCIFiles(context.Background(), pc, out, nil)
+	require.NoError(t, err)
+	require.Equal(t, []export.Var{{Key: "A", Value: "1"}, {Key: "B", Value: "prod"}}, mergeEnvVars(loaded))
+
+	loaded, err = readCIFiles(context.Background(), pc, out, []string{"config/prod.env"})
+	require.NoError(t, err)
+	require.Equal(t, []export.Var{{Key: "B", Value: "prod"}}, mergeEnvVars(loaded))
+}
@@ -0,0 +1,191 @@
+package cli
+
+import (
+	"github.com/charliek/envsecrets/internal/config"
+	"github.com/charliek/envsecrets/internal/domain"
+	"github.com/spf13/cobra"
+)
+
+var configReveal bool
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect and edit the configuration file",
+	Long: `Inspect and edit ~/.envsecrets/config.yaml without rerunning init.
+
+Keys are the YAML field names (bucket, passphrase_env, ...). Profile fields
+are addressed as profiles.<name>.<key>. Edits keep comments, key order, and
+unknown keys intact, and the file is validated before it is written.`,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print a config value",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runConfigGet,
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>...",
+	Short: "Set a config value",
+	Long: `Set a config value.
+
+List keys (passphrase_command_args, allowed_buckets, remotes) take one or more values,
+or a single JSON array:
+
+  envsecrets config set passphrase_command_args op read op://vault/envsecrets/password
+  envsecrets config set profiles.work.bucket acme-envsecrets`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: runConfigSet,
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset <key>",
+	Short: "Remove a config value",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runConfigUnset,
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all values in the config file",
+	Args:  cobra.NoArgs,
+	RunE:  runConfigList,
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the config file for errors",
+	Args:  cobra.NoArgs,
+	RunE:  runConfigValidate,
+}
+
+var configPathCmd = &cobra.Command{
+	Use:   "path",
+	Short: "Print the config file path",
+	Args:  cobra.NoArgs,
+	RunE:  runConfigPath,
+}
+
+func init() {
+	configGetCmd.Flags().BoolVar(&configReveal, "reveal", false, "show secret values instead of [set]")
+	configListCmd.Flags().BoolVar(&configReveal, "reveal", false, "show secret values instead of [set]")
+
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configUnsetCmd)
+	configCmd.AddCommand(configListCmd)
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configPathCmd)
+}
+
+func runConfigGet(cmd *cobra.Command, args []string) error {
+	out := GetOutput()
+
+	editor, err := config.OpenEditor(config.ConfigPath(cfgFile))
+	if err != nil {
+		return err
+	}
+	value, found, err := editor.Get(args[0])
+	if err != nil {
+		return err
+	}
+	if !found {
+		return domain.Errorf(domain.ErrInvalidArgs, "%s is not set in %s", args[0], editor.Path())
+	}
+	if !configReveal {
+		value = config.RedactValue(args[0], value)
+	}
+
+	if out.IsJSON() {
+		return out.JSON(config.Entry{Key: args[0], Value: value})
+	}
+	out.Println(value)
+	return nil
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) error {
+	out := GetOutput()
+
+	editor, err := config.OpenEditor(config.ConfigPath(cfgFile))
+	if err != nil {
+		return err
+	}
+	if err := editor.Set(args[0], args[1:]); err != nil {
+		return err
+	}
+	if err := editor.Save(); err != nil {
+		return err
+	}
+
+	out.Success("Set %s in %s", args[0], editor.Path())
+	return nil
+}
+
+func runConfigUnset(cmd *cobra.Command, args []string) error {
+	out := GetOutput()
+
+	editor, err := config.OpenEditor(config.ConfigPath(cfgFile))
+	if err != nil {
+		return err
+	}
+	removed, err := editor.Unset(args[0])
+	if err != nil {
+		return err
+	}
+	if !removed {
+		out.Printf("%s is not set\n", args[0])
+		return nil
+	}
+	if err := editor.Save(); err != nil {
+		return err
+	}
+
+	out.Success("Removed %s from %s", args[0], editor.Path())
+	return nil
+}
+
+func runConfigList(cmd *cobra.Command, args []string) error {
+	out := GetOutput()
+
+	editor, err := config.OpenEditor(config.ConfigPath(cfgFile))
+	if err != nil {
+		return err
+	}
+	entries := editor.Entries(configReveal)
+
+	if out.IsJSON() {
+		return out.JSON(entries)
+	}
+	if len(entries) == 0 {
+		out.Println("No values set in", editor.Path())
+		return nil
+	}
+	rows := make([][]string, 0, len(entries))
+	for _, e := range entries {
+		rows = append(rows, []string{e.Key, e.Value})
+	}
+	out.Table([]string{"KEY", "VALUE"}, rows)
+	return nil
+}
+
+func runConfigValidate(cmd *cobra.Command, args []string) error {
+	out := GetOutput()
+
+	path := config.ConfigPath(cfgFile)
+	loaded, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+	if err := loaded.CheckCommands(); err != nil {
+		return err
+	}
+
+	out.Success("%s is valid", path)
+	return nil
+}
+
+func runConfigPath(cmd *cobra.Command, args []string) error {
+	GetOutput().Println(config.ConfigPath(cfgFile))
+	return nil
+}
@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/charliek/envsecrets/internal/domain"
+	"github.com/charliek/envsecrets/internal/dotenv"
+	"github.com/charliek/envsecrets/internal/sync"
+	"github.com/charliek/envsecrets/internal/ui"
+)
+
+// conflictResolver resolves conflicting files interactively. Besides taking
+// or leaving the remote file, the user can view a masked key diff, pick a
+// side for each conflicting key, or edit the file with conflict markers.
+func conflictResolver(pc *ProjectContext, out *ui.Output, prompt *ui.Prompt) sync.ConflictResolver {
+	return func(c *sync.Conflict) (sync.Resolution, error) {
+		for {
+			choice, err := prompt.ConflictChoice(c.File, c.Keys)
+			if err != nil {
+				return sync.Resolution{Action: sync.ConflictAbort}, err
+			}
+			switch choice {
+			case "d":
+				if err := printConflictDiff(pc, out, c); err != nil {
+					return sync.Resolution{Action: sync.ConflictAbort}, err
+				}
+			case "k":
+				return resolveConflictKeys(prompt, c)
+			case "e":
+				content, err := editConflict(c)
+				if err != nil {
+					// Let the user edit again or choose another action
+					out.Warn("%v", err)
+					continue
+				}
+				return sync.Resolution{Action: sync.ConflictMerge, Content: content}, nil
+			case "o":
+				return sync.Resolution{Action: sync.ConflictOverwrite}, nil
+			case "s":
+				return sync.Resolution{Action: sync.ConflictSkip}, nil
+			default:
+				return sync.Resolution{Action: sync.ConflictAbort}, nil
+			}
+		}
+	}
+}
+
+// printConflictDiff prints what each side changed since the baseline, with
+// fingerprinted values; without a baseline, local is compared to remote
+func printConflictDiff(pc *ProjectContext, out *ui.Output, c *sync.Conflict) error {
+	fingerprinter, err := pc.Fingerprinter()
+	if err != nil {
+		return err
+	}
+
+	var diffs []keyDiff
+	if c.Base != nil {
+		local := diffFileKeys(c.File, c.Base, c.Local, fingerprinter)
+		local.From, local.To = "base", "local"
+		remote := diffFileKeys(c.File, c.Base, c.Remote, fingerprinter)
+		remote.From, remote.To = "base", "remote"
+		diffs = append(diffs, local, remote)
+	} else {
+		d := diffFileKeys(c.File, c.Local, c.Remote, fingerprinter)
+		d.From, d.To = "local", "remote"
+		diffs = append(diffs, d)
+	}
+	for _, d := range diffs {
+		if d.Error != "" {
+			d.Error = "not a dotenv file; choose [e]dit to compare the versions"
+		}
+		printKeyDiff(out, d)
+	}
+	return nil
+}
+
+// resolveConflictKeys asks which side wins for each conflicting key
+func resolveConflictKeys(prompt *ui.Prompt, c *sync.Conflict) (sync.Resolution, error) {
+	var remote []string
+	for _, key := range c.Keys {
+		choice, err := prompt.KeyConflictChoice(c.File, key)
+		if err != nil {
+			return sync.Resolution{Action: sync.ConflictAbort}, err
+		}
+		switch choice {
+		case "r":
+			remote = append(remote, key)
+		case "l":
+			// Keep the local value already in the merge
+		default:
+			return sync.Resolution{Action: sync.ConflictAbort}, nil
+		}
+	}
+	return sync.Resolution{Action: sync.ConflictMerge, Content: c.Merged(remote...)}, nil
+}
+
+// editConflict opens the conflict, with markers, in the user's editor and
+// returns the edited content once no markers remain
+func editConflict(c *sync.Conflict) ([]byte, error) {
+	path, _, cleanup, err := secureTempFile(c.File)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	if err := os.WriteFile(path, c.Marked(), 0600); err != nil {
+		return nil, fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := runEditor(path); err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read temp file: %w", err)
+	}
+
+	if dotenv.HasConflictMarkers(content) {
+		return nil, domain.Errorf(domain.ErrInvalidArgs, "%s still has conflict markers", c.File)
+	}
+	if c.Mergeable() {
+		if _, err := dotenv.Parse(content); err != nil {
+			return nil, domain.Errorf(domain.ErrInvalidArgs, "edited %s is not a valid env file: %v", c.File, err)
+		}
+	}
+	return content, nil
+}
@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charliek/envsecrets/internal/sync"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeEditor installs a shell script as $EDITOR that replaces the edited
+// file with content
+func fakeEditor(t *testing.T, content string) {
+	t.Helper()
+	src := filepath.Join(t.TempDir(), "content")
+	require.NoError(t, os.WriteFile(src, []byte(content), 0600))
+	script := filepath.Join(t.TempDir(), "editor")
+	require.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\ncp '"+src+"' \"$1\"\n"), 0700))
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", script)
+}
+
+func TestEditConflict(t *testing.T) {
+	c := &sync.Conflict{File: "app/.env", Local: []byte("A=1\n"), Remote: []byte("A=2\n")}
+
+	fakeEditor(t, "A=3\n")
+	content, err := editConflict(c)
+	require.NoError(t, err)
+	require.Equal(t, "A=3\n", string(content))
+
+	fakeEditor(t, "<<<<<<< local\nA=1\n=======\nA=2\n>>>>>>> remote\n")
+	_, err = editConflict(c)
+	require.ErrorContains(t, err, "conflict markers")
+}
@@ -1,16 +1,25 @@
 package cli
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
 	"strings"
 
+	"github.com/charliek/envsecrets/internal/crypto"
 	"github.com/charliek/envsecrets/internal/domain"
+	"github.com/charliek/envsecrets/internal/dotenv"
 	"github.com/charliek/envsecrets/internal/sync"
 	"github.com/charliek/envsecrets/internal/ui"
 	"github.com/sergi/go-diff/diffmatchpatch"
 	"github.com/spf13/cobra"
 )
 
+var (
+	diffKeys       bool
+	diffShowValues bool
+)
+
 var diffCmd = &cobra.Command{
 	Use:   "diff [ref1] [ref2]",
 	Short: "Show changes between versions",
@@ -18,11 +27,40 @@ var diffCmd = &cobra.Command{
 
 If no refs are provided, shows diff between local files and latest remote.
 If one ref is provided, shows diff between that ref and current local.
-If two refs are provided, shows diff between those refs.`,
+If two refs are provided, shows diff between those refs.
+
+With --keys, diff lists the added, removed, and changed keys of each file
+instead of lines. Values are shown as short fingerprints (a keyed hash that
+is the same for everyone sharing the passphrase) unless --show-values is
+set. --keys is the default when output is not a terminal or is JSON; use
+--keys=false for a line diff.`,
 	Args: cobra.MaximumNArgs(2),
 	RunE: runDiff,
 }
 
+func init() {
+	diffCmd.Flags().BoolVar(&diffKeys, "keys", false, "show changed keys with masked values (default when output is not a terminal)")
+	diffCmd.Flags().BoolVar(&diffShowValues, "show-values", false, "show values instead of fingerprints in key diffs")
+}
+
+// keyDiff is the key-level diff of one file
+type keyDiff struct {
+	File    string          `json:"file"`
+	From    string          `json:"from"`
+	To      string          `json:"to"`
+	Changes []keyDiffChange `json:"changes,omitempty"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// keyDiffChange is one changed key; values are fingerprints unless
+// --show-values is set
+type keyDiffChange struct {
+	Key    string            `json:"key"`
+	Change dotenv.ChangeKind `json:"change"`
+	Old    string            `json:"old,omitempty"`
+	New    string            `json:"new,omitempty"`
+}
+
 func runDiff(cmd *cobra.Command, args []string) error {
 	ctx, cancel := signalContext()
 	defer cancel()
@@ -49,85 +87,90 @@ func runDiff(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	var ref1, ref2 string
+	// Diffs run from the old side to the new side; "" is the working tree
+	var oldRef, newRef, oldLabel, newLabel string
 	switch len(args) {
 	case 0:
-		// Compare local to HEAD (remote)
-		ref2 = "HEAD"
+		// Compare remote to local
+		oldRef, oldLabel, newLabel = "HEAD", "remote", "local"
 	case 1:
-		// Compare local to specified ref
-		ref2 = args[0]
+		// Compare the specified ref to local
+		oldRef, oldLabel, newLabel = args[0], args[0], "local"
 	case 2:
 		// Compare two refs
-		ref1 = args[0]
-		ref2 = args[1]
+		oldRef, newRef, oldLabel, newLabel = args[0], args[1], args[0], args[1]
 	}
 
-	hasChanges := false
-
-	for _, file := range files {
-		var content1, content2 []byte
-
-		if ref1 == "" {
-			// Get local content
-			content1, err = pc.ReadProjectFile(file)
-			if err != nil {
-				if !errors.Is(err, domain.ErrFileNotFound) {
-					out.Verbose("Warning: could not read local file %s: %v", file, err)
-				}
-				content1 = nil
-			}
+	// readAt returns a file's content at ref, or nil when it does not exist
+	readAt := func(file, ref string) []byte {
+		var content []byte
+		var err error
+		if ref == "" {
+			content, err = pc.ReadProjectFile(file)
 		} else {
-			// Get content from ref1
-			content1, err = syncer.PullFile(ctx, file, ref1)
-			if err != nil {
-				if !errors.Is(err, domain.ErrFileNotFound) {
-					out.Verbose("Warning: could not read %s at %s: %v", file, ref1, err)
-				}
-				content1 = nil
-			}
+			content, err = syncer.PullFile(ctx, file, ref)
 		}
-
-		// Get content from ref2
-		content2, err = syncer.PullFile(ctx, file, ref2)
 		if err != nil {
 			if !errors.Is(err, domain.ErrFileNotFound) {
-				out.Verbose("Warning: could not read %s at %s: %v", file, ref2, err)
+				where := ref
+				if where == "" {
+					where = "local"
+				}
+				out.Verbose("Warning: could not read %s at %s: %v", file, where, err)
 			}
-			content2 = nil
+			return nil
 		}
+		return content
+	}
 
-		// Compare
-		if string(content1) == string(content2) {
-			continue
+	keys := diffKeys
+	if !cmd.Flags().Changed("keys") {
+		keys = out.IsJSON() || !ui.IsOutputTerminal()
+	}
+	var fingerprinter *crypto.Fingerprinter
+	if keys && !diffShowValues {
+		if fingerprinter, err = pc.Fingerprinter(); err != nil {
+			return err
 		}
+	}
 
+	var diffs []keyDiff
+	hasChanges := false
+	for _, file := range files {
+		oldContent := readAt(file, oldRef)
+		newContent := readAt(file, newRef)
+		if bytes.Equal(oldContent, newContent) {
+			continue
+		}
 		hasChanges = true
 
-		// Print diff header based on argument count
-		switch len(args) {
-		case 0:
-			out.Printf("--- %s (remote)\n", file)
-			out.Printf("+++ %s (local)\n", file)
-		case 1:
-			out.Printf("--- %s (%s)\n", file, ref2)
-			out.Printf("+++ %s (local)\n", file)
-		case 2:
-			out.Printf("--- %s (%s)\n", file, ref1)
-			out.Printf("+++ %s (%s)\n", file, ref2)
+		if keys {
+			d := diffFileKeys(file, oldContent, newContent, fingerprinter)
+			d.From, d.To = oldLabel, newLabel
+			diffs = append(diffs, d)
+			if !out.IsJSON() {
+				printKeyDiff(out, d)
+			}
+			continue
 		}
 
-		// Proper line-by-line diff
-		if len(args) == 2 {
-			// Two refs: show changes from ref1 to ref2
-			printLineDiff(out, string(content1), string(content2))
-		} else {
-			// 0 or 1 ref: show changes from remote/ref to local
-			printLineDiff(out, string(content2), string(content1))
-		}
+		out.Printf("--- %s (%s)\n", file, oldLabel)
+		out.Printf("+++ %s (%s)\n", file, newLabel)
+		printLineDiff(out, string(oldContent), string(newContent))
 		out.Println()
 	}
 
+	if out.IsJSON() {
+		values := "fingerprint"
+		if diffShowValues {
+			values = "plain"
+		}
+		if diffs == nil {
+			diffs = []keyDiff{}
+		}
+		return out.JSON(map[string]interface{}{"values": values, "files": diffs})
+	}
+
 	if !hasChanges {
 		out.Println("No changes")
 	}
@@ -135,6 +178,82 @@ func runDiff(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// diffFileKeys compares two versions of a file key by key. Values are
+// fingerprinted unless fingerprinter is nil. A version that does not parse
+// is reported as an error rather than shown.
+func diffFileKeys(file string, oldContent, newContent []byte, fingerprinter *crypto.Fingerprinter) keyDiff {
+	d := keyDiff{File: file}
+	oldFile, err := parseOptional(oldContent)
+	if err != nil {
+		d.Error = fmt.Sprintf("not a dotenv file (%v); use --keys=false for a line diff", err)
+		return d
+	}
+	newFile, err := parseOptional(newContent)
+	if err != nil {
+		d.Error = fmt.Sprintf("not a dotenv file (%v); use --keys=false for a line diff", err)
+		return d
+	}
+	for _, c := range dotenv.Diff(oldFile, newFile) {
+		d.Changes = append(d.Changes, keyDiffChange{
+			Key:    c.Key,
+			Change: c.Kind,
+			Old:    maskValue(c.Old, c.Kind != dotenv.Added, fingerprinter),
+			New:    maskValue(c.New, c.Kind != dotenv.Removed, fingerprinter),
+		})
+	}
+	return d
+}
+
+// parseOptional parses content, returning nil for a file that does not exist
+func parseOptional(content []byte) (*dotenv.File, error) {
+	if content == nil {
+		return nil, nil
+	}
+	return dotenv.Parse(content)
+}
+
+// maskValue returns how a value is shown in a key diff: its fingerprint,
+// or the value itself when fingerprinter is nil
+func maskValue(value string, present bool, fingerprinter *crypto.Fingerprinter) string {
+	if !present {
+		return ""
+	}
+	if fingerprinter == nil {
+		return value
+	}
+	if value == "" {
+		return "(empty)"
+	}
+	return fingerprinter.Fingerprint(value)
+}
+
+// printKeyDiff prints one file's key diff
+func printKeyDiff(out *ui.Output, d keyDiff) {
+	out.Printf("%s (%s -> %s)\n", d.File, d.From, d.To)
+	if d.Error != "" {
+		out.Printf("  %s\n", d.Error)
+	}
+	for _, c := range d.Changes {
+		switch c.Change {
+		case dotenv.Added:
+			out.Printf("  + %s  %s\n", c.Key, displayValue(c.New))
+		case dotenv.Removed:
+			out.Printf("  - %s  %s\n", c.Key, displayValue(c.Old))
+		case dotenv.Changed:
+			out.Printf("  ~ %s  %s -> %s\n", c.Key, displayValue(c.Old), displayValue(c.New))
+		}
+	}
+	out.Println()
+}
+
+// displayValue quotes a shown value so whitespace and newlines are visible
+func displayValue(value string) string {
+	if value == "" {
+		return `""`
+	}
+	return dotenv.QuoteValue(value)
+}
+
 func printLineDiff(out *ui.Output, old, new string) {
 	dmp := diffmatchpatch.New()
 
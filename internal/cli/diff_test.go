@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"testing"
 
+	"github.com/charliek/envsecrets/internal/crypto"
+	"github.com/charliek/envsecrets/internal/dotenv"
 	"github.com/charliek/envsecrets/internal/ui"
 	"github.com/stretchr/testify/require"
 )
@@ -124,3 +126,37 @@ func TestSplitDiffLines(t *testing.T) {
 		})
 	}
 }
+
+func TestDiffFileKeys(t *testing.T) {
+	f, err := crypto.NewFingerprinter("passphrase", "acme/api")
+	require.NoError(t, err)
+
+	old := []byte("A=1\nB=secret\nC=gone\n")
+	new := []byte("A=1\nB=rotated\nD=\n")
+
+	d := diffFileKeys(".env", old, new, f)
+	require.Empty(t, d.Error)
+	require.Equal(t, []keyDiffChange{
+		{Key: "B", Change: dotenv.Changed, Old: f.Fingerprint("secret"), New: f.Fingerprint("rotated")},
+		{Key: "D", Change: dotenv.Added, New: "(empty)"},
+		{Key: "C", Change: dotenv.Removed, Old: f.Fingerprint("gone")},
+	}, d.Changes)
+
+	var buf bytes.Buffer
+	out := ui.NewOutputWithWriters(&buf, &buf, false, false)
+	d.From, d.To = "remote", "local"
+	printKeyDiff(out, d)
+	require.NotContains(t, buf.String(), "secret")
+	require.NotContains(t, buf.String(), "rotated")
+	require.Contains(t, buf.String(), ".env (remote -> local)")
+	require.Contains(t, buf.String(), "~ B  "+f.Fingerprint("secret")+" -> "+f.Fingerprint("rotated"))
+
+	// Without a fingerprinter values are shown
+	d = diffFileKeys(".env", nil, []byte("A=two words\n"), nil)
+	require.Equal(t, []keyDiffChange{{Key: "A", Change: dotenv.Added, New: "two words"}}, d.Changes)
+
+	// Unparseable content is never shown
+	d = diffFileKeys("cert.pem", []byte("-----BEGIN secret\n"), nil, f)
+	require.Contains(t, d.Error, "not a dotenv file")
+	require.Empty(t, d.Changes)
+}
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/charliek/envsecrets/internal/cache"
@@ -14,7 +15,8 @@ import (
 )
 
 var (
-	doctorFix bool
+	doctorFix         bool
+	doctorAllProfiles bool
 )
 
 var doctorCmd = &cobra.Command{
@@ -24,17 +26,25 @@ var doctorCmd = &cobra.Command{
 
 This command checks:
 - Configuration file exists and is valid
+- Effective configuration values and their sources (config file,
+  profile, or ENVSECRETS_* environment variable)
 - GCS bucket is accessible
 - Passphrase is available
 - Current directory is a git repository (optional)
+- Tracked files are git-ignored and absent from the git index and history
 - Local cache health
 
-Use --fix to attempt automatic repair of cache issues.`,
+Use --all-profiles to check the bucket and passphrase of every configured
+profile, not just the selected one.
+
+Use --fix to attempt automatic repair of cache issues and to add tracked
+files that are not git-ignored to the "# envsecrets" block in .gitignore.`,
 	RunE: runDoctor,
 }
 
 func init() {
-	doctorCmd.Flags().BoolVar(&doctorFix, "fix", false, "attempt to fix cache issues by resetting")
+	doctorCmd.Flags().BoolVar(&doctorFix, "fix", false, "attempt to fix cache issues by resetting and add missing .gitignore entries")
+	doctorCmd.Flags().BoolVar(&doctorAllProfiles, "all-profiles", false, "also check the backend of every configured profile")
 }
 
 func runDoctor(cmd *cobra.Command, args []string) error {
@@ -54,90 +64,65 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 		out.Println("  Run 'envsecrets init' to create configuration")
 		return nil
 	}
-	out.Println("OK")
-
-	// Check bucket configuration
-	out.Printf("Bucket configured: ")
-	if cfg.Bucket == "" {
-		out.Println("MISSING")
-		allOK = false
+	if config.Exists(configPath) {
+		out.Println("OK")
 	} else {
-		out.Println(cfg.Bucket)
+		out.Println("NOT FOUND (using environment)")
 	}
 
-	// Check GCS connectivity
-	out.Printf("GCS connectivity: ")
-	store, err := storage.NewGCSStorage(ctx, cfg.Bucket, cfg.GCSCredentials)
-	if err != nil {
-		out.Println("FAILED")
-		out.Printf("  Error: %v\n", err)
-		allOK = false
-	} else {
-		defer store.Close()
-		// Try to list objects to verify access
-		_, err := store.List(ctx, "")
-		if err != nil {
-			out.Println("FAILED")
-			out.Printf("  Error: %v\n", err)
-			allOK = false
-		} else {
-			out.Println("OK")
-		}
+	if name := cfg.Profile(); name != "" {
+		out.Printf("Profile: %s\n", name)
 	}
 
-	// Check passphrase availability
-	out.Printf("Passphrase: ")
-	resolver := config.NewPassphraseResolver(cfg)
-	passphrase, err := resolver.Resolve()
-	if err != nil {
-		out.Println("NOT AVAILABLE")
-		if cfg.PassphraseEnv != "" {
-			out.Printf("  Set environment variable: %s\n", cfg.PassphraseEnv)
-		} else if len(cfg.PassphraseCommandArgs) > 0 {
-			out.Println("  Passphrase command failed to execute")
-		} else {
-			out.Println("  Configure passphrase_env or passphrase_command_args in config")
+	// Show each effective value and where it came from
+	out.Println("Effective configuration:")
+	for _, src := range cfg.Sources() {
+		value := src.Value
+		if value == "" {
+			value = "(unset)"
 		}
+		out.Printf("  %-24s %-28s [%s]\n", src.Key, value, src.Source)
+	}
+
+	store, backendOK := checkBackend(ctx, out, cfg)
+	if store != nil {
+		defer store.Close()
+	}
+	if !backendOK {
 		allOK = false
-	} else {
-		out.Println("OK")
+	}
 
-		// Test encryption/decryption
-		out.Printf("Encryption: ")
-		{
-			encrypter, err := crypto.NewAgeEncrypter(passphrase)
+	// Check the remaining profiles' backends when asked to
+	if doctorAllProfiles {
+		for _, name := range cfg.Persisted().ProfileNames() {
+			if name == cfg.Profile() {
+				continue
+			}
+			out.Println()
+			out.Printf("Profile: %s\n", name)
+			profileCfg, err := cfg.Persisted().ForProfile(name)
+			if err == nil {
+				profileCfg, err = profileCfg.ApplyEnv()
+			}
 			if err != nil {
-				out.Println("FAILED")
 				out.Printf("  Error: %v\n", err)
 				allOK = false
-			} else {
-				testData := []byte("test encryption")
-				encrypted, err := encrypter.Encrypt(testData)
-				if err != nil {
-					out.Println("FAILED")
-					out.Printf("  Encrypt error: %v\n", err)
-					allOK = false
-				} else {
-					decrypted, err := encrypter.Decrypt(encrypted)
-					if err != nil {
-						out.Println("FAILED")
-						out.Printf("  Decrypt error: %v\n", err)
-						allOK = false
-					} else if string(decrypted) != string(testData) {
-						out.Println("FAILED")
-						out.Println("  Round-trip verification failed")
-						allOK = false
-					} else {
-						out.Println("OK")
-					}
-				}
+				continue
+			}
+			profileStore, profileOK := checkBackend(ctx, out, profileCfg)
+			if profileStore != nil {
+				profileStore.Close()
+			}
+			if !profileOK {
+				allOK = false
 			}
 		}
+		out.Println()
 	}
 
 	// Check git repository (optional)
 	out.Printf("Git repository: ")
-	discovery, err := project.NewDiscovery("")
+	discovery, err := discoverProject(cfg)
 	var repoInfoForCache *project.Discovery
 	if err != nil {
 		out.Println("NOT IN REPO")
@@ -149,6 +134,8 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 			out.Println("NO REMOTE")
 			out.Printf("  Error: %v\n", err)
 			repoInfoForCache = nil
+		} else if !discovery.IsGitRepo() {
+			out.Printf("%s (no git; project root %s)\n", repoInfo.String(), discovery.ProjectRoot())
 		} else {
 			out.Println(repoInfo.String())
 		}
@@ -168,6 +155,34 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 				}
 				out.Printf("    %s (%s)\n", f, exists)
 			}
+
+			// Check that tracked files cannot leak into the project's git
+			out.Printf("Git safety: ")
+			issues, err := discovery.CheckSafety(files, project.SafetyOptions{History: true})
+			switch {
+			case err != nil:
+				out.Println("ERROR")
+				out.Printf("  Error: %v\n", err)
+				allOK = false
+			case len(issues) == 0:
+				out.Println("OK")
+			default:
+				out.Printf("%d ISSUE(S)\n", len(issues))
+				printSafetyIssues(out, "  ", issues)
+				allOK = false
+
+				if doctorFix {
+					fixed, err := fixGitignoreIssues(discovery, issues)
+					if err != nil {
+						out.Printf("  Failed to update .gitignore: %v\n", err)
+					} else if fixed > 0 {
+						out.Printf("  Added %d file(s) to .gitignore\n", fixed)
+						if fixed == len(issues) {
+							allOK = true // Fixed!
+						}
+					}
+				}
+			}
 		}
 
 		// Check cache health if we have repo info and storage
@@ -196,7 +211,7 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 						if err := cacheRepo.Reset(ctx); err != nil {
 							out.Println(" FAILED")
 							out.Printf("  Error: %v\n", err)
-							out.Printf("  Manual fix: rm -rf ~/.envsecrets/cache/%s/%s\n", repoInfo.Owner, repoInfo.Name)
+							out.Printf("  Manual fix: rm -rf %s\n", cacheRepo.Path())
 						} else {
 							out.Println(" OK")
 							allOK = true // Fixed!
@@ -297,3 +312,92 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// checkBackend verifies the bucket, GCS connectivity, passphrase, and
+// encryption round-trip for one effective config. The returned store (nil if
+// it could not be created) is left open for the caller to reuse and close.
+func checkBackend(ctx context.Context, out *ui.Output, c *config.Config) (*storage.GCSStorage, bool) {
+	ok := true
+
+	// Check bucket configuration
+	out.Printf("Bucket configured: ")
+	if c.Bucket == "" {
+		out.Println("MISSING")
+		ok = false
+	} else {
+		out.Println(c.Bucket)
+	}
+
+	// Check GCS connectivity
+	out.Printf("GCS connectivity: ")
+	store, err := storage.NewGCSStorage(ctx, c.Bucket, c.GCSCredentials)
+	if err != nil {
+		out.Println("FAILED")
+		out.Printf("  Error: %v\n", err)
+		ok = false
+	} else {
+		// Try to list objects to verify access
+		_, err := store.List(ctx, "")
+		if err != nil {
+			out.Println("FAILED")
+			out.Printf("  Error: %v\n", err)
+			ok = false
+		} else {
+			out.Println("OK")
+		}
+	}
+
+	// Check passphrase availability
+	out.Printf("Passphrase: ")
+	resolver := config.NewPassphraseResolver(c)
+	passphrase, err := resolver.Resolve()
+	if err != nil {
+		out.Println("NOT AVAILABLE")
+		if c.PassphraseEnv != "" {
+			out.Printf("  Set environment variable: %s\n", c.PassphraseEnv)
+		} else if c.PassphraseKeyring {
+			out.Println("  No passphrase in keyring; run 'envsecrets keyring set'")
+		} else if len(c.PassphraseCommandArgs) > 0 {
+			out.Println("  Passphrase command failed to execute")
+		} else {
+			out.Println("  Configure passphrase_env, passphrase_command_args, or passphrase_keyring in config")
+		}
+		ok = false
+	} else {
+		out.Println("OK")
+
+		// Test encryption/decryption
+		out.Printf("Encryption: ")
+		{
+			encrypter, err := crypto.NewAgeEncrypter(passphrase)
+			if err != nil {
+				out.Println("FAILED")
+				out.Printf("  Error: %v\n", err)
+				ok = false
+			} else {
+				testData := []byte("test encryption")
+				encrypted, err := encrypter.Encrypt(testData)
+				if err != nil {
+					out.Println("FAILED")
+					out.Printf("  Encrypt error: %v\n", err)
+					ok = false
+				} else {
+					decrypted, err := encrypter.Decrypt(encrypted)
+					if err != nil {
+						out.Println("FAILED")
+						out.Printf("  Decrypt error: %v\n", err)
+						ok = false
+					} else if string(decrypted) != string(testData) {
+						out.Println("FAILED")
+						out.Println("  Round-trip verification failed")
+						ok = false
+					} else {
+						out.Println("OK")
+					}
+				}
+			}
+		}
+	}
+
+	return store, ok
+}
@@ -0,0 +1,221 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/charliek/envsecrets/internal/domain"
+	"github.com/charliek/envsecrets/internal/dotenv"
+	"github.com/charliek/envsecrets/internal/sync"
+	"github.com/charliek/envsecrets/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var editMessage string
+
+var editCmd = &cobra.Command{
+	Use:   "edit [file]",
+	Short: "Edit the latest remote version of a file and push it",
+	Long: `Edit the latest remote version of a tracked file in $VISUAL or $EDITOR
+and push the result as one commit.
+
+The file is decrypted to a private 0600 temporary file, on a memory-backed
+filesystem ($XDG_RUNTIME_DIR or /dev/shm) when available, and shredded
+afterwards. The edited file must parse as a dotenv file; there is no schema
+configuration, so keys and values are not validated further. A masked key
+diff is shown before pushing.
+
+Only the edited file is pushed; the working tree is not read or written, so
+run 'envsecrets pull' afterwards to update a local copy. If another machine
+pushes while you edit, your edit is merged with its change key by key, and
+keys changed on both sides are resolved interactively: overwrite pushes
+your edit as is, and skip keeps the remote version without pushing.
+
+With no file argument, the only tracked file is edited, or you pick one.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runEdit,
+}
+
+func init() {
+	editCmd.Flags().StringVarP(&editMessage, "message", "m", "", "commit message (default: Edit <file>)")
+}
+
+func runEdit(cmd *cobra.Command, args []string) error {
+	out := GetOutput()
+	if !ui.CanPrompt() {
+		return domain.Errorf(domain.ErrInvalidArgs, "edit needs an interactive terminal; use 'envsecrets set' in scripts")
+	}
+	prompt := ui.NewPrompt()
+
+	// The editor may stay open longer than one operation timeout, so the
+	// project context outlives it while reading and pushing each get their
+	// own cancellable context
+	pc, err := NewProjectContext(context.Background(), cfg)
+	if err != nil {
+		return err
+	}
+	defer pc.Close()
+
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	file, err := editFileArg(pc, prompt, args)
+	if err != nil {
+		return err
+	}
+	syncer := sync.NewSyncer(pc.Discovery, pc.RepoInfo, pc.Storage, pc.Encrypter, pc.Cache)
+	base, err := syncer.ReadRemoteVersion(ctx, file)
+	if err != nil {
+		return err
+	}
+	cancel()
+
+	edited, err := editContent(out, prompt, file, base.Content)
+	if err != nil || edited == nil {
+		return err
+	}
+
+	message := editMessage
+	if message == "" {
+		message = "Edit " + file
+	}
+	return pushEdit(pc, out, prompt, syncer, base, edited, message)
+}
+
+// editFileArg returns the tracked file to edit: the argument, the only
+// tracked file, or one the user picks
+func editFileArg(pc *ProjectContext, prompt *ui.Prompt, args []string) (string, error) {
+	if len(args) == 1 {
+		return trackedFileArg(pc, args[0])
+	}
+	files, err := pc.EnvFiles()
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 1 {
+		return files[0], nil
+	}
+	i, err := prompt.Select("Select a file to edit:", files)
+	if err != nil {
+		return "", err
+	}
+	return files[i], nil
+}
+
+// editContent lets the user edit content in a secure temporary file until
+// it parses. It returns nil when the content was left unchanged.
+func editContent(out *ui.Output, prompt *ui.Prompt, file string, content []byte) ([]byte, error) {
+	path, onDisk, cleanup, err := secureTempFile(file)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	if onDisk {
+		out.Warn("no memory-backed temp directory found; the decrypted file is briefly written to %s", os.TempDir())
+	}
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write temp file: %w", err)
+	}
+
+	for {
+		if err := runEditor(path); err != nil {
+			return nil, err
+		}
+		edited, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read temp file: %w", err)
+		}
+		if bytes.Equal(edited, content) {
+			out.Println("No changes.")
+			return nil, nil
+		}
+		_, parseErr := dotenv.Parse(edited)
+		if parseErr == nil {
+			return edited, nil
+		}
+
+		out.Warn("%s is not a valid env file: %v", file, parseErr)
+		again, err := prompt.Confirm("Edit again?", true)
+		if err != nil {
+			return nil, err
+		}
+		if !again {
+			return nil, domain.ErrUserCancelled
+		}
+	}
+}
+
+// pushEdit shows the masked diff of an edit and pushes it. When the remote
+// moved since base was read, the edit is merged with the remote change and
+// shown again before pushing.
+func pushEdit(pc *ProjectContext, out *ui.Output, prompt *ui.Prompt, syncer *sync.Syncer, base *sync.RemoteVersion, edited []byte, message string) error {
+	fingerprinter, err := pc.Fingerprinter()
+	if err != nil {
+		return err
+	}
+
+	for {
+		d := diffFileKeys(base.File, base.Content, edited, fingerprinter)
+		d.From, d.To = "remote", "edited"
+		printKeyDiff(out, d)
+		ok, err := prompt.Confirm("Push these changes?", true)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return domain.ErrUserCancelled
+		}
+
+		ctx, cancel := signalContext()
+		result, err := syncer.PushFile(ctx, base.File, edited, sync.PushFileOptions{Message: message, Base: base.Head})
+		if err == nil {
+			cancel()
+			out.Printf("Pushed commit %s\n", ui.TruncateHash(result.CommitHash))
+			if pc.FileExists(base.File) {
+				out.Println("Run 'envsecrets pull' to update your working tree copy.")
+			}
+			return nil
+		}
+		if errors.Is(err, domain.ErrNothingToCommit) {
+			cancel()
+			out.Println("Nothing to push - the remote already has this content")
+			return nil
+		}
+		if !errors.Is(err, domain.ErrRemoteChanged) {
+			cancel()
+			return err
+		}
+
+		// Another push landed while editing: merge with it and try again
+		out.Warn("%s changed remotely while you were editing; merging", base.File)
+		latest, err := syncer.ReadRemoteVersion(ctx, base.File)
+		cancel()
+		if err != nil {
+			return err
+		}
+		merged, conflict := sync.MergeVersions(base.File, base.Content, edited, latest.Content)
+		if conflict != nil {
+			resolution, err := conflictResolver(pc, out, prompt)(conflict)
+			if err != nil {
+				return err
+			}
+			switch resolution.Action {
+			case sync.ConflictOverwrite:
+				// Push the edit as is, undoing the other push's changes
+				out.Warn("pushing your edit as is replaces the remote changes to %s", base.File)
+				merged = edited
+			case sync.ConflictSkip:
+				out.Println("Kept the remote version; your edit was not pushed.")
+				return domain.ErrUserCancelled
+			case sync.ConflictMerge:
+				merged = resolution.Content
+			default:
+				return domain.ErrUserCancelled
+			}
+		}
+		base, edited = latest, merged
+	}
+}
@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/charliek/envsecrets/internal/domain"
+	"github.com/charliek/envsecrets/internal/sync"
+	"github.com/charliek/envsecrets/internal/ui"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPushEdit_ConcurrentRemoteChange(t *testing.T) {
+	const (
+		edited = "A=edited\nB=2\n"
+		remote = "A=remote\nB=1\nC=3\n"
+	)
+	tests := []struct {
+		name    string
+		answers string
+		wantErr error
+		want    string
+	}{
+		{name: "keep local key", answers: "y\nk\nl\ny\n", want: "A=edited\nB=2\nC=3\n"},
+		{name: "take remote key", answers: "y\nk\nr\ny\n", want: "A=remote\nB=2\nC=3\n"},
+		{name: "overwrite", answers: "y\no\ny\n", want: edited},
+		{name: "skip", answers: "y\ns\n", wantErr: domain.ErrUserCancelled, want: remote},
+		{name: "abort", answers: "y\na\n", wantErr: domain.ErrUserCancelled, want: remote},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			files := map[string]string{
+				".envsecrets": ".env\n",
+				".gitignore":  ".env\n",
+				".env":        "A=1\nB=1\n",
+			}
+			r := newTestRemote()
+			other := r.newMachine(t, files)
+			r.push(t, other)
+
+			pc := r.newMachine(t, nil)
+			pc.passphrase = "test"
+			syncer := sync.NewSyncer(pc.Discovery, pc.RepoInfo, pc.Storage, pc.Encrypter, pc.Cache)
+			base, err := syncer.ReadRemoteVersion(ctx, ".env")
+			require.NoError(t, err)
+
+			// Another machine pushes while the editor is open
+			otherSyncer := sync.NewSyncer(other.Discovery, other.RepoInfo, other.Storage, other.Encrypter, other.Cache)
+			latest, err := otherSyncer.ReadRemoteVersion(ctx, ".env")
+			require.NoError(t, err)
+			_, err = otherSyncer.PushFile(ctx, ".env", []byte(remote), sync.PushFileOptions{Message: "concurrent", Base: latest.Head})
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			out := ui.NewOutputWithWriters(&buf, &buf, false, false)
+			prompt := ui.NewPromptWithReader(strings.NewReader(tt.answers))
+			err = pushEdit(pc, out, prompt, syncer, base, []byte(edited), "Edit .env")
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			got, err := syncer.ReadRemoteVersion(ctx, ".env")
+			require.NoError(t, err)
+			require.Equal(t, tt.want, string(got.Content))
+		})
+	}
+}
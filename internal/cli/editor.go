@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+
+	"github.com/charliek/envsecrets/internal/domain"
+)
+
+// defaultEditor is used when neither $VISUAL nor $EDITOR is set
+const defaultEditor = "vi"
+
+// editorCommand returns the user's editor command line from $VISUAL, then
+// $EDITOR, so values like "code --wait" work
+func editorCommand() []string {
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if fields := strings.Fields(os.Getenv(name)); len(fields) > 0 {
+			return fields
+		}
+	}
+	return []string{defaultEditor}
+}
+
+// runEditor opens path in the user's editor and waits for it to exit.
+// Interrupts typed in the editor do not stop envsecrets, so temporary
+// files are always cleaned up.
+func runEditor(path string) error {
+	args := append(editorCommand(), path)
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+
+	if err := cmd.Run(); err != nil {
+		return domain.Errorf(domain.ErrInvalidArgs, "editor %q failed: %v", args[0], err)
+	}
+	return nil
+}
+
+// memoryTempDirs are searched in order for a memory-backed filesystem to
+// hold decrypted files
+func memoryTempDirs() []string {
+	return []string{os.Getenv("XDG_RUNTIME_DIR"), "/dev/shm"}
+}
+
+// secureTempFile creates an empty 0600 file named like file in a private
+// directory, on a memory-backed filesystem when one is available so the
+// plaintext never reaches disk. onDisk reports the fallback to the system
+// temp directory. cleanup shreds and removes it.
+func secureTempFile(file string) (path string, onDisk bool, cleanup func(), err error) {
+	var dir string
+	for _, base := range memoryTempDirs() {
+		if base == "" {
+			continue
+		}
+		if info, statErr := os.Stat(base); statErr == nil && info.IsDir() {
+			if dir, err = os.MkdirTemp(base, "envsecrets-"); err == nil {
+				break
+			}
+		}
+	}
+	if dir == "" {
+		onDisk = true
+		if dir, err = os.MkdirTemp("", "envsecrets-"); err != nil {
+			return "", false, nil, fmt.Errorf("failed to create temp directory: %w", err)
+		}
+	}
+
+	path = filepath.Join(dir, filepath.Base(file))
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", false, nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	f.Close()
+
+	cleanup = func() {
+		shredFile(path)
+		os.RemoveAll(dir)
+	}
+	return path, onDisk, cleanup, nil
+}
+
+// shredFile overwrites a file with zeros before it is removed. Editors that
+// save by replacing the file leave nothing behind for this to reach, so it
+// is best effort.
+func shredFile(path string) {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return
+	}
+	_, _ = f.Write(make([]byte, info.Size()))
+	_ = f.Sync()
+}
@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEditorCommand(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "code --wait")
+	require.Equal(t, []string{"code", "--wait"}, editorCommand())
+
+	t.Setenv("VISUAL", "nano")
+	require.Equal(t, []string{"nano"}, editorCommand())
+
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "")
+	require.Equal(t, []string{defaultEditor}, editorCommand())
+}
+
+func TestSecureTempFile(t *testing.T) {
+	runtimeDir := t.TempDir()
+	t.Setenv("XDG_RUNTIME_DIR", runtimeDir)
+
+	path, onDisk, cleanup, err := secureTempFile("app/.env")
+	require.NoError(t, err)
+	require.False(t, onDisk)
+	require.Equal(t, ".env", filepath.Base(path))
+	require.Equal(t, runtimeDir, filepath.Dir(filepath.Dir(path)))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	require.NoError(t, os.WriteFile(path, []byte("SECRET=1\n"), 0600))
+	cleanup()
+	_, err = os.Stat(filepath.Dir(path))
+	require.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,279 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/charliek/envsecrets/internal/domain"
+	"github.com/charliek/envsecrets/internal/dotenv"
+	"github.com/charliek/envsecrets/internal/export"
+	"github.com/charliek/envsecrets/internal/sync"
+	"github.com/charliek/envsecrets/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportFormat     string
+	exportRef        string
+	exportOutput     string
+	exportName       string
+	exportNamespace  string
+	exportSecretType string
+	exportLabels     []string
+	exportBase64     bool
+	exportPerFile    bool
+)
+
+// exportSecretFlags only apply to the Kubernetes formats
+var exportSecretFlags = []string{"name", "namespace", "type", "label", "base64", "per-file"}
+
+var exportCmd = &cobra.Command{
+	Use:   "export [file]...",
+	Short: "Print tracked files in another format",
+	Long: `Print the variables of tracked env files in a format other tools read.
+
+Formats:
+  json         a JSON object
+  yaml         a YAML mapping
+  shell        export statements, for eval "$(envsecrets export)"
+  docker-env   KEY=value lines for docker --env-file (no multi-line values)
+  systemd      an EnvironmentFile= for systemd units
+  properties   a Java properties file
+  k8s-secret   Kubernetes v1 Secret manifests
+  kustomize    a kustomization.yaml secretGenerator list
+
+Files are read from the working tree, or with --ref from a pushed version
+(HEAD for the latest). Without arguments every tracked file is exported, in
+.envsecrets order; otherwise only the given files, in the order given. When
+files set the same key, the later file wins.
+
+k8s-secret and kustomize write one Secret named --name holding the merged
+variables, or with --per-file one Secret per file, named after the file
+(prefixed with --name when given). Values go under stringData unless --base64
+is set.`,
+	Example: `  eval "$(envsecrets export --format shell)"
+  envsecrets export .env .env.production --format json
+  envsecrets export --ref HEAD --format docker-env -o app.env
+  envsecrets export .env --format k8s-secret --name app --namespace prod | kubectl apply -f -`,
+	RunE: runExport,
+}
+
+func init() {
+	formats := append(append([]string(nil), export.Formats...), export.SecretFormats...)
+	exportCmd.Flags().StringVar(&exportFormat, "format", export.FormatShell, "output format: "+strings.Join(formats, ", "))
+	exportCmd.Flags().StringVar(&exportRef, "ref", "", "read a pushed version (commit hash, or HEAD) instead of the working tree")
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "write to a file (mode 0600) instead of stdout")
+	exportCmd.Flags().StringVar(&exportName, "name", "", "Secret name (required unless --per-file)")
+	exportCmd.Flags().StringVar(&exportNamespace, "namespace", "", "Secret namespace")
+	exportCmd.Flags().StringVar(&exportSecretType, "type", export.DefaultSecretType, "Secret type")
+	exportCmd.Flags().StringArrayVar(&exportLabels, "label", nil, "Secret label as key=value (repeatable)")
+	exportCmd.Flags().BoolVar(&exportBase64, "base64", false, "write base64-encoded data instead of stringData")
+	exportCmd.Flags().BoolVar(&exportPerFile, "per-file", false, "write one Secret per file instead of one merged Secret")
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	ctx, cancel := signalContext()
+	defer cancel()
+	out := GetOutput()
+
+	secretFormat := slices.Contains(export.SecretFormats, exportFormat)
+	if !secretFormat {
+		for _, name := range exportSecretFlags {
+			if cmd.Flags().Changed(name) {
+				return domain.Errorf(domain.ErrInvalidArgs, "--%s only applies to the %s formats", name, strings.Join(export.SecretFormats, " and "))
+			}
+		}
+	} else if exportName == "" && !exportPerFile {
+		return domain.Errorf(domain.ErrInvalidArgs, "--name is required for %s output unless --per-file is set", exportFormat)
+	}
+
+	pc, err := NewProjectContext(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer pc.Close()
+
+	loaded, err := readExportFiles(ctx, pc, out, args)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if secretFormat {
+		err = writeSecrets(&buf, loaded)
+	} else {
+		err = export.Write(&buf, exportFormat, mergeEnvVars(loaded))
+	}
+	if err != nil {
+		return err
+	}
+	return writeExport(out, buf.Bytes())
+}
+
+// readExportFiles loads the requested tracked files, or every tracked file,
+// from the working tree or with --ref from the cache. The cache is synced
+// before the files are resolved so glob patterns match pushed files too.
+func readExportFiles(ctx context.Context, pc *ProjectContext, out *ui.Output, args []string) ([]envFile, error) {
+	files, err := resolveTrackedFiles(ctx, pc, args, exportRef != "")
+	if err != nil {
+		return nil, err
+	}
+	read := pc.ReadProjectFile
+	if exportRef != "" {
+		syncer := sync.NewSyncer(pc.Discovery, pc.RepoInfo, pc.Storage, pc.Encrypter, pc.Cache)
+		read = func(file string) ([]byte, error) {
+			return syncer.ReadFileAt(file, exportRef)
+		}
+	}
+	return loadEnvFiles(out, files, len(args) > 0, read)
+}
+
+// writeSecrets writes the loaded files as Kubernetes Secrets
+func writeSecrets(w io.Writer, loaded []envFile) error {
+	opts := export.SecretOptions{
+		Namespace: exportNamespace,
+		Type:      exportSecretType,
+		Base64:    exportBase64,
+	}
+	for _, label := range exportLabels {
+		key, value, ok := strings.Cut(label, "=")
+		if !ok || key == "" {
+			return domain.Errorf(domain.ErrInvalidArgs, "invalid label %q: expected key=value", label)
+		}
+		opts.Labels = append(opts.Labels, export.Var{Key: key, Value: value})
+	}
+
+	if !exportPerFile {
+		secret := export.Secret{Name: exportName, Vars: mergeEnvVars(loaded)}
+		return export.WriteSecrets(w, exportFormat, opts, []export.Secret{secret})
+	}
+	secrets := make([]export.Secret, 0, len(loaded))
+	names := make(map[string]string, len(loaded))
+	for _, f := range loaded {
+		name := secretName(exportName, f.name)
+		if other, ok := names[name]; ok {
+			return domain.Errorf(domain.ErrInvalidArgs, "%s and %s both map to Secret name %q", other, f.name, name)
+		}
+		names[name] = f.name
+		secrets = append(secrets, export.Secret{Name: name, Vars: mergeEnvVars([]envFile{f})})
+	}
+	return export.WriteSecrets(w, exportFormat, opts, secrets)
+}
+
+// secretName derives a Secret name from a tracked file's path: lowercase,
+// with runs of anything but letters and digits turned into a dash, and
+// prefixed with prefix when set
+func secretName(prefix, file string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(file) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	if prefix == "" {
+		return b.String()
+	}
+	if b.Len() == 0 {
+		return prefix
+	}
+	return prefix + "-" + b.String()
+}
+
+// writeExport writes exported secrets to --output, or to stdout
+func writeExport(out *ui.Output, data []byte) error {
+	if exportOutput == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	if err := writePrivateFile(exportOutput, data); err != nil {
+		return err
+	}
+	out.Verbose("Wrote %s", exportOutput)
+	return nil
+}
+
+// writePrivateFile replaces the content of path with data, creating it with
+// mode 0600. An existing regular file is made 0600 before anything is
+// written, since os.WriteFile keeps the mode of a file it does not create.
+func writePrivateFile(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	info, err := f.Stat()
+	if err == nil && info.Mode().IsRegular() {
+		if err = f.Chmod(0600); err == nil {
+			err = f.Truncate(0)
+		}
+	}
+	if err == nil {
+		_, err = f.Write(data)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// envFile is a parsed tracked file
+type envFile struct {
+	name string
+	vars *dotenv.File
+}
+
+// loadEnvFiles reads and parses files in order. A file that does not exist
+// is skipped unless explicit, when the user named the files.
+func loadEnvFiles(out *ui.Output, files []string, explicit bool, read func(file string) ([]byte, error)) ([]envFile, error) {
+	loaded := make([]envFile, 0, len(files))
+	for _, file := range files {
+		content, err := read(file)
+		if err != nil {
+			if errors.Is(err, domain.ErrFileNotFound) && !explicit {
+				out.Verbose("Skipping %s: not found", file)
+				continue
+			}
+			return nil, err
+		}
+		f, err := dotenv.Parse(content)
+		if err != nil {
+			return nil, domain.Errorf(domain.ErrInvalidArgs, "failed to parse %s: %v", file, err)
+		}
+		loaded = append(loaded, envFile{name: file, vars: f})
+	}
+	return loaded, nil
+}
+
+// mergeEnvVars returns each key once, in the order first seen, with the
+// value of the last file that sets it
+func mergeEnvVars(files []envFile) []export.Var {
+	var vars []export.Var
+	index := make(map[string]int)
+	for _, f := range files {
+		for _, key := range f.vars.Keys() {
+			value, _ := f.vars.Lookup(key)
+			if i, ok := index[key]; ok {
+				vars[i].Value = value
+				continue
+			}
+			index[key] = len(vars)
+			vars = append(vars, export.Var{Key: key, Value: value})
+		}
+	}
+	return vars
+}
@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charliek/envsecrets/internal/domain"
+	"github.com/charliek/envsecrets/internal/export"
+	"github.com/charliek/envsecrets/internal/ui"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadEnvFiles(t *testing.T) {
+	var buf bytes.Buffer
+	out := ui.NewOutputWithWriters(&buf, &buf, false, false)
+	contents := map[string]string{
+		".env":      "A=1\nB=2\n",
+		".env.prod": "B=prod\nC=3\n",
+		".env.bad":  "A='unterminated\n",
+	}
+	read := func(file string) ([]byte, error) {
+		content, ok := contents[file]
+		if !ok {
+			return nil, domain.Errorf(domain.ErrFileNotFound, "file not found: %s", file)
+		}
+		return []byte(content), nil
+	}
+
+	loaded, err := loadEnvFiles(out, []string{".env", ".env.missing", ".env.prod"}, false, read)
+	require.NoError(t, err)
+	require.Len(t, loaded, 2)
+	require.Equal(t, []export.Var{{Key: "A", Value: "1"}, {Key: "B", Value: "prod"}, {Key: "C", Value: "3"}}, mergeEnvVars(loaded))
+
+	loaded, err = loadEnvFiles(out, []string{".env.prod", ".env"}, true, read)
+	require.NoError(t, err)
+	require.Equal(t, []export.Var{{Key: "B", Value: "2"}, {Key: "C", Value: "3"}, {Key: "A", Value: "1"}}, mergeEnvVars(loaded))
+
+	_, err = loadEnvFiles(out, []string{".env", ".env.missing"}, true, read)
+	require.ErrorIs(t, err, domain.ErrFileNotFound)
+
+	_, err = loadEnvFiles(out, []string{".env.bad"}, false, read)
+	require.ErrorIs(t, err, domain.ErrInvalidArgs)
+}
+
+func TestSecretName(t *testing.T) {
+	require.Equal(t, "env", secretName("", ".env"))
+	require.Equal(t, "app-env-production", secretName("app", ".env.production"))
+	require.Equal(t, "api-config-env-local", secretName("", "api/config/.env.LOCAL"))
+	require.Equal(t, "app", secretName("app", "..."))
+}
+
+func TestReadExportFiles_RefMatchesRemoteOnlyGlob(t *testing.T) {
+	remote := newTestRemote()
+	remote.push(t, remote.newMachine(t, map[string]string{
+		".envsecrets":     "config/*.env\n",
+		".gitignore":      "*.env\n",
+		"config/prod.env": "API_KEY=secret\n",
+	}))
+	pc := remote.newMachine(t, map[string]string{".envsecrets": "config/*.env\n"})
+	var buf bytes.Buffer
+	out := ui.NewOutputWithWriters(&buf, &buf, false, false)
+
+	exportRef = "HEAD"
+	t.Cleanup(func() { exportRef = "" })
+	loaded, err := readExportFiles(context.Background(), pc, out, nil)
+	require.NoError(t, err)
+	require.Equal(t, []export.Var{{Key: "API_KEY", Value: "secret"}}, mergeEnvVars(loaded))
+}
+
+func TestWritePrivateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.env")
+	require.NoError(t, os.WriteFile(path, []byte("OLD=a much longer old value\n"), 0644))
+	require.NoError(t, os.Chmod(path, 0644))
+
+	require.NoError(t, writePrivateFile(path, []byte("A=1\n")))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "A=1\n", string(content))
+
+	created := filepath.Join(t.TempDir(), "new.env")
+	require.NoError(t, writePrivateFile(created, []byte("B=2\n")))
+	info, err = os.Stat(created)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
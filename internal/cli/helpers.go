@@ -21,18 +21,13 @@ type ProjectContext struct {
 	Storage   storage.Storage
 	Encrypter crypto.Encrypter
 	Cache     *cache.Cache
+
+	passphrase    string
+	fingerprinter *crypto.Fingerprinter
 }
 
 // NewProjectContext creates a new project context with all required components
 func NewProjectContext(ctx context.Context, cfg *config.Config) (*ProjectContext, error) {
-	// Plumb optional machine identity into the env var the git layer reads
-	// when stamping commit authors. Only override if the user explicitly set
-	// machine_id in config, so a pre-existing ENVSECRETS_MACHINE_ID env var
-	// (e.g. set by CI) still wins.
-	if cfg != nil && cfg.MachineID != "" && os.Getenv("ENVSECRETS_MACHINE_ID") == "" {
-		_ = os.Setenv("ENVSECRETS_MACHINE_ID", cfg.MachineID)
-	}
-
 	repoOverride := GetRepo()
 
 	var repoInfo *domain.RepoInfo
@@ -46,7 +41,7 @@ func NewProjectContext(ctx context.Context, cfg *config.Config) (*ProjectContext
 			return nil, err
 		}
 		// Still try to get discovery for EnvFiles(), but it may fail if not in git repo
-		discovery, err = project.NewDiscovery("")
+		discovery, err = discoverProject(cfg)
 		if err != nil {
 			// Log at verbose level - this is expected when not in a git repo
 			out := GetOutput()
@@ -55,7 +50,7 @@ func NewProjectContext(ctx context.Context, cfg *config.Config) (*ProjectContext
 	} else {
 		// Normal discovery
 		var err error
-		discovery, err = project.NewDiscovery("")
+		discovery, err = discoverProject(cfg)
 		if err != nil {
 			return nil, err
 		}
@@ -65,6 +60,44 @@ func NewProjectContext(ctx context.Context, cfg *config.Config) (*ProjectContext
 		}
 	}
 
+	return newProjectContext(ctx, cfg, discovery, repoInfo)
+}
+
+// discoverProject finds the project containing the working directory and
+// applies the user's git remote preference from cfg
+func discoverProject(cfg *config.Config) (*project.Discovery, error) {
+	discovery, err := project.NewDiscovery("")
+	if err != nil {
+		return nil, err
+	}
+	if cfg != nil {
+		discovery.SetRemotePreference(cfg.Remotes)
+	}
+	return discovery, nil
+}
+
+// NewNamespaceContext creates a project context for one namespace returned
+// by Discovery.Namespaces, ignoring the --repo override
+func NewNamespaceContext(ctx context.Context, cfg *config.Config, discovery *project.Discovery) (*ProjectContext, error) {
+	discovery.SetRemotePreference(cfg.Remotes)
+	repoInfo, err := discovery.RepoInfo()
+	if err != nil {
+		return nil, err
+	}
+	return newProjectContext(ctx, cfg, discovery, repoInfo)
+}
+
+// newProjectContext connects storage, resolves the passphrase, and opens
+// the cache for an already identified project
+func newProjectContext(ctx context.Context, cfg *config.Config, discovery *project.Discovery, repoInfo *domain.RepoInfo) (*ProjectContext, error) {
+	// Plumb optional machine identity into the env var the git layer reads
+	// when stamping commit authors. Only override if the user explicitly set
+	// machine_id in config, so a pre-existing ENVSECRETS_MACHINE_ID env var
+	// (e.g. set by CI) still wins.
+	if cfg != nil && cfg.MachineID != "" && os.Getenv("ENVSECRETS_MACHINE_ID") == "" {
+		_ = os.Setenv("ENVSECRETS_MACHINE_ID", cfg.MachineID)
+	}
+
 	// Create storage client with retry wrapper
 	gcsStore, err := storage.NewGCSStorage(ctx, cfg.Bucket, cfg.GCSCredentials)
 	if err != nil {
@@ -101,32 +134,77 @@ func NewProjectContext(ctx context.Context, cfg *config.Config) (*ProjectContext
 		returnErr = err
 		return nil, err
 	}
+	// Linked worktrees share the cache but each keeps its own baseline
+	if discovery != nil {
+		cacheRepo.SetWorktree(discovery.WorktreeID())
+	}
 
 	return &ProjectContext{
-		Config:    cfg,
-		Discovery: discovery,
-		RepoInfo:  repoInfo,
-		Storage:   store,
-		Encrypter: encrypter,
-		Cache:     cacheRepo,
+		Config:     cfg,
+		Discovery:  discovery,
+		RepoInfo:   repoInfo,
+		Storage:    store,
+		Encrypter:  encrypter,
+		Cache:      cacheRepo,
+		passphrase: passphrase,
 	}, nil
 }
 
+// Fingerprinter returns the project's value fingerprinter, deriving its
+// key on first use
+func (pc *ProjectContext) Fingerprinter() (*crypto.Fingerprinter, error) {
+	if pc.fingerprinter == nil {
+		f, err := crypto.NewFingerprinter(pc.passphrase, pc.RepoInfo.String())
+		if err != nil {
+			return nil, err
+		}
+		pc.fingerprinter = f
+	}
+	return pc.fingerprinter, nil
+}
+
 // requireDiscovery returns the Discovery instance or an error if unavailable
 func (pc *ProjectContext) requireDiscovery() (*project.Discovery, error) {
 	if pc.Discovery == nil {
-		return nil, domain.Errorf(domain.ErrNotInRepo, "project discovery unavailable (not in a git repository)")
+		return nil, domain.Errorf(domain.ErrNotInRepo, "project discovery unavailable (not in a git repository or a directory with .envsecrets)")
 	}
 	return pc.Discovery, nil
 }
 
-// EnvFiles returns the list of environment files to track
+// EnvFiles returns the list of environment files to track. Glob patterns
+// are matched against the working tree and the files already in the cache.
 func (pc *ProjectContext) EnvFiles() ([]string, error) {
 	d, err := pc.requireDiscovery()
 	if err != nil {
 		return nil, err
 	}
-	return d.EnvFiles()
+	var cached []string
+	if pc.Cache != nil {
+		cached, err = pc.Cache.ListTrackedFiles()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return d.EnvFilesWith(cached)
+}
+
+// resolveTrackedFiles returns every tracked file, or the requested ones in
+// the order requested. With fromCache the cache is synced first, so glob
+// patterns also match files that only exist remotely.
+func resolveTrackedFiles(ctx context.Context, pc *ProjectContext, requested []string, fromCache bool) ([]string, error) {
+	if fromCache {
+		if err := pc.Cache.SyncFromStorage(ctx); err != nil {
+			return nil, err
+		}
+	}
+	files, err := pc.EnvFiles()
+	if err != nil {
+		return nil, err
+	}
+	if len(requested) == 0 {
+		return files, nil
+	}
+	return selectTrackedFiles(requested, files)
 }
 
 // ReadProjectFile reads a file from the project directory
@@ -172,6 +250,7 @@ func (pc *ProjectContext) GetFileStatuses() ([]domain.FileStatus, error) {
 		status := domain.FileStatus{
 			Path:        file,
 			LocalExists: pc.FileExists(file),
+			Pattern:     pc.Discovery.MatchingPattern(file),
 		}
 
 		// Check if file exists in cache
@@ -230,15 +309,32 @@ func countFileStatuses(statuses []domain.FileStatus) statusCounts {
 	return c
 }
 
-// extractReposFromObjects extracts unique owner/repo combinations from storage paths
+// extractReposFromObjects extracts unique owner/repo combinations from storage
+// paths. Nested repositories (owner/repo/services/api) are identified by
+// their HEAD object.
 func extractReposFromObjects(objects []string) map[string]bool {
+	nested := make(map[string]bool)
+	for _, obj := range objects {
+		if dir, ok := strings.CutSuffix(obj, "/HEAD"); ok && strings.Count(dir, "/") >= 2 {
+			nested[dir] = true
+		}
+	}
+
 	repos := make(map[string]bool)
 	for _, obj := range objects {
 		parts := strings.Split(obj, "/")
-		if len(parts) >= 2 {
-			repo := parts[0] + "/" + parts[1]
-			repos[repo] = true
+		if len(parts) < 2 {
+			continue
+		}
+		repo := parts[0] + "/" + parts[1]
+		// Attribute the object to the deepest nested repository containing it
+		for i := len(parts) - 1; i > 2; i-- {
+			if dir := strings.Join(parts[:i], "/"); nested[dir] {
+				repo = dir
+				break
+			}
 		}
+		repos[repo] = true
 	}
 	return repos
 }
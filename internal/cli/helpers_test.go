@@ -1,12 +1,121 @@
 package cli
 
 import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/charliek/envsecrets/internal/cache"
+	"github.com/charliek/envsecrets/internal/crypto"
 	"github.com/charliek/envsecrets/internal/domain"
+	"github.com/charliek/envsecrets/internal/git"
+	"github.com/charliek/envsecrets/internal/project"
+	"github.com/charliek/envsecrets/internal/storage"
+	"github.com/charliek/envsecrets/internal/sync"
+	"github.com/charliek/envsecrets/internal/ui"
+	gogit "github.com/go-git/go-git/v5"
 	"github.com/stretchr/testify/require"
 )
 
+// testRemote is a mock remote shared by the machines of a test
+type testRemote struct {
+	storage   *storage.MockStorage
+	encrypter *crypto.MockEncrypter
+	repoInfo  *domain.RepoInfo
+}
+
+func newTestRemote() *testRemote {
+	return &testRemote{
+		storage:   storage.NewMockStorage(),
+		encrypter: crypto.NewMockEncrypter(),
+		repoInfo:  &domain.RepoInfo{Owner: "owner", Name: "repo"},
+	}
+}
+
+// newMachine returns the project context of a machine with its own git
+// project holding files and an empty cache
+func (r *testRemote) newMachine(t *testing.T, files map[string]string) *ProjectContext {
+	t.Helper()
+	projectDir := t.TempDir()
+	_, err := gogit.PlainInit(projectDir, false)
+	require.NoError(t, err)
+	for name, content := range files {
+		path := filepath.Join(projectDir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	}
+	disc, err := project.NewDiscovery(projectDir)
+	require.NoError(t, err)
+
+	cacheDir := t.TempDir()
+	gitRepo, err := git.NewGoGitRepository(cacheDir)
+	require.NoError(t, err)
+	require.NoError(t, gitRepo.Init())
+	return &ProjectContext{
+		Discovery: disc,
+		RepoInfo:  r.repoInfo,
+		Storage:   r.storage,
+		Encrypter: r.encrypter,
+		Cache:     cache.NewCacheWithRepo(r.repoInfo, r.storage, gitRepo, cacheDir),
+	}
+}
+
+// push pushes a machine's working tree to the remote
+func (r *testRemote) push(t *testing.T, pc *ProjectContext) {
+	t.Helper()
+	syncer := sync.NewSyncer(pc.Discovery, pc.RepoInfo, pc.Storage, pc.Encrypter, pc.Cache)
+	_, err := syncer.Push(context.Background(), sync.PushOptions{Message: "test"})
+	require.NoError(t, err)
+}
+
+func TestResolveTrackedFiles_RemoteOnlyGlobMatch(t *testing.T) {
+	remote := newTestRemote()
+	remote.push(t, remote.newMachine(t, map[string]string{
+		".envsecrets":     "config/*.env\n",
+		".gitignore":      "*.env\n",
+		"config/prod.env": "API_KEY=secret\n",
+	}))
+
+	// A fresh machine, as in CI: empty cache and no env files locally
+	pc := remote.newMachine(t, map[string]string{".envsecrets": "config/*.env\n"})
+
+	files, err := resolveTrackedFiles(context.Background(), pc, nil, false)
+	require.NoError(t, err)
+	require.Empty(t, files, "the glob matches nothing before the cache is synced")
+
+	files, err = resolveTrackedFiles(context.Background(), pc, nil, true)
+	require.NoError(t, err)
+	require.Equal(t, []string{"config/prod.env"}, files)
+
+	files, err = resolveTrackedFiles(context.Background(), pc, []string{"./config/prod.env"}, true)
+	require.NoError(t, err)
+	require.Equal(t, []string{"config/prod.env"}, files)
+}
+
+func TestPushWorkingTree_Strict(t *testing.T) {
+	remote := newTestRemote()
+	pc := remote.newMachine(t, map[string]string{
+		".envsecrets": ".env\n",
+		".env":        "API_KEY=secret\n",
+	})
+	var buf bytes.Buffer
+	out := ui.NewOutputWithWriters(&buf, &buf, false, false)
+
+	err := pushWorkingTree(context.Background(), pc, out, "test", true)
+	require.ErrorIs(t, err, domain.ErrPermissionDenied)
+	require.Contains(t, buf.String(), "Git safety issues")
+	exists, err := remote.storage.Exists(context.Background(), pc.RepoInfo.CachePath()+"/objects.pack")
+	require.NoError(t, err)
+	require.False(t, exists, "nothing is pushed")
+
+	buf.Reset()
+	require.NoError(t, pushWorkingTree(context.Background(), pc, out, "test", false))
+	require.Contains(t, buf.String(), "Git safety issues")
+	require.Contains(t, buf.String(), "Pushed commit")
+}
+
 func TestCountFileStatuses(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -60,3 +169,21 @@ func TestCountFileStatuses(t *testing.T) {
 		})
 	}
 }
+
+func TestExtractReposFromObjects(t *testing.T) {
+	repos := extractReposFromObjects([]string{
+		"acme/mono/HEAD",
+		"acme/mono/objects.pack",
+		"acme/mono/services/api/HEAD",
+		"acme/mono/services/api/objects.pack",
+		"acme/legacy/config/.env.age",
+		"github.com/acme/api/HEAD",
+		"github.com/acme/api/objects.pack",
+	})
+	require.Equal(t, map[string]bool{
+		"acme/mono":              true,
+		"acme/mono/services/api": true,
+		"acme/legacy":            true,
+		"github.com/acme/api":    true,
+	}, repos)
+}
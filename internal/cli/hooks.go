@@ -0,0 +1,257 @@
+package cli
+
+import (
+	"context"
+	"time"
+
+	"github.com/charliek/envsecrets/internal/domain"
+	"github.com/charliek/envsecrets/internal/project"
+	"github.com/charliek/envsecrets/internal/sync"
+	"github.com/charliek/envsecrets/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+// hookStatusTimeout bounds the remote check run after checkout and merge so
+// a slow network never holds up git
+const hookStatusTimeout = 15 * time.Second
+
+var hooksPostCheckout bool
+
+var hooksCmd = &cobra.Command{
+	Use:   "hooks",
+	Short: "Manage git hooks that protect the project's git from secrets",
+	Long: `Manage git hooks in the project's git repository.
+
+The pre-commit hook blocks commits that stage a tracked env file or a file
+containing a value from a tracked env file. The optional post-checkout and
+post-merge hooks check the remote after switching branches or pulling and
+warn when secrets changed.
+
+Hooks are installed into core.hooksPath when it is set, otherwise into the
+repository's hooks directory. Existing hooks keep running: they are renamed
+to <hook>.envsecrets-chained and called first.`,
+}
+
+var hooksInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install the envsecrets git hooks",
+	Args:  cobra.NoArgs,
+	RunE:  runHooksInstall,
+}
+
+var hooksUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Remove the envsecrets git hooks and restore chained hooks",
+	Args:  cobra.NoArgs,
+	RunE:  runHooksUninstall,
+}
+
+var hooksRunCmd = &cobra.Command{
+	Use:    "run <hook> [args]...",
+	Short:  "Run a hook (called by the installed hook scripts)",
+	Hidden: true,
+	Args:   cobra.MinimumNArgs(1),
+	RunE:   runHooksRun,
+}
+
+func init() {
+	hooksInstallCmd.Flags().BoolVar(&hooksPostCheckout, "post-checkout", false, "also install post-checkout and post-merge hooks that warn when remote secrets changed")
+
+	hooksCmd.AddCommand(hooksInstallCmd)
+	hooksCmd.AddCommand(hooksUninstallCmd)
+	hooksCmd.AddCommand(hooksRunCmd)
+}
+
+func runHooksInstall(cmd *cobra.Command, args []string) error {
+	out := GetOutput()
+
+	discovery, err := project.NewDiscovery("")
+	if err != nil {
+		return err
+	}
+	dir, err := discovery.HooksDir()
+	if err != nil {
+		return err
+	}
+
+	names := []string{project.HookPreCommit}
+	if hooksPostCheckout {
+		names = append(names, project.HookPostCheckout, project.HookPostMerge)
+	}
+
+	var installed []*project.HookInstall
+	for _, name := range names {
+		result, err := project.InstallHook(dir, name)
+		if err != nil {
+			return err
+		}
+		installed = append(installed, result)
+	}
+
+	if out.IsJSON() {
+		return out.JSON(installed)
+	}
+
+	for _, h := range installed {
+		verb := "Installed"
+		if h.Updated {
+			verb = "Updated"
+		}
+		out.Printf("%s %s hook: %s\n", verb, h.Name, h.Path)
+		if h.Chained != "" {
+			out.Printf("  runs existing hook first: %s\n", h.Chained)
+		}
+	}
+	return nil
+}
+
+func runHooksUninstall(cmd *cobra.Command, args []string) error {
+	out := GetOutput()
+
+	discovery, err := project.NewDiscovery("")
+	if err != nil {
+		return err
+	}
+	dir, err := discovery.HooksDir()
+	if err != nil {
+		return err
+	}
+
+	var removed []string
+	for _, name := range []string{project.HookPreCommit, project.HookPostCheckout, project.HookPostMerge} {
+		ok, err := project.UninstallHook(dir, name)
+		if err != nil {
+			return err
+		}
+		if ok {
+			removed = append(removed, name)
+		}
+	}
+
+	if out.IsJSON() {
+		return out.JSON(map[string]interface{}{"removed": removed})
+	}
+
+	if len(removed) == 0 {
+		out.Println("No envsecrets hooks installed")
+		return nil
+	}
+	for _, name := range removed {
+		out.Printf("Removed %s hook\n", name)
+	}
+	return nil
+}
+
+func runHooksRun(cmd *cobra.Command, args []string) error {
+	switch args[0] {
+	case project.HookPreCommit:
+		return runPreCommitHook()
+	case project.HookPostCheckout, project.HookPostMerge:
+		runRemoteCheckHook(args[0], args[1:])
+		return nil
+	default:
+		return domain.Errorf(domain.ErrInvalidArgs, "unknown hook %q", args[0])
+	}
+}
+
+// runPreCommitHook blocks the commit when staged changes leak the secrets
+// of any namespace in the repository
+func runPreCommitHook() error {
+	out := GetOutput()
+
+	discovery, err := project.NewDiscovery("")
+	if err != nil {
+		return err
+	}
+	namespaces, err := discovery.Namespaces()
+	if err != nil {
+		return err
+	}
+
+	var issues []domain.SafetyIssue
+	seen := make(map[domain.SafetyIssue]bool)
+	for _, ns := range namespaces {
+		found, err := ns.CheckStaged()
+		if err != nil {
+			return err
+		}
+		for _, issue := range found {
+			if !seen[issue] {
+				seen[issue] = true
+				issues = append(issues, issue)
+			}
+		}
+	}
+	if len(issues) == 0 {
+		return nil
+	}
+
+	out.Println("envsecrets: staged changes contain secrets:")
+	printSafetyIssues(out, "  ", issues)
+	out.Println("Unstage them with 'git restore --staged <file>', or bypass with 'git commit --no-verify'.")
+	return domain.Errorf(domain.ErrPermissionDenied, "commit blocked by envsecrets pre-commit hook")
+}
+
+// runRemoteCheckHook warns when a namespace's remote has secrets this
+// machine has not pulled. It never fails: a missing config, passphrase, or
+// network only shows up in verbose output.
+func runRemoteCheckHook(name string, args []string) {
+	out := GetOutput()
+
+	// post-checkout passes <prev> <new> <flag>; flag 0 is a file checkout
+	if name == project.HookPostCheckout && len(args) == 3 && args[2] == "0" {
+		return
+	}
+
+	// Hooks have no terminal to prompt on
+	ui.SetNonInteractive(true)
+
+	discovery, err := project.NewDiscovery("")
+	if err != nil {
+		out.Verbose("envsecrets: skipping remote check: %v", err)
+		return
+	}
+	namespaces, err := discovery.Namespaces()
+	if err != nil {
+		out.Verbose("envsecrets: skipping remote check: %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), hookStatusTimeout)
+	defer cancel()
+
+	for _, ns := range namespaces {
+		if err := checkRemoteChanged(ctx, ns); err != nil {
+			out.Verbose("envsecrets: skipping remote check for %s: %v", namespaceLabel(ns), err)
+		}
+	}
+}
+
+// checkRemoteChanged warns when the namespace's remote is ahead of this machine
+func checkRemoteChanged(ctx context.Context, ns *project.Discovery) error {
+	out := GetOutput()
+
+	pc, err := openNamespace(ctx, ns)
+	if err != nil {
+		return err
+	}
+	defer pc.Close()
+
+	syncer := sync.NewSyncer(pc.Discovery, pc.RepoInfo, pc.Storage, pc.Encrypter, pc.Cache)
+	status, err := syncer.GetSyncStatus(ctx)
+	if err != nil {
+		return err
+	}
+
+	where := ""
+	if ns.IsNested() {
+		where = " in " + ns.Namespace()
+	}
+	switch status.Action {
+	case domain.SyncActionPull, domain.SyncActionPullThenPush, domain.SyncActionFirstPull:
+		out.Warn("envsecrets: remote secrets changed for %s; run 'envsecrets pull'%s", pc.RepoInfo.String(), where)
+	case domain.SyncActionReconcile:
+		out.Warn("envsecrets: remote secrets changed for %s and conflict with local edits; run 'envsecrets pull'%s", pc.RepoInfo.String(), where)
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package cli
+
+import (
+	"context"
+	"errors"
+
+	"github.com/charliek/envsecrets/internal/cache"
+	"github.com/charliek/envsecrets/internal/domain"
+	"github.com/charliek/envsecrets/internal/project"
+	"github.com/charliek/envsecrets/internal/storage"
+	"github.com/spf13/cobra"
+)
+
+var migrateIdentityDryRun bool
+
+var migrateIdentityCmd = &cobra.Command{
+	Use:   "migrate-identity [host|owner]",
+	Short: "Move the project's secrets to a host-qualified (or plain) identity",
+	Long: `Move the project's secrets to another repository identity.
+
+By default a repository is identified by owner/name, so projects with the
+same owner and name on different git hosts (github.com/acme/api and
+gitlab.example.com/acme/api) share storage. The "host" identity prefixes the
+git remote's host: github.com/acme/api.
+
+migrate-identity moves the project's storage prefix and local cache to the
+new identity and writes the matching "identity:" directive to .envsecrets.
+Run from the git root, it also moves nested namespaces that inherit the
+root's identity. Commit .envsecrets afterwards: teammates using the old
+identity push to and pull from the old prefix until they pull the change.
+
+The argument defaults to host; "owner" moves back to owner/name.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runMigrateIdentity,
+}
+
+func init() {
+	migrateIdentityCmd.Flags().BoolVar(&migrateIdentityDryRun, "dry-run", false, "show what would be moved without moving")
+}
+
+// identityMove is one namespace's move between identities
+type identityMove struct {
+	Namespace string `json:"namespace"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Remote    bool   `json:"remote"`
+
+	from  *domain.RepoInfo
+	to    *domain.RepoInfo
+	store storage.Storage
+}
+
+func runMigrateIdentity(cmd *cobra.Command, args []string) error {
+	ctx, cancel := signalContext()
+	defer cancel()
+	out := GetOutput()
+
+	if GetRepo() != "" {
+		return domain.Errorf(domain.ErrInvalidArgs, "migrate-identity cannot be combined with --repo")
+	}
+	scheme := project.IdentityHost
+	if len(args) == 1 {
+		scheme = args[0]
+	}
+	if scheme != project.IdentityHost && scheme != project.IdentityOwner {
+		return domain.Errorf(domain.ErrInvalidArgs, "invalid identity %q (expected %s or %s)", scheme, project.IdentityHost, project.IdentityOwner)
+	}
+
+	discovery, err := discoverProject(cfg)
+	if err != nil {
+		return err
+	}
+	targets, err := identityTargets(discovery)
+	if err != nil {
+		return err
+	}
+
+	var moves []*identityMove
+	defer func() {
+		for _, m := range moves {
+			m.store.Close()
+		}
+	}()
+	for _, ns := range targets {
+		m, err := planIdentityMove(ctx, ns, scheme == project.IdentityHost)
+		if err != nil {
+			return err
+		}
+		if m != nil {
+			moves = append(moves, m)
+		}
+	}
+
+	if migrateIdentityDryRun {
+		out.PrintDryRunHeader()
+		if out.IsJSON() {
+			return out.JSON(moves)
+		}
+		if len(moves) == 0 {
+			out.Printf("Already using the %s identity\n", scheme)
+		}
+		for _, m := range moves {
+			out.Printf("Would move %s to %s\n", m.From, m.To)
+		}
+		return nil
+	}
+
+	for _, m := range moves {
+		if m.Remote {
+			if err := cache.MoveRemote(ctx, m.store, m.from, m.to); err != nil {
+				return err
+			}
+		}
+		if err := cache.MoveLocal(m.from, m.to); err != nil {
+			return err
+		}
+	}
+	if err := discovery.SetIdentityScheme(scheme); err != nil {
+		return err
+	}
+
+	if out.IsJSON() {
+		return out.JSON(moves)
+	}
+	if len(moves) == 0 {
+		out.Printf("Already using the %s identity\n", scheme)
+		return nil
+	}
+	for _, m := range moves {
+		out.Success("Moved %s to %s", m.From, m.To)
+	}
+	out.Printf("Commit %s so teammates use the new identity.\n", discovery.EnvSecretsFile())
+	return nil
+}
+
+// identityTargets returns the namespaces whose identity follows the
+// current project's directive: the project itself and, at the git root,
+// nested namespaces without an identity directive of their own
+func identityTargets(discovery *project.Discovery) ([]*project.Discovery, error) {
+	targets := []*project.Discovery{discovery}
+	if discovery.IsNested() {
+		return targets, nil
+	}
+	namespaces, err := discovery.Namespaces()
+	if err != nil {
+		return nil, err
+	}
+	for _, ns := range namespaces {
+		if !ns.IsNested() {
+			continue
+		}
+		nsConfig, err := project.ParseEnvSecretsFile(ns.EnvSecretsFile())
+		if err != nil && !errors.Is(err, domain.ErrNoEnvFiles) {
+			return nil, err
+		}
+		if nsConfig == nil || nsConfig.Identity == "" {
+			targets = append(targets, ns)
+		}
+	}
+	return targets, nil
+}
+
+// planIdentityMove checks that a namespace can move to the host-qualified
+// (or plain) identity. It returns nil when the namespace already uses it.
+func planIdentityMove(ctx context.Context, ns *project.Discovery, qualify bool) (*identityMove, error) {
+	nsCfg, err := loadConfigFor(ns)
+	if err != nil {
+		return nil, err
+	}
+	ns.SetRemotePreference(nsCfg.Remotes)
+
+	from, err := ns.RepoInfo()
+	if err != nil {
+		return nil, err
+	}
+	to, err := ns.RepoInfoWithHost(qualify)
+	if err != nil {
+		return nil, err
+	}
+	if from.CachePath() == to.CachePath() {
+		return nil, nil
+	}
+
+	store, err := storage.NewGCSStorage(ctx, nsCfg.Bucket, nsCfg.GCSCredentials)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &identityMove{Namespace: namespaceLabel(ns), From: from.String(), To: to.String(), from: from, to: to, store: store}
+	exists, err := store.Exists(ctx, to.CachePath()+"/HEAD")
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+	if exists {
+		store.Close()
+		return nil, domain.Errorf(domain.ErrConflict, "%s already exists in storage; delete it or pull from it instead", to.String())
+	}
+	if m.Remote, err = store.Exists(ctx, from.CachePath()+"/HEAD"); err != nil {
+		store.Close()
+		return nil, err
+	}
+	return m, nil
+}
@@ -116,7 +116,8 @@ func runInit(cmd *cobra.Command, args []string) error {
 	out.Println("How would you like to provide the passphrase?")
 	out.Println("  1. Environment variable")
 	out.Println("  2. Command (e.g., 1Password CLI)")
-	out.Println("  3. Enter manually each time")
+	out.Println("  3. OS keyring (macOS Keychain / Linux Secret Service)")
+	out.Println("  4. Enter manually each time")
 
 	selection, err := prompt.String("Selection", "1")
 	if err != nil {
@@ -152,6 +153,13 @@ func runInit(cmd *cobra.Command, args []string) error {
 		}
 		cfg.PassphraseCommandArgs = args
 	case "3":
+		backend, err := storeKeyringPassphrase(cfg)
+		if err != nil {
+			return err
+		}
+		cfg.PassphraseKeyring = true
+		out.Printf("Passphrase stored in %s.\n", backend)
+	case "4":
 		// No passphrase config - will prompt each time
 		out.Println("Passphrase will be requested when needed.")
 	default:
@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charliek/envsecrets/internal/config"
+	"github.com/charliek/envsecrets/internal/domain"
+	"github.com/spf13/cobra"
+)
+
+var keyringStdin bool
+
+var keyringCmd = &cobra.Command{
+	Use:   "keyring",
+	Short: "Manage the passphrase stored in the OS keyring",
+	Long: `Manage the passphrase stored in the OS keyring.
+
+On macOS the passphrase is stored in the Keychain; on Linux desktops it is
+stored in the Secret Service (GNOME Keyring, KWallet). Headless machines fall
+back to an age-encrypted file in ~/.envsecrets. Set keyring_backend in the
+config to force a specific backend.`,
+}
+
+var keyringSetCmd = &cobra.Command{
+	Use:   "set",
+	Short: "Store the passphrase in the keyring",
+	Long: `Store the passphrase in the keyring and enable passphrase_keyring in the config.
+
+The passphrase is prompted for interactively. Use --stdin to read it from
+standard input instead (e.g. when piping from another secret manager).`,
+	Args: cobra.NoArgs,
+	RunE: runKeyringSet,
+}
+
+var keyringClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove the passphrase from the keyring",
+	Long:  `Remove the passphrase from the keyring and disable passphrase_keyring in the config.`,
+	Args:  cobra.NoArgs,
+	RunE:  runKeyringClear,
+}
+
+func init() {
+	keyringSetCmd.Flags().BoolVar(&keyringStdin, "stdin", false, "read the passphrase from standard input")
+
+	keyringCmd.AddCommand(keyringSetCmd)
+	keyringCmd.AddCommand(keyringClearCmd)
+}
+
+func runKeyringSet(cmd *cobra.Command, args []string) error {
+	out := GetOutput()
+
+	var passphrase string
+	var err error
+	if keyringStdin {
+		passphrase, err = readPassphraseStdin()
+	} else {
+		passphrase, err = config.PromptNewPassphrase()
+	}
+	if err != nil {
+		return err
+	}
+
+	kr, err := config.OpenKeyring(cfg.KeyringBackend)
+	if err != nil {
+		return err
+	}
+	if err := kr.Set(cfg.KeyringAccount(), passphrase); err != nil {
+		return err
+	}
+
+	if !cfg.PassphraseKeyring {
+		if err := cfg.SetPassphraseKeyring(true); err != nil {
+			return err
+		}
+	}
+
+	out.Success("Passphrase stored in %s", kr.Name())
+	return nil
+}
+
+func runKeyringClear(cmd *cobra.Command, args []string) error {
+	out := GetOutput()
+
+	kr, err := config.OpenKeyring(cfg.KeyringBackend)
+	if err != nil {
+		return err
+	}
+	if err := kr.Delete(cfg.KeyringAccount()); err != nil {
+		return err
+	}
+
+	if cfg.PassphraseKeyring {
+		if err := cfg.SetPassphraseKeyring(false); err != nil {
+			return err
+		}
+	}
+
+	out.Success("Passphrase removed from %s", kr.Name())
+	return nil
+}
+
+// readPassphraseStdin reads a single-line passphrase from standard input
+func readPassphraseStdin() (string, error) {
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", domain.Errorf(domain.ErrNoPassphrase, "failed to read passphrase from stdin: %v", err)
+	}
+	pass := strings.TrimRight(line, "\r\n")
+	if pass == "" {
+		return "", domain.Errorf(domain.ErrNoPassphrase, "passphrase cannot be empty")
+	}
+	return pass, nil
+}
+
+// storeKeyringPassphrase prompts for a new passphrase and stores it in the
+// configured keyring. Used by init when the keyring method is selected.
+func storeKeyringPassphrase(c *config.Config) (string, error) {
+	passphrase, err := config.PromptNewPassphrase()
+	if err != nil {
+		return "", err
+	}
+	kr, err := config.OpenKeyring(c.KeyringBackend)
+	if err != nil {
+		return "", err
+	}
+	if err := kr.Set(c.KeyringAccount(), passphrase); err != nil {
+		return "", fmt.Errorf("failed to store passphrase: %w", err)
+	}
+	return kr.Name(), nil
+}
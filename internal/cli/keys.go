@@ -0,0 +1,293 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/charliek/envsecrets/internal/domain"
+	"github.com/charliek/envsecrets/internal/dotenv"
+	"github.com/charliek/envsecrets/internal/sync"
+	"github.com/charliek/envsecrets/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	getRef       string
+	setPush      bool
+	setMessage   string
+	setStrict    bool
+	unsetPush    bool
+	unsetMessage string
+	unsetStrict  bool
+)
+
+var getCmd = &cobra.Command{
+	Use:   "get <file> <key>",
+	Short: "Print one value from a tracked file",
+	Long: `Print the value of one key in a tracked env file, for use in scripts.
+
+The value is read from the working tree, or with --ref from a pushed version
+(HEAD for the latest). Only the value and a newline are printed.`,
+	Example: `  DB_URL=$(envsecrets get .env DATABASE_URL)
+  envsecrets get --ref HEAD .env API_KEY`,
+	Args: cobra.ExactArgs(2),
+	RunE: runGet,
+}
+
+var setCmd = &cobra.Command{
+	Use:   "set <file> <key>[=<value>]...",
+	Short: "Set values in a tracked file",
+	Long: `Set keys in a tracked env file, keeping its comments and formatting.
+
+Give KEY=VALUE to set a value inline, or only KEY to read the value from
+stdin so it stays out of shell history: typed without echo in a terminal,
+or piped, with one trailing newline dropped. Keys not in the file are
+appended, and the file is created if needed.
+
+With --push the working tree is pushed right after, like 'envsecrets push'.`,
+	Example: `  envsecrets set .env LOG_LEVEL=debug
+  envsecrets set .env API_KEY            # prompts for the value
+  pbpaste | envsecrets set .env API_KEY --push -m "Rotate API key"`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: runSet,
+}
+
+var unsetCmd = &cobra.Command{
+	Use:   "unset <file> <key>...",
+	Short: "Remove keys from a tracked file",
+	Long: `Remove keys from a tracked env file, keeping the rest of it unchanged.
+
+With --push the working tree is pushed right after, like 'envsecrets push'.`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: runUnset,
+}
+
+func init() {
+	getCmd.Flags().StringVar(&getRef, "ref", "", "read a pushed version (commit hash, or HEAD) instead of the working tree")
+	setCmd.Flags().BoolVar(&setPush, "push", false, "push after setting")
+	setCmd.Flags().StringVarP(&setMessage, "message", "m", "", "commit message (used with --push)")
+	setCmd.Flags().BoolVar(&setStrict, "strict", false, "with --push, refuse while tracked files are not git-ignored or are committed")
+	unsetCmd.Flags().BoolVar(&unsetPush, "push", false, "push after removing")
+	unsetCmd.Flags().StringVarP(&unsetMessage, "message", "m", "", "commit message (used with --push)")
+	unsetCmd.Flags().BoolVar(&unsetStrict, "strict", false, "with --push, refuse while tracked files are not git-ignored or are committed")
+}
+
+func runGet(cmd *cobra.Command, args []string) error {
+	ctx, cancel := signalContext()
+	defer cancel()
+	out := GetOutput()
+
+	pc, err := NewProjectContext(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer pc.Close()
+
+	files, err := resolveTrackedFiles(ctx, pc, args[:1], getRef != "")
+	if err != nil {
+		return err
+	}
+	file, key := files[0], args[1]
+
+	var content []byte
+	if getRef != "" {
+		syncer := sync.NewSyncer(pc.Discovery, pc.RepoInfo, pc.Storage, pc.Encrypter, pc.Cache)
+		content, err = syncer.ReadFileAt(file, getRef)
+	} else {
+		content, err = pc.ReadProjectFile(file)
+	}
+	if err != nil {
+		return err
+	}
+	f, err := dotenv.Parse(content)
+	if err != nil {
+		return domain.Errorf(domain.ErrInvalidArgs, "failed to parse %s: %v", file, err)
+	}
+	value, ok := f.Lookup(key)
+	if !ok {
+		return domain.Errorf(domain.ErrFileNotFound, "%s is not set in %s", key, file)
+	}
+
+	if out.IsJSON() {
+		return out.JSON(map[string]string{"file": file, "key": key, "value": value})
+	}
+	fmt.Println(value)
+	return nil
+}
+
+func runSet(cmd *cobra.Command, args []string) error {
+	ctx, cancel := signalContext()
+	defer cancel()
+	out := GetOutput()
+
+	assignments, err := parseAssignments(args[1:])
+	if err != nil {
+		return err
+	}
+
+	pc, err := NewProjectContext(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer pc.Close()
+
+	file, err := trackedFileArg(pc, args[0])
+	if err != nil {
+		return err
+	}
+	f, err := readTrackedEnvFile(pc, file)
+	if errors.Is(err, domain.ErrFileNotFound) {
+		f, err = &dotenv.File{}, nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var keys []string
+	for _, a := range assignments {
+		value := a.value
+		if !a.hasValue {
+			if value, err = readSecretValue(a.key); err != nil {
+				return err
+			}
+		}
+		f.Set(a.key, value)
+		keys = append(keys, a.key)
+	}
+	if err := pc.WriteProjectFile(file, f.Bytes()); err != nil {
+		return err
+	}
+	out.Printf("Set %s in %s\n", strings.Join(keys, ", "), file)
+
+	if !setPush {
+		return nil
+	}
+	message := setMessage
+	if message == "" {
+		message = fmt.Sprintf("Set %s in %s", strings.Join(keys, ", "), file)
+	}
+	return pushWorkingTree(ctx, pc, out, message, setStrict)
+}
+
+func runUnset(cmd *cobra.Command, args []string) error {
+	ctx, cancel := signalContext()
+	defer cancel()
+	out := GetOutput()
+
+	pc, err := NewProjectContext(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer pc.Close()
+
+	file, err := trackedFileArg(pc, args[0])
+	if err != nil {
+		return err
+	}
+	f, err := readTrackedEnvFile(pc, file)
+	if err != nil {
+		return err
+	}
+
+	var removed []string
+	for _, key := range args[1:] {
+		if f.Unset(key) {
+			removed = append(removed, key)
+		} else {
+			out.Warn("%s is not set in %s", key, file)
+		}
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+	if err := pc.WriteProjectFile(file, f.Bytes()); err != nil {
+		return err
+	}
+	out.Printf("Removed %s from %s\n", strings.Join(removed, ", "), file)
+
+	if !unsetPush {
+		return nil
+	}
+	message := unsetMessage
+	if message == "" {
+		message = fmt.Sprintf("Remove %s from %s", strings.Join(removed, ", "), file)
+	}
+	return pushWorkingTree(ctx, pc, out, message, unsetStrict)
+}
+
+// trackedFileArg resolves a file argument to the tracked file it names
+func trackedFileArg(pc *ProjectContext, arg string) (string, error) {
+	tracked, err := pc.EnvFiles()
+	if err != nil {
+		return "", err
+	}
+	files, err := selectTrackedFiles([]string{arg}, tracked)
+	if err != nil {
+		return "", err
+	}
+	return files[0], nil
+}
+
+// readTrackedEnvFile parses a tracked file from the working tree
+func readTrackedEnvFile(pc *ProjectContext, file string) (*dotenv.File, error) {
+	content, err := pc.ReadProjectFile(file)
+	if err != nil {
+		return nil, err
+	}
+	f, err := dotenv.Parse(content)
+	if err != nil {
+		return nil, domain.Errorf(domain.ErrInvalidArgs, "failed to parse %s: %v", file, err)
+	}
+	return f, nil
+}
+
+// assignment is one KEY[=VALUE] argument of set
+type assignment struct {
+	key      string
+	value    string
+	hasValue bool
+}
+
+// parseAssignments validates set's KEY[=VALUE] arguments. Only one key can
+// take its value from stdin.
+func parseAssignments(args []string) ([]assignment, error) {
+	assignments := make([]assignment, 0, len(args))
+	fromStdin := 0
+	for _, arg := range args {
+		key, value, hasValue := strings.Cut(arg, "=")
+		if !dotenv.ValidKey(key) {
+			return nil, domain.Errorf(domain.ErrInvalidArgs, "invalid key %q", key)
+		}
+		if !hasValue {
+			fromStdin++
+		}
+		assignments = append(assignments, assignment{key: key, value: value, hasValue: hasValue})
+	}
+	if fromStdin > 1 {
+		return nil, domain.Errorf(domain.ErrInvalidArgs, "only one key can be read from stdin; give the others as KEY=VALUE")
+	}
+	return assignments, nil
+}
+
+// readSecretValue reads a value from stdin: prompted without echo in a
+// terminal, otherwise all of stdin minus one trailing newline
+func readSecretValue(key string) (string, error) {
+	if ui.IsInteractive() {
+		if !ui.CanPrompt() {
+			return "", domain.Errorf(domain.ErrInvalidArgs, "no value for %s; pipe it to stdin or give %s=VALUE", key, key)
+		}
+		return ui.NewPrompt().Password(fmt.Sprintf("Value for %s", key))
+	}
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", fmt.Errorf("failed to read value from stdin: %w", err)
+	}
+	value := string(data)
+	if trimmed, ok := strings.CutSuffix(value, "\r\n"); ok {
+		return trimmed, nil
+	}
+	return strings.TrimSuffix(value, "\n"), nil
+}
@@ -0,0 +1,25 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/charliek/envsecrets/internal/domain"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAssignments(t *testing.T) {
+	assignments, err := parseAssignments([]string{"A=1", "B=x=y", "EMPTY=", "SECRET"})
+	require.NoError(t, err)
+	require.Equal(t, []assignment{
+		{key: "A", value: "1", hasValue: true},
+		{key: "B", value: "x=y", hasValue: true},
+		{key: "EMPTY", value: "", hasValue: true},
+		{key: "SECRET"},
+	}, assignments)
+
+	_, err = parseAssignments([]string{"A", "B"})
+	require.ErrorIs(t, err, domain.ErrInvalidArgs)
+
+	_, err = parseAssignments([]string{"1BAD=x"})
+	require.ErrorIs(t, err, domain.ErrInvalidArgs)
+}
@@ -7,28 +7,33 @@ import (
 	"strings"
 
 	"github.com/charliek/envsecrets/internal/constants"
-	"github.com/charliek/envsecrets/internal/project"
 	"github.com/charliek/envsecrets/internal/storage"
 	"github.com/charliek/envsecrets/internal/ui"
 	"github.com/spf13/cobra"
 )
 
-var listCurrent bool
+var (
+	listCurrent     bool
+	listAllProfiles bool
+)
 
 var listCmd = &cobra.Command{
 	Use:   "list [repo]",
 	Short: "List repositories or files in the bucket",
 	Long: `List repositories or files stored in the bucket.
 
-Without arguments, lists all repositories (owner/repo).
+Without arguments, lists all repositories (owner/repo, or host/owner/repo
+for host-qualified identities).
 With a repo argument, lists files in that repository.
-With --current flag, lists files in the auto-detected current repository.`,
+With --current flag, lists files in the auto-detected current repository.
+With --all-profiles, lists repositories in the bucket of every configured profile.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runList,
 }
 
 func init() {
 	listCmd.Flags().BoolVar(&listCurrent, "current", false, "list files in current repository")
+	listCmd.Flags().BoolVar(&listAllProfiles, "all-profiles", false, "list repositories across all configured profiles")
 }
 
 func runList(cmd *cobra.Command, args []string) error {
@@ -36,9 +41,16 @@ func runList(cmd *cobra.Command, args []string) error {
 	defer cancel()
 	out := GetOutput()
 
+	if listAllProfiles {
+		if listCurrent || len(args) > 0 {
+			return fmt.Errorf("--all-profiles cannot be combined with --current or a repo argument")
+		}
+		return listReposAllProfiles(ctx, out)
+	}
+
 	// Handle --current flag - only needs discovery + storage, no passphrase required
 	if listCurrent {
-		discovery, err := project.NewDiscovery("")
+		discovery, err := discoverProject(cfg)
 		if err != nil {
 			return err
 		}
@@ -71,34 +83,105 @@ func runList(cmd *cobra.Command, args []string) error {
 }
 
 func listRepos(ctx context.Context, store storage.Storage, out *ui.Output) error {
-	// List all objects in bucket
-	objects, err := store.List(ctx, "")
+	repoList, err := collectRepos(ctx, store)
 	if err != nil {
 		return err
 	}
 
-	// Extract unique owner/repo combinations
-	repos := extractReposFromObjects(objects)
-
-	if len(repos) == 0 {
+	if len(repoList) == 0 {
 		out.Println("No repositories found")
 		return nil
 	}
 
+	if out.IsJSON() {
+		return out.JSON(repoList)
+	}
+
+	out.Println("Repositories:")
+	for _, repo := range repoList {
+		out.Printf("  %s\n", repo)
+	}
+
+	return nil
+}
+
+// collectRepos returns the sorted owner/repo names stored in a bucket
+func collectRepos(ctx context.Context, store storage.Storage) ([]string, error) {
+	// List all objects in bucket
+	objects, err := store.List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// Extract unique owner/repo combinations
+	repos := extractReposFromObjects(objects)
+
 	// Sort repos for deterministic output
 	repoList := make([]string, 0, len(repos))
 	for repo := range repos {
 		repoList = append(repoList, repo)
 	}
 	sort.Strings(repoList)
+	return repoList, nil
+}
+
+// listReposAllProfiles lists repositories in each configured profile's bucket.
+// A profile whose bucket cannot be listed is reported and skipped.
+func listReposAllProfiles(ctx context.Context, out *ui.Output) error {
+	names := cfg.Persisted().ProfileNames()
+	if len(names) == 0 {
+		return fmt.Errorf("no profiles configured")
+	}
+
+	type profileRepos struct {
+		Profile string   `json:"profile"`
+		Bucket  string   `json:"bucket"`
+		Repos   []string `json:"repos"`
+		Error   string   `json:"error,omitempty"`
+	}
+	var results []profileRepos
+
+	for _, name := range names {
+		profileCfg, err := cfg.Persisted().ForProfile(name)
+		if err != nil {
+			return err
+		}
+		entry := profileRepos{Profile: name, Bucket: profileCfg.Bucket, Repos: []string{}}
+
+		store, err := storage.NewGCSStorage(ctx, profileCfg.Bucket, profileCfg.GCSCredentials)
+		if err != nil {
+			entry.Error = err.Error()
+		} else {
+			repos, err := collectRepos(ctx, store)
+			store.Close()
+			if err != nil {
+				entry.Error = err.Error()
+			} else {
+				entry.Repos = repos
+			}
+		}
+		results = append(results, entry)
+	}
 
 	if out.IsJSON() {
-		return out.JSON(repoList)
+		return out.JSON(results)
 	}
 
-	out.Println("Repositories:")
-	for _, repo := range repoList {
-		out.Printf("  %s\n", repo)
+	for i, r := range results {
+		if i > 0 {
+			out.Println()
+		}
+		out.Printf("Profile %s (%s):\n", r.Profile, r.Bucket)
+		switch {
+		case r.Error != "":
+			out.Printf("  Error: %s\n", r.Error)
+		case len(r.Repos) == 0:
+			out.Println("  No repositories found")
+		default:
+			for _, repo := range r.Repos {
+				out.Printf("  %s\n", repo)
+			}
+		}
 	}
 
 	return nil
@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/charliek/envsecrets/internal/domain"
+	"github.com/charliek/envsecrets/internal/project"
+)
+
+// listNamespaces returns every .envsecrets namespace in the current git
+// repository for commands run with --all
+func listNamespaces() ([]*project.Discovery, error) {
+	if GetRepo() != "" {
+		return nil, domain.Errorf(domain.ErrInvalidArgs, "--all cannot be combined with --repo")
+	}
+	discovery, err := project.NewDiscovery("")
+	if err != nil {
+		return nil, err
+	}
+	namespaces, err := discovery.Namespaces()
+	if err != nil {
+		return nil, err
+	}
+	if len(namespaces) == 0 {
+		return nil, domain.ErrNoEnvFiles
+	}
+	return namespaces, nil
+}
+
+// openNamespace creates a project context for a namespace using the config
+// profile its own .envsecrets selects
+func openNamespace(ctx context.Context, ns *project.Discovery) (*ProjectContext, error) {
+	nsCfg, err := loadConfigFor(ns)
+	if err != nil {
+		return nil, err
+	}
+	return NewNamespaceContext(ctx, nsCfg, ns)
+}
+
+// namespaceLabel is how a namespace is shown in --all output
+func namespaceLabel(ns *project.Discovery) string {
+	if ns.Namespace() == "" {
+		return "."
+	}
+	return ns.Namespace()
+}
+
+// namespacesFailed summarizes failures of an --all run. Each failure was
+// already printed; the exit code is the first failure's.
+func namespacesFailed(failed, total int, first error) error {
+	if failed == 0 {
+		return nil
+	}
+	return domain.NewExitCodeError(fmt.Errorf("%d of %d namespaces failed", failed, total), domain.GetExitCode(first))
+}
@@ -21,7 +21,15 @@ var pullCmd = &cobra.Command{
 	Long: `Download and decrypt environment files from GCS.
 
 Files are downloaded from the configured GCS bucket, decrypted, and written
-to the project directory.`,
+to the project directory.
+
+When a file changed both locally and remotely since this machine last
+synced, pull merges it key by key: keys changed on only one side are taken
+from that side, keeping the local file's ordering and comments. Files with
+keys changed differently on both sides are conflicts: interactively you can
+view a masked diff, choose a side per key, edit the file with conflict
+markers in $EDITOR, or overwrite or skip the whole file. Without a terminal
+pull aborts instead. --force skips merging and takes the remote file.`,
 	RunE: runPull,
 }
 
@@ -61,26 +69,12 @@ func runPull(cmd *cobra.Command, args []string) error {
 	// Set up conflict resolver
 	if pullSkipConflicts {
 		// Skip all conflicts automatically
-		opts.ConflictResolver = func(f string) (sync.ConflictAction, error) {
-			return sync.ConflictSkip, nil
+		opts.ConflictResolver = func(c *sync.Conflict) (sync.Resolution, error) {
+			return sync.Resolution{Action: sync.ConflictSkip}, nil
 		}
 	} else if !pullForce && ui.CanPrompt() {
 		// Interactive conflict resolution
-		prompt := ui.NewPrompt()
-		opts.ConflictResolver = func(f string) (sync.ConflictAction, error) {
-			choice, err := prompt.ConflictChoice(f)
-			if err != nil {
-				return sync.ConflictAbort, err
-			}
-			switch choice {
-			case "o":
-				return sync.ConflictOverwrite, nil
-			case "s":
-				return sync.ConflictSkip, nil
-			default:
-				return sync.ConflictAbort, nil
-			}
-		}
+		opts.ConflictResolver = conflictResolver(pc, out, ui.NewPrompt())
 	}
 
 	if pullDryRun {
@@ -111,6 +105,9 @@ func runPull(cmd *cobra.Command, args []string) error {
 	if result.FilesDeleted > 0 {
 		out.Printf("  %d file(s) deleted (remote dropped them)\n", result.FilesDeleted)
 	}
+	if result.FilesMerged > 0 {
+		out.Printf("  %d file(s) merged (push to publish the result)\n", result.FilesMerged)
+	}
 	if result.FilesKeptLocal > 0 {
 		out.Printf("  %d file(s) kept (local edits preserved; push to publish)\n", result.FilesKeptLocal)
 	}
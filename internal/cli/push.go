@@ -1,10 +1,13 @@
 package cli
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/charliek/envsecrets/internal/domain"
+	"github.com/charliek/envsecrets/internal/project"
 	"github.com/charliek/envsecrets/internal/sync"
 	"github.com/charliek/envsecrets/internal/ui"
 	"github.com/spf13/cobra"
@@ -15,6 +18,7 @@ var (
 	pushDryRun       bool
 	pushForce        bool
 	pushAllowMissing bool
+	pushStrict       bool
 )
 
 var pushCmd = &cobra.Command{
@@ -23,7 +27,16 @@ var pushCmd = &cobra.Command{
 	Long: `Encrypt and upload environment files to GCS.
 
 Files listed in .envsecrets are encrypted with age and uploaded to the
-configured GCS bucket.`,
+configured GCS bucket.
+
+Before pushing, tracked files are checked for accidental-commit risks in the
+project's git (not ignored by .gitignore, or committed). These are warnings
+unless --strict is set, in which case push refuses until they are fixed.
+
+Tracked files selected by "example:" directives in .envsecrets get a
+<file>.example sibling regenerated after the push: the same keys, comments,
+and ordering with values blanked (or set from a "# example: <value>" comment
+above the key). Commit the example files.`,
 	RunE: runPush,
 }
 
@@ -32,6 +45,7 @@ func init() {
 	pushCmd.Flags().BoolVar(&pushDryRun, "dry-run", false, "show what would be pushed without pushing")
 	pushCmd.Flags().BoolVar(&pushForce, "force", false, "force push even with conflicts")
 	pushCmd.Flags().BoolVar(&pushAllowMissing, "allow-missing", false, "allow push with missing tracked files (for non-interactive mode)")
+	pushCmd.Flags().BoolVar(&pushStrict, "strict", false, "refuse to push while tracked files are not git-ignored or are committed")
 }
 
 func runPush(cmd *cobra.Command, args []string) error {
@@ -47,11 +61,15 @@ func runPush(cmd *cobra.Command, args []string) error {
 	defer pc.Close()
 
 	// Check for missing tracked files
-	files, err := pc.Discovery.EnvFiles()
+	files, err := pc.EnvFiles()
 	if err != nil {
 		return err
 	}
 
+	if err := checkPushSafety(pc, out, files, pushStrict); err != nil {
+		return err
+	}
+
 	var missing []string
 	for _, f := range files {
 		if !pc.Discovery.FileExists(f) {
@@ -106,6 +124,11 @@ func runPush(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		if errors.Is(err, domain.ErrNothingToCommit) {
 			out.Println("Nothing to push - all files are up to date")
+			updated, err := pc.Discovery.UpdateExamples(files, pushDryRun)
+			if err != nil {
+				return err
+			}
+			printExamplesUpdated(out, updated, pushDryRun)
 			return nil
 		}
 		// ErrDivergedHistory covers several distinct causes (overlap with a
@@ -122,6 +145,11 @@ func runPush(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	// Regenerate example files from the pushed content
+	if result.ExamplesUpdated, err = pc.Discovery.UpdateExamples(files, pushDryRun); err != nil {
+		return err
+	}
+
 	// Output results
 	if out.IsJSON() {
 		return out.JSON(result)
@@ -148,9 +176,97 @@ func runPush(cmd *cobra.Command, args []string) error {
 		out.Printf("Commit: %s\n", ui.TruncateHash(result.CommitHash))
 	}
 
+	printExamplesUpdated(out, result.ExamplesUpdated, pushDryRun)
+
 	if result.Warning != "" {
 		out.Warn("%s", result.Warning)
 	}
 
 	return nil
 }
+
+// printExamplesUpdated lists the example files push regenerated
+func printExamplesUpdated(out *ui.Output, examples []string, dryRun bool) {
+	if len(examples) == 0 || out.IsJSON() {
+		return
+	}
+	verb := "Updated"
+	if dryRun {
+		verb = "Would update"
+	}
+	out.Println()
+	for _, e := range examples {
+		out.Printf("%s %s\n", verb, e)
+	}
+}
+
+// checkPushSafety warns when tracked files could leak into the project's
+// git. With strict it refuses instead, and also when the check fails.
+func checkPushSafety(pc *ProjectContext, out *ui.Output, files []string, strict bool) error {
+	issues, err := pc.Discovery.CheckSafety(files, project.SafetyOptions{})
+	if err != nil {
+		if strict {
+			return err
+		}
+		out.Verbose("Could not check git safety: %v", err)
+	}
+	if len(issues) > 0 {
+		out.Warn("Git safety issues:")
+		printSafetyIssues(out, "  ", issues)
+		if blocking := blockingSafetyIssues(issues); strict && len(blocking) > 0 {
+			return domain.Errorf(domain.ErrPermissionDenied, "push refused (--strict): %d tracked file(s) could be committed to git", len(blocking))
+		}
+		out.Println()
+	}
+	return nil
+}
+
+// pushWorkingTree pushes the working tree after a command changed a tracked
+// file, with the same git safety check as push. It refuses when a tracked
+// file is missing locally, since the push would delete it remotely without
+// the confirmation push asks for.
+func pushWorkingTree(ctx context.Context, pc *ProjectContext, out *ui.Output, message string, strict bool) error {
+	files, err := pc.EnvFiles()
+	if err != nil {
+		return err
+	}
+	if err := checkPushSafety(pc, out, files, strict); err != nil {
+		return err
+	}
+	var missing []string
+	for _, f := range files {
+		if !pc.Discovery.FileExists(f) {
+			missing = append(missing, f)
+		}
+	}
+	if len(missing) > 0 {
+		return domain.Errorf(domain.ErrInvalidArgs, "not pushing: tracked files missing locally would be deleted remotely: %s; run 'envsecrets push' to confirm", strings.Join(missing, ", "))
+	}
+
+	syncer := sync.NewSyncer(pc.Discovery, pc.RepoInfo, pc.Storage, pc.Encrypter, pc.Cache)
+	result, err := syncer.Push(ctx, sync.PushOptions{Message: message})
+	if err != nil {
+		if errors.Is(err, domain.ErrNothingToCommit) {
+			out.Println("Nothing to push - all files are up to date")
+			return nil
+		}
+		if errors.Is(err, domain.ErrDivergedHistory) {
+			out.Warn("push refused: %v", err)
+			out.Println("  Resolve with: envsecrets pull, then envsecrets push")
+		}
+		return err
+	}
+
+	updated, err := pc.Discovery.UpdateExamples(files, false)
+	if err != nil {
+		return err
+	}
+	if result.CommitHash != "" {
+		out.Printf("Pushed commit %s\n", ui.TruncateHash(result.CommitHash))
+	}
+	printExamplesUpdated(out, updated, false)
+	if result.Warning != "" {
+		out.Warn("%s", result.Warning)
+	}
+	return nil
+}
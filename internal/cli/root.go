@@ -2,13 +2,16 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
 	"github.com/charliek/envsecrets/internal/config"
+	"github.com/charliek/envsecrets/internal/constants"
 	"github.com/charliek/envsecrets/internal/domain"
+	"github.com/charliek/envsecrets/internal/project"
 	"github.com/charliek/envsecrets/internal/ui"
 	"github.com/charliek/envsecrets/internal/version"
 	"github.com/spf13/cobra"
@@ -26,6 +29,7 @@ var (
 	jsonOut        bool
 	repo           string
 	nonInteractive bool
+	profileName    string
 
 	// Shared state
 	cfg    *config.Config
@@ -56,12 +60,8 @@ providing secure team-wide access with version history.`,
 
 		// Load configuration
 		var err error
-		cfg, err = config.Load(cfgFile)
-		if err != nil {
-			return err
-		}
-
-		return nil
+		cfg, err = loadConfig()
+		return err
 	},
 	SilenceUsage:  true,
 	SilenceErrors: true,
@@ -78,6 +78,12 @@ func needsConfig(cmd *cobra.Command) bool {
 		"version":    true,
 	}
 
+	// config subcommands work on the file directly, even when it is invalid;
+	// hooks subcommands load it themselves only when they need it
+	if cmd.Parent() != nil && (cmd.Parent().Name() == "config" || cmd.Parent().Name() == "hooks") {
+		return false
+	}
+
 	return !noConfigCmds[cmd.Name()]
 }
 
@@ -85,6 +91,12 @@ func needsConfig(cmd *cobra.Command) bool {
 func Execute() error {
 	err := rootCmd.Execute()
 	if err != nil {
+		// A child process run by envsecrets reports its own failure;
+		// only its exit code is passed on
+		if errors.Is(err, domain.ErrCommandFailed) {
+			return domain.WrapWithExitCode(err)
+		}
+
 		// Print error if output is available
 		if output != nil {
 			output.Error("%v", err)
@@ -103,6 +115,7 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default: ~/.envsecrets/config.yaml)")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
 	rootCmd.PersistentFlags().BoolVar(&jsonOut, "json", false, "output in JSON format")
+	rootCmd.PersistentFlags().StringVar(&profileName, "profile", "", "config profile to use (default: $ENVSECRETS_PROFILE or default_profile)")
 	rootCmd.PersistentFlags().StringVarP(&repo, "repo", "r", "", "override repository (owner/name)")
 	rootCmd.PersistentFlags().BoolVar(&nonInteractive, "non-interactive", false, "disable interactive prompts (for CI/CD)")
 
@@ -119,12 +132,64 @@ func init() {
 	rootCmd.AddCommand(pullCmd)
 	rootCmd.AddCommand(logCmd)
 	rootCmd.AddCommand(diffCmd)
+	rootCmd.AddCommand(runCmd)
+	rootCmd.AddCommand(getCmd)
+	rootCmd.AddCommand(setCmd)
+	rootCmd.AddCommand(unsetCmd)
+	rootCmd.AddCommand(editCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(ciExportCmd)
 	rootCmd.AddCommand(revertCmd)
 	rootCmd.AddCommand(listCmd)
+	rootCmd.AddCommand(addCmd)
 	rootCmd.AddCommand(rmCmd)
 	rootCmd.AddCommand(deleteCmd)
 	rootCmd.AddCommand(rotateCmd)
 	rootCmd.AddCommand(verifyCmd)
+	rootCmd.AddCommand(keyringCmd)
+	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(hooksCmd)
+	rootCmd.AddCommand(migrateIdentityCmd)
+}
+
+// loadConfig loads the config file, selects the effective profile for the
+// current project, and applies ENVSECRETS_* environment overrides
+func loadConfig() (*config.Config, error) {
+	// Project directives are best-effort here: a missing or unparsable
+	// .envsecrets is reported by the command that actually needs it.
+	discovery, _ := project.NewDiscovery("")
+	return loadConfigFor(discovery)
+}
+
+// loadConfigFor is loadConfig for a specific project (nil for none)
+func loadConfigFor(discovery *project.Discovery) (*config.Config, error) {
+	loaded, err := config.Load(cfgFile)
+	if err != nil {
+		return nil, err
+	}
+	loaded, err = selectConfig(loaded, discovery)
+	if err != nil {
+		return nil, err
+	}
+	return loaded.ApplyEnv()
+}
+
+// selectConfig picks the effective profile. An explicit --profile or
+// ENVSECRETS_PROFILE always wins; otherwise profile:/bucket: directives in
+// the project's .envsecrets apply, then default_profile.
+func selectConfig(loaded *config.Config, discovery *project.Discovery) (*config.Config, error) {
+	if profileName != "" || os.Getenv(constants.ProfileEnvVar) != "" {
+		return loaded.SelectProfile(profileName)
+	}
+
+	if discovery != nil {
+		envConfig, err := project.ParseEnvSecretsFile(discovery.EnvSecretsFile())
+		if err == nil && (envConfig.Profile != "" || envConfig.Bucket != "") {
+			return loaded.SelectForProject(envConfig.Profile, envConfig.Bucket)
+		}
+	}
+
+	return loaded.SelectProfile("")
 }
 
 // GetConfig returns the loaded configuration (for use by subcommands)
@@ -0,0 +1,221 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/charliek/envsecrets/internal/domain"
+	"github.com/charliek/envsecrets/internal/sync"
+	"github.com/charliek/envsecrets/internal/ui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	runFiles   []string
+	runRef     string
+	runKeepEnv bool
+)
+
+var runCmd = &cobra.Command{
+	Use:   "run [--file <file>]... [--ref <ref>] -- <command> [args...]",
+	Short: "Run a command with secrets in its environment",
+	Long: `Run a command with the variables of tracked env files in its environment.
+
+Files are decrypted from the cache at the remote HEAD (or --ref) in memory;
+nothing is written to the working tree, and local edits are not used. By
+default every tracked file is loaded, in .envsecrets order; --file (repeatable)
+loads only the given files, in the order given. Later files override earlier
+ones.
+
+Secrets override variables already set in the environment unless --keep-env
+is set. Signals are forwarded to the command (except Ctrl-C and Ctrl-\, which
+a terminal already sends it), and envsecrets exits with the command's exit
+code.`,
+	Example: `  envsecrets run -- npm start
+  envsecrets run --file .env --file .env.local -- ./server --port 8080
+  envsecrets run --keep-env -- env`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runRun,
+}
+
+func init() {
+	runCmd.Flags().StringArrayVarP(&runFiles, "file", "f", nil, "tracked file to load (repeatable; default: all tracked files)")
+	runCmd.Flags().StringVar(&runRef, "ref", "", "load a specific version (commit hash)")
+	runCmd.Flags().BoolVar(&runKeepEnv, "keep-env", false, "let variables already in the environment take precedence over secrets")
+	// Everything after the command belongs to the command
+	runCmd.Flags().SetInterspersed(false)
+}
+
+func runRun(cmd *cobra.Command, args []string) error {
+	out := GetOutput()
+
+	secrets, err := loadRunSecrets(out)
+	if err != nil {
+		return err
+	}
+	return runChild(args, mergeEnviron(os.Environ(), secrets, !runKeepEnv))
+}
+
+// loadRunSecrets decrypts the selected files and returns their variables
+// as KEY=value pairs, each key once with the value of the last file that
+// sets it
+func loadRunSecrets(out *ui.Output) ([]string, error) {
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	pc, err := NewProjectContext(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer pc.Close()
+
+	if err := pc.Cache.SyncFromStorage(ctx); err != nil {
+		out.Warn("could not sync from remote, using cached secrets: %v", err)
+	}
+
+	files, err := pc.EnvFiles()
+	if err != nil {
+		return nil, err
+	}
+	explicit := len(runFiles) > 0
+	if explicit {
+		if files, err = selectTrackedFiles(runFiles, files); err != nil {
+			return nil, err
+		}
+	}
+
+	syncer := sync.NewSyncer(pc.Discovery, pc.RepoInfo, pc.Storage, pc.Encrypter, pc.Cache)
+	loaded, err := loadEnvFiles(out, files, explicit, func(file string) ([]byte, error) {
+		return syncer.ReadFileAt(file, runRef)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	vars := mergeEnvVars(loaded)
+	secrets := make([]string, len(vars))
+	for i, v := range vars {
+		secrets[i] = v.Key + "=" + v.Value
+	}
+	return secrets, nil
+}
+
+// selectTrackedFiles returns the requested files, which must be tracked, in
+// the order requested
+func selectTrackedFiles(requested, tracked []string) ([]string, error) {
+	isTracked := make(map[string]bool, len(tracked))
+	for _, f := range tracked {
+		isTracked[f] = true
+	}
+	selected := make([]string, 0, len(requested))
+	for _, f := range requested {
+		clean := filepath.ToSlash(filepath.Clean(f))
+		if !isTracked[clean] {
+			return nil, domain.Errorf(domain.ErrInvalidArgs, "%s is not tracked in .envsecrets", f)
+		}
+		selected = append(selected, clean)
+	}
+	return selected, nil
+}
+
+// mergeEnviron adds secrets to an environment in KEY=value form. A secret
+// replaces a variable already set when override is true and is dropped
+// otherwise.
+func mergeEnviron(environ, secrets []string, override bool) []string {
+	merged := append([]string(nil), environ...)
+	index := make(map[string]int, len(merged))
+	for i, kv := range merged {
+		key, _, _ := strings.Cut(kv, "=")
+		index[key] = i
+	}
+	for _, kv := range secrets {
+		key, _, _ := strings.Cut(kv, "=")
+		if i, ok := index[key]; ok {
+			if override {
+				merged[i] = kv
+			}
+			continue
+		}
+		index[key] = len(merged)
+		merged = append(merged, kv)
+	}
+	return merged
+}
+
+// forwardedSignals are passed on to the child rather than stopping envsecrets
+var forwardedSignals = []os.Signal{os.Interrupt, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT}
+
+// terminalSignals are the forwarded signals a terminal's Ctrl-C and Ctrl-\
+// send to the whole foreground process group, child included
+var terminalSignals = []os.Signal{os.Interrupt, syscall.SIGQUIT}
+
+// stdinIsTerminal reports whether the child shares envsecrets' terminal
+var stdinIsTerminal = ui.IsInteractive
+
+// shouldForward reports whether sig must be passed on to the child. With a
+// terminal the child already got terminal signals itself, and a second copy
+// can turn a graceful shutdown into a forced one.
+func shouldForward(sig os.Signal, onTerminal bool) bool {
+	return !onTerminal || !slices.Contains(terminalSignals, sig)
+}
+
+// runChild runs a command with env and the terminal's stdio, forwarding
+// signals it would not otherwise get. A failing command yields an error
+// carrying its exit code.
+func runChild(args, env []string) error {
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Env = env
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, forwardedSignals...)
+	defer signal.Stop(signals)
+
+	if err := cmd.Start(); err != nil {
+		return domain.Errorf(domain.ErrInvalidArgs, "failed to start %s: %v", args[0], err)
+	}
+
+	onTerminal := stdinIsTerminal()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				if shouldForward(sig, onTerminal) {
+					_ = cmd.Process.Signal(sig)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	err := cmd.Wait()
+	if err == nil {
+		return nil
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return domain.NewExitCodeError(fmt.Errorf("%w: %s: %v", domain.ErrCommandFailed, args[0], err), childExitCode(exitErr))
+	}
+	return fmt.Errorf("failed to run %s: %w", args[0], err)
+}
+
+// childExitCode returns the command's exit code, or 128 plus the signal
+// number when a signal killed it, as shells report it
+func childExitCode(err *exec.ExitError) int {
+	if status, ok := err.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	return err.ExitCode()
+}
@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/charliek/envsecrets/internal/domain"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeEnviron(t *testing.T) {
+	environ := []string{"PATH=/bin", "API_KEY=from-shell"}
+	secrets := []string{"API_KEY=secret", "DB_URL=postgres://db"}
+
+	require.Equal(t, []string{"PATH=/bin", "API_KEY=secret", "DB_URL=postgres://db"}, mergeEnviron(environ, secrets, true))
+	require.Equal(t, []string{"PATH=/bin", "API_KEY=from-shell", "DB_URL=postgres://db"}, mergeEnviron(environ, secrets, false))
+	require.Equal(t, []string{"PATH=/bin", "API_KEY=from-shell"}, environ)
+}
+
+func TestSelectTrackedFiles(t *testing.T) {
+	tracked := []string{".env", "app/.env.local"}
+
+	files, err := selectTrackedFiles([]string{"./app/.env.local", ".env"}, tracked)
+	require.NoError(t, err)
+	require.Equal(t, []string{"app/.env.local", ".env"}, files)
+
+	_, err = selectTrackedFiles([]string{".env.prod"}, tracked)
+	require.ErrorIs(t, err, domain.ErrInvalidArgs)
+}
+
+func TestRunChild(t *testing.T) {
+	require.NoError(t, runChild([]string{"sh", "-c", `test "$SECRET" = value`}, []string{"SECRET=value"}))
+
+	err := runChild([]string{"sh", "-c", "exit 3"}, nil)
+	require.ErrorIs(t, err, domain.ErrCommandFailed)
+	require.Equal(t, 3, domain.GetExitCode(err))
+
+	err = runChild([]string{"sh", "-c", "kill -TERM $$"}, nil)
+	require.Equal(t, 128+15, domain.GetExitCode(err))
+}
+
+// TestRunChildHelper is the child of TestRunChild_SignalForwarding. It counts
+// the SIGINTs it receives until $DIR/done exists, then writes $DIR/count.
+func TestRunChildHelper(t *testing.T) {
+	dir := os.Getenv("ENVSECRETS_SIGNAL_HELPER_DIR")
+	if dir == "" {
+		t.Skip("only runs as the child of TestRunChild_SignalForwarding")
+	}
+	signals := make(chan os.Signal, 10)
+	signal.Notify(signals, os.Interrupt)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pid"), []byte(strconv.Itoa(os.Getpid())), 0600))
+
+	count := 0
+	for {
+		select {
+		case <-signals:
+			count++
+		case <-time.After(10 * time.Millisecond):
+			if _, err := os.Stat(filepath.Join(dir, "done")); err == nil {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "count"), []byte(strconv.Itoa(count)), 0600))
+				os.Exit(0)
+			}
+		}
+	}
+}
+
+func TestRunChild_SignalForwarding(t *testing.T) {
+	countSIGINTs := func(t *testing.T, onTerminal bool) int {
+		t.Helper()
+		saved := stdinIsTerminal
+		stdinIsTerminal = func() bool { return onTerminal }
+		defer func() { stdinIsTerminal = saved }()
+
+		dir := t.TempDir()
+		result := make(chan error, 1)
+		go func() {
+			result <- runChild([]string{os.Args[0], "-test.run=^TestRunChildHelper$"}, []string{"ENVSECRETS_SIGNAL_HELPER_DIR=" + dir})
+		}()
+
+		var pid int
+		require.Eventually(t, func() bool {
+			content, err := os.ReadFile(filepath.Join(dir, "pid"))
+			if err != nil {
+				return false
+			}
+			pid, err = strconv.Atoi(string(content))
+			return err == nil
+		}, 10*time.Second, 10*time.Millisecond)
+
+		if onTerminal {
+			// A terminal's Ctrl-C reaches the child directly as well
+			child, err := os.FindProcess(pid)
+			require.NoError(t, err)
+			require.NoError(t, child.Signal(os.Interrupt))
+		}
+		self, err := os.FindProcess(os.Getpid())
+		require.NoError(t, err)
+		require.NoError(t, self.Signal(os.Interrupt))
+
+		time.Sleep(300 * time.Millisecond)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "done"), nil, 0600))
+		require.NoError(t, <-result)
+
+		content, err := os.ReadFile(filepath.Join(dir, "count"))
+		require.NoError(t, err)
+		count, err := strconv.Atoi(string(content))
+		require.NoError(t, err)
+		return count
+	}
+
+	require.Equal(t, 1, countSIGINTs(t, false), "SIGINT sent to envsecrets is forwarded")
+	require.Equal(t, 1, countSIGINTs(t, true), "terminal SIGINT is not forwarded a second time")
+}
+
+func TestShouldForward(t *testing.T) {
+	require.True(t, shouldForward(os.Interrupt, false))
+	require.False(t, shouldForward(os.Interrupt, true))
+	require.False(t, shouldForward(syscall.SIGQUIT, true))
+	require.True(t, shouldForward(syscall.SIGTERM, true))
+	require.True(t, shouldForward(syscall.SIGHUP, true))
+}
@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"github.com/charliek/envsecrets/internal/domain"
+	"github.com/charliek/envsecrets/internal/project"
+	"github.com/charliek/envsecrets/internal/ui"
+)
+
+// printSafetyIssues prints one line per accidental-commit risk with the fix
+func printSafetyIssues(out *ui.Output, indent string, issues []domain.SafetyIssue) {
+	for _, issue := range issues {
+		switch issue.Problem {
+		case domain.SafetyNotIgnored:
+			out.Printf("%s! %s is not ignored by .gitignore (fix: envsecrets doctor --fix)\n", indent, issue.Path)
+		case domain.SafetyInIndex:
+			out.Printf("%s! %s is committed to git (fix: git rm --cached %s)\n", indent, issue.Path, issue.Path)
+		case domain.SafetyInHistory:
+			out.Printf("%s! %s is in git history at %s; rotate these secrets and consider rewriting history\n",
+				indent, issue.Path, ui.TruncateHash(issue.Commit))
+		case domain.SafetySecretValue:
+			out.Printf("%s! %s contains the value of %s from %s\n", indent, issue.Path, issue.Key, issue.Source)
+		}
+	}
+}
+
+// blockingSafetyIssues returns the issues --strict refuses to push with.
+// History cannot be fixed by the user short of a rewrite, so it only warns.
+func blockingSafetyIssues(issues []domain.SafetyIssue) []domain.SafetyIssue {
+	var blocking []domain.SafetyIssue
+	for _, issue := range issues {
+		if issue.Problem != domain.SafetyInHistory {
+			blocking = append(blocking, issue)
+		}
+	}
+	return blocking
+}
+
+// fixGitignoreIssues adds every not-ignored file to the managed block in the
+// project's .gitignore and returns how many were added
+func fixGitignoreIssues(discovery *project.Discovery, issues []domain.SafetyIssue) (int, error) {
+	fixed := 0
+	for _, issue := range issues {
+		if issue.Problem != domain.SafetyNotIgnored {
+			continue
+		}
+		if err := project.AddToGitignoreMarker(discovery.GitignorePath(), issue.Path); err != nil {
+			return fixed, err
+		}
+		fixed++
+	}
+	return fixed, nil
+}
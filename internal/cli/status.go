@@ -3,9 +3,11 @@ package cli
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/charliek/envsecrets/internal/domain"
+	"github.com/charliek/envsecrets/internal/project"
 	"github.com/charliek/envsecrets/internal/sync"
 	"github.com/charliek/envsecrets/internal/ui"
 	"github.com/spf13/cobra"
@@ -15,6 +17,8 @@ import (
 // emits the "you may have unpushed work elsewhere" nudge.
 const staleBaselineThreshold = 7 * 24 * time.Hour
 
+var statusAll bool
+
 var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show repository info, file status, and recommended next action",
@@ -26,14 +30,24 @@ Displays:
 - Remote status and HEAD provenance (who pushed it, when)
 - This machine's last-synced commit and how stale it is
 - Per-file status against the local cache
-- A recommendation that tells you exactly what to run next`,
+- A recommendation that tells you exactly what to run next
+
+In a monorepo with nested .envsecrets files, status reports the namespace
+containing the current directory; --all reports every namespace.`,
 	RunE: runStatus,
 }
 
+func init() {
+	statusCmd.Flags().BoolVar(&statusAll, "all", false, "show every .envsecrets namespace in the git repository")
+}
+
 func runStatus(cmd *cobra.Command, args []string) error {
 	ctx, cancel := signalContext()
 	defer cancel()
-	out := GetOutput()
+
+	if statusAll {
+		return runStatusAll(ctx)
+	}
 
 	// Create project context
 	pc, err := NewProjectContext(ctx, cfg)
@@ -42,20 +56,106 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	}
 	defer pc.Close()
 
+	if GetOutput().IsJSON() {
+		data, err := statusJSONData(ctx, pc)
+		if err != nil {
+			return err
+		}
+		return GetOutput().JSON(data)
+	}
+	return printStatus(ctx, pc)
+}
+
+// runStatusAll reports every namespace, continuing past failures
+// and reports them together at the end
+func runStatusAll(ctx context.Context) error {
+	out := GetOutput()
+
+	namespaces, err := listNamespaces()
+	if err != nil {
+		return err
+	}
+
+	var results []map[string]interface{}
+	var firstErr error
+	failed := 0
+	for i, ns := range namespaces {
+		if !out.IsJSON() {
+			if i > 0 {
+				out.Println()
+			}
+			out.Printf("== %s ==\n", namespaceLabel(ns))
+		}
+
+		err := func() error {
+			pc, err := openNamespace(ctx, ns)
+			if err != nil {
+				return err
+			}
+			defer pc.Close()
+
+			if !out.IsJSON() {
+				return printStatus(ctx, pc)
+			}
+			data, err := statusJSONData(ctx, pc)
+			if err != nil {
+				return err
+			}
+			results = append(results, data)
+			return nil
+		}()
+		if err == nil {
+			continue
+		}
+		failed++
+		if firstErr == nil {
+			firstErr = err
+		}
+		if out.IsJSON() {
+			results = append(results, map[string]interface{}{"namespace": ns.Namespace(), "error": err.Error()})
+		} else {
+			out.Error("%v", err)
+		}
+	}
+
+	if out.IsJSON() {
+		if err := out.JSON(results); err != nil {
+			return err
+		}
+	}
+	return namespacesFailed(failed, len(namespaces), firstErr)
+}
+
+// printStatus prints the human-readable status of one project
+func printStatus(ctx context.Context, pc *ProjectContext) error {
+	out := GetOutput()
+
 	// Compute the full sync picture once. GetSyncStatus internally calls
 	// SyncFromStorage when a remote exists, so the cache reflects remote
 	// state by the time we read per-file statuses below.
 	syncer := sync.NewSyncer(pc.Discovery, pc.RepoInfo, pc.Storage, pc.Encrypter, pc.Cache)
 	syncStatus, syncErr := syncer.GetSyncStatus(ctx)
 
-	if out.IsJSON() {
-		return outputStatusJSON(pc, ctx, syncStatus, syncErr)
-	}
-
 	out.Println("Repository:", pc.RepoInfo.String())
-	out.Println("Bucket:", cfg.Bucket)
+	if pc.Discovery != nil && pc.Discovery.IsNested() {
+		out.Println("Namespace:", pc.Discovery.Namespace())
+	}
+	if pc.Config.Profile() != "" {
+		out.Println("Profile:", pc.Config.Profile())
+	}
+	out.Println("Bucket:", pc.Config.Bucket)
 	out.Println()
 
+	if selected, remotes := ambiguousRemotes(pc); len(remotes) > 0 {
+		var b strings.Builder
+		fmt.Fprintf(&b, "git remotes identify different repositories; using %s:", selected)
+		for _, r := range remotes {
+			fmt.Fprintf(&b, "\n  %-10s %s", r.Remote, r.Repo)
+		}
+		b.WriteString("\nAdd a remote: directive to .envsecrets (or remotes to your config) to choose, e.g. \"remote: upstream\"")
+		out.Warn("%s", b.String())
+	}
+
 	if syncErr != nil {
 		out.Warn("Could not compute sync status: %v", syncErr)
 	}
@@ -114,6 +214,21 @@ func runStatus(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	// Accidental-commit checks (history is left to doctor; it can be slow)
+	if issues := statusSafetyIssues(pc, statuses); len(issues) > 0 {
+		out.Println()
+		out.Println("Git safety:")
+		printSafetyIssues(out, "  ", issues)
+	}
+
+	if stale := staleExamples(pc, statuses); len(stale) > 0 {
+		out.Println()
+		out.Warn("Example files out of date (run 'envsecrets push' to regenerate):")
+		for _, e := range stale {
+			out.Printf("  %s%s\n", e.Example, describeStaleExample(e))
+		}
+	}
+
 	// Summary
 	counts := countFileStatuses(statuses)
 	out.Println()
@@ -162,9 +277,8 @@ func printSyncRecommendation(out *ui.Output, s *domain.SyncStatus) {
 	case domain.SyncActionReconcile:
 		out.Printf("  ! Reconcile needed: %d file(s) changed on both sides:\n", len(s.Conflicts))
 		printFileList(out, "      ", s.Conflicts)
-		out.Println("    1. Review with: envsecrets diff <file>")
-		out.Println("    2. Resolve with: envsecrets pull   (interactive: keep-local or overwrite)")
-		out.Println("    3. Publish with: envsecrets push")
+		out.Println("    Run: envsecrets sync   (merges key by key; prompts only for keys changed on both sides)")
+		out.Println("    Review first with: envsecrets diff <file>")
 	case domain.SyncActionFirstPushInit:
 		out.Println("  → Remote not initialized. Run: envsecrets push")
 	case domain.SyncActionFirstPull:
@@ -215,12 +329,14 @@ func humanizeDuration(d time.Duration) string {
 	}
 }
 
-func outputStatusJSON(pc *ProjectContext, ctx context.Context, syncStatus *domain.SyncStatus, syncErr error) error {
-	out := GetOutput()
+// statusJSONData computes the status of one project for JSON output
+func statusJSONData(ctx context.Context, pc *ProjectContext) (map[string]interface{}, error) {
+	syncer := sync.NewSyncer(pc.Discovery, pc.RepoInfo, pc.Storage, pc.Encrypter, pc.Cache)
+	syncStatus, syncErr := syncer.GetSyncStatus(ctx)
 
 	statuses, err := pc.GetFileStatuses()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	var formatVersion interface{}
@@ -240,7 +356,8 @@ func outputStatusJSON(pc *ProjectContext, ctx context.Context, syncStatus *domai
 
 	data := map[string]interface{}{
 		"repository":     pc.RepoInfo.String(),
-		"bucket":         cfg.Bucket,
+		"profile":        pc.Config.Profile(),
+		"bucket":         pc.Config.Bucket,
 		"remote_exists":  remoteHead != "",
 		"remote_head":    remoteHead,
 		"storage_format": formatVersion,
@@ -255,9 +372,91 @@ func outputStatusJSON(pc *ProjectContext, ctx context.Context, syncStatus *domai
 		"sync": syncStatus,
 	}
 
+	if issues := statusSafetyIssues(pc, statuses); len(issues) > 0 {
+		data["safety"] = issues
+	}
+
+	if stale := staleExamples(pc, statuses); len(stale) > 0 {
+		data["stale_examples"] = stale
+	}
+
+	if pc.Discovery != nil && pc.Discovery.IsNested() {
+		data["namespace"] = pc.Discovery.Namespace()
+	}
+
+	if selected, remotes := ambiguousRemotes(pc); len(remotes) > 0 {
+		data["remote_conflict"] = map[string]interface{}{
+			"selected": selected,
+			"remotes":  remotes,
+		}
+	}
+
 	if syncErr != nil {
 		data["sync_error"] = syncErr.Error()
 	}
 
-	return out.JSON(data)
+	return data, nil
+}
+
+// ambiguousRemotes returns the git remotes when they identify different
+// repositories and neither .envsecrets nor the config chose one. Failures
+// are reported only in verbose mode.
+func ambiguousRemotes(pc *ProjectContext) (string, []project.RemoteIdentity) {
+	if pc.Discovery == nil || GetRepo() != "" {
+		return "", nil
+	}
+	selected, remotes, err := pc.Discovery.AmbiguousRemotes()
+	if err != nil {
+		GetOutput().Verbose("Could not check git remotes: %v", err)
+		return "", nil
+	}
+	return selected, remotes
+}
+
+// staleExamples returns the example files whose keys no longer match the
+// tracked files in statuses. Failures are reported only in verbose mode.
+func staleExamples(pc *ProjectContext, statuses []domain.FileStatus) []project.StaleExample {
+	if pc.Discovery == nil || len(statuses) == 0 {
+		return nil
+	}
+	files := make([]string, 0, len(statuses))
+	for _, s := range statuses {
+		files = append(files, s.Path)
+	}
+	stale, err := pc.Discovery.StaleExamples(files)
+	if err != nil {
+		GetOutput().Verbose("Could not check example files: %v", err)
+		return nil
+	}
+	return stale
+}
+
+// describeStaleExample summarizes how an example's keys differ
+func describeStaleExample(e project.StaleExample) string {
+	var parts []string
+	if len(e.Missing) > 0 {
+		parts = append(parts, "missing "+strings.Join(e.Missing, ", "))
+	}
+	if len(e.Extra) > 0 {
+		parts = append(parts, "no longer in "+e.File+": "+strings.Join(e.Extra, ", "))
+	}
+	return " (" + strings.Join(parts, "; ") + ")"
+}
+
+// statusSafetyIssues runs the fast accidental-commit checks for the files in
+// statuses. Failures are reported only in verbose mode so they never hide status.
+func statusSafetyIssues(pc *ProjectContext, statuses []domain.FileStatus) []domain.SafetyIssue {
+	if pc.Discovery == nil || len(statuses) == 0 {
+		return nil
+	}
+	files := make([]string, 0, len(statuses))
+	for _, s := range statuses {
+		files = append(files, s.Path)
+	}
+	issues, err := pc.Discovery.CheckSafety(files, project.SafetyOptions{})
+	if err != nil {
+		GetOutput().Verbose("Could not check git safety: %v", err)
+		return nil
+	}
+	return issues
 }
@@ -15,6 +15,7 @@ import (
 var (
 	syncMessage string
 	syncDryRun  bool
+	syncAll     bool
 )
 
 var syncCmd = &cobra.Command{
@@ -30,19 +31,25 @@ sync inspects the same signals 'status' uses, then performs the safe action:
   first_pull       -> envsecrets pull (initialize this machine's baseline,
                       e.g. fresh clone, post-reset, or upgraded client)
   pull_then_push   -> pull, then push (no overlapping changes)
-  reconcile        -> print guidance and exit non-zero (sync does NOT
-                      auto-resolve overlapping conflicts; resolve with
-                      'envsecrets pull' interactive prompts, then push)
+  reconcile        -> merge files changed on both sides key by key, then
+                      push; keys changed differently on both sides are
+                      prompted for, or without a terminal sync prints
+                      guidance and exits non-zero
   first_push_init  -> print "remote not initialized; run push" and exit non-zero
                       (initialization is intentional, not a side effect)
 
-Use 'envsecrets push --force' directly when you want to override divergence.`,
+Use 'envsecrets push --force' directly when you want to override divergence.
+
+With --all, every .envsecrets namespace in the git repository is synced in
+turn, each with the profile its .envsecrets selects. A namespace that needs
+manual action does not stop the others; the first failure sets the exit code.`,
 	RunE: runSync,
 }
 
 func init() {
 	syncCmd.Flags().BoolVar(&syncDryRun, "dry-run", false, "show what would be done without doing it")
 	syncCmd.Flags().StringVarP(&syncMessage, "message", "m", "", "commit message used when sync runs push")
+	syncCmd.Flags().BoolVar(&syncAll, "all", false, "sync every .envsecrets namespace in the git repository")
 }
 
 func runSync(cmd *cobra.Command, args []string) error {
@@ -50,12 +57,56 @@ func runSync(cmd *cobra.Command, args []string) error {
 	defer cancel()
 	out := GetOutput()
 
+	if syncAll {
+		return runSyncAll(ctx, out)
+	}
+
 	pc, err := NewProjectContext(ctx, cfg)
 	if err != nil {
 		return err
 	}
 	defer pc.Close()
 
+	return syncProject(ctx, pc, out)
+}
+
+// runSyncAll syncs every namespace, continuing past failures
+// and reports them together at the end
+func runSyncAll(ctx context.Context, out *ui.Output) error {
+	namespaces, err := listNamespaces()
+	if err != nil {
+		return err
+	}
+
+	var firstErr error
+	failed := 0
+	for i, ns := range namespaces {
+		if i > 0 {
+			out.Println()
+		}
+		out.Printf("== %s ==\n", namespaceLabel(ns))
+
+		err := func() error {
+			pc, err := openNamespace(ctx, ns)
+			if err != nil {
+				return err
+			}
+			defer pc.Close()
+			return syncProject(ctx, pc, out)
+		}()
+		if err != nil {
+			failed++
+			if firstErr == nil {
+				firstErr = err
+			}
+			out.Error("%v", err)
+		}
+	}
+	return namespacesFailed(failed, len(namespaces), firstErr)
+}
+
+// syncProject runs the recommended action for one project
+func syncProject(ctx context.Context, pc *ProjectContext, out *ui.Output) error {
 	syncer := sync.NewSyncer(pc.Discovery, pc.RepoInfo, pc.Storage, pc.Encrypter, pc.Cache)
 
 	status, err := syncer.GetSyncStatus(ctx)
@@ -83,60 +134,74 @@ func runSync(cmd *cobra.Command, args []string) error {
 		return domain.NewExitCodeError(domain.ErrActionRequired, exitCodeForActionRequired())
 
 	case domain.SyncActionFirstPull, domain.SyncActionPull:
-		return runSyncPull(ctx, syncer, status, out)
+		return runSyncPull(ctx, pc, syncer, status, out)
 
 	case domain.SyncActionPush:
 		return runSyncPush(ctx, syncer, status, out)
 
 	case domain.SyncActionPullThenPush:
-		if err := runSyncPull(ctx, syncer, status, out); err != nil {
-			return err
-		}
-		// Re-fetch status after pull so the second leg works against the
-		// updated baseline. Pull may have written LAST_SYNCED, and a
-		// user-resolved conflict (skip) can leave us in an unexpected
-		// state — dispatch on the new action rather than blindly pushing.
-		updated, err := syncer.GetSyncStatus(ctx)
-		if err != nil {
+		if err := runSyncPull(ctx, pc, syncer, status, out); err != nil {
 			return err
 		}
-		switch updated.Action {
-		case domain.SyncActionInSync:
-			return nil
-		case domain.SyncActionPush:
-			return runSyncPush(ctx, syncer, updated, out)
-		case domain.SyncActionReconcile:
-			out.Warn("reconcile required after pull: %d file(s) changed on both sides", len(updated.Conflicts))
-			for _, f := range updated.Conflicts {
+		return pushAfterPull(ctx, syncer, out)
+
+	case domain.SyncActionReconcile:
+		// Overlapping edits usually touch different keys; try a key-level
+		// merge and fall back to guidance when keys genuinely conflict
+		if err := runSyncPull(ctx, pc, syncer, status, out); err != nil {
+			if !errors.Is(err, domain.ErrConflict) {
+				return err
+			}
+			out.Warn("reconcile required: %d file(s) have keys changed on both sides", len(status.Conflicts))
+			for _, f := range status.Conflicts {
 				out.Printf("  - %s\n", f)
 			}
-			out.Println("  Resolve with: envsecrets diff <file>, then envsecrets pull again, then envsecrets push")
+			out.Println("  1. Review with: envsecrets diff <file>")
+			out.Println("  2. Resolve with: envsecrets pull   (interactive: local or remote per conflicting key)")
+			out.Println("  3. Publish with: envsecrets push")
 			return domain.NewExitCodeError(domain.ErrActionRequired, exitCodeForActionRequired())
-		default:
-			// Pull, PullThenPush, FirstPull, FirstPushInit, NothingTracked
-			// shouldn't happen right after a successful pull. Refuse with
-			// a clear error rather than taking an implicit action — the
-			// user can re-run 'envsecrets sync' to dispatch from scratch
-			// once they've verified the unexpected state.
-			return fmt.Errorf("unexpected post-pull action: %q (re-run 'envsecrets sync' or 'envsecrets status' to inspect)", updated.Action)
 		}
+		return pushAfterPull(ctx, syncer, out)
 
+	default:
+		return fmt.Errorf("unknown sync action: %q", status.Action)
+	}
+}
+
+// pushAfterPull publishes local changes once a pull has brought in the
+// remote ones
+func pushAfterPull(ctx context.Context, syncer *sync.Syncer, out *ui.Output) error {
+	// Re-fetch status after pull so the second leg works against the
+	// updated baseline. Pull may have written LAST_SYNCED, and a
+	// user-resolved conflict (skip) can leave us in an unexpected
+	// state — dispatch on the new action rather than blindly pushing.
+	updated, err := syncer.GetSyncStatus(ctx)
+	if err != nil {
+		return err
+	}
+	switch updated.Action {
+	case domain.SyncActionInSync:
+		return nil
+	case domain.SyncActionPush:
+		return runSyncPush(ctx, syncer, updated, out)
 	case domain.SyncActionReconcile:
-		out.Warn("reconcile required: %d file(s) changed on both sides", len(status.Conflicts))
-		for _, f := range status.Conflicts {
+		out.Warn("reconcile required after pull: %d file(s) changed on both sides", len(updated.Conflicts))
+		for _, f := range updated.Conflicts {
 			out.Printf("  - %s\n", f)
 		}
-		out.Println("  1. Review with: envsecrets diff <file>")
-		out.Println("  2. Resolve with: envsecrets pull   (interactive: keep-local or overwrite per file)")
-		out.Println("  3. Publish with: envsecrets push")
+		out.Println("  Resolve with: envsecrets diff <file>, then envsecrets pull again, then envsecrets push")
 		return domain.NewExitCodeError(domain.ErrActionRequired, exitCodeForActionRequired())
-
 	default:
-		return fmt.Errorf("unknown sync action: %q", status.Action)
+		// Pull, PullThenPush, FirstPull, FirstPushInit, NothingTracked
+		// shouldn't happen right after a successful pull. Refuse with
+		// a clear error rather than taking an implicit action — the
+		// user can re-run 'envsecrets sync' to dispatch from scratch
+		// once they've verified the unexpected state.
+		return fmt.Errorf("unexpected post-pull action: %q (re-run 'envsecrets sync' or 'envsecrets status' to inspect)", updated.Action)
 	}
 }
 
-func runSyncPull(ctx context.Context, syncer *sync.Syncer, status *domain.SyncStatus, out *ui.Output) error {
+func runSyncPull(ctx context.Context, pc *ProjectContext, syncer *sync.Syncer, status *domain.SyncStatus, out *ui.Output) error {
 	if status.Action == domain.SyncActionFirstPull {
 		// FirstPull returns from GetSyncStatus before per-file
 		// classification runs, so RemoteChanges is empty even though
@@ -150,21 +215,7 @@ func runSyncPull(ctx context.Context, syncer *sync.Syncer, status *domain.SyncSt
 	// Wire interactive conflict resolution exactly like 'pull' does, so the
 	// user keeps the same UX they're used to if conflicts surface mid-pull.
 	if ui.CanPrompt() {
-		prompt := ui.NewPrompt()
-		opts.ConflictResolver = func(f string) (sync.ConflictAction, error) {
-			choice, err := prompt.ConflictChoice(f)
-			if err != nil {
-				return sync.ConflictAbort, err
-			}
-			switch choice {
-			case "o":
-				return sync.ConflictOverwrite, nil
-			case "s":
-				return sync.ConflictSkip, nil
-			default:
-				return sync.ConflictAbort, nil
-			}
-		}
+		opts.ConflictResolver = conflictResolver(pc, out, ui.NewPrompt())
 	}
 
 	result, err := syncer.Pull(ctx, opts)
@@ -180,6 +231,9 @@ func runSyncPull(ctx context.Context, syncer *sync.Syncer, status *domain.SyncSt
 	if result.FilesDeleted > 0 {
 		out.Printf("  %d file(s) deleted (remote dropped them)\n", result.FilesDeleted)
 	}
+	if result.FilesMerged > 0 {
+		out.Printf("  %d file(s) merged (push to publish the result)\n", result.FilesMerged)
+	}
 	if result.FilesKeptLocal > 0 {
 		out.Printf("  %d file(s) kept (local edits preserved; push to publish)\n", result.FilesKeptLocal)
 	}
@@ -253,7 +307,7 @@ func describeSyncAction(out *ui.Output, s *domain.SyncStatus) {
 		out.Printf("Would pull %d remote change(s), then push %d local change(s).\n",
 			len(s.RemoteChanges), len(s.LocalChanges))
 	case domain.SyncActionReconcile:
-		out.Printf("Would refuse — %d file(s) changed on both sides; reconcile manually.\n",
+		out.Printf("Would merge %d file(s) changed on both sides key by key, then push; conflicting keys need a decision.\n",
 			len(s.Conflicts))
 	default:
 		out.Printf("Unknown action: %q\n", s.Action)
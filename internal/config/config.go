@@ -23,6 +23,13 @@ type Config struct {
 	// Example: ["pass", "show", "envsecrets"]
 	PassphraseCommandArgs []string `yaml:"passphrase_command_args,omitempty"`
 
+	// PassphraseKeyring reads the passphrase from the OS keyring (macOS
+	// Keychain / Linux Secret Service) or the encrypted file fallback
+	PassphraseKeyring bool `yaml:"passphrase_keyring,omitempty"`
+
+	// KeyringBackend selects the keyring: auto (default), system, or file
+	KeyringBackend string `yaml:"keyring_backend,omitempty"`
+
 	// GCSCredentials is base64-encoded service account JSON
 	GCSCredentials string `yaml:"gcs_credentials,omitempty"`
 
@@ -31,11 +38,43 @@ type Config struct {
 	// Defaults to $USER@$hostname when empty.
 	MachineID string `yaml:"machine_id,omitempty"`
 
+	// DefaultProfile names the profile used when neither --profile nor
+	// ENVSECRETS_PROFILE selects one
+	DefaultProfile string `yaml:"default_profile,omitempty"`
+
+	// Profiles holds named backend configurations. Fields set in a profile
+	// override the top-level fields above, which act as shared defaults.
+	Profiles map[string]Profile `yaml:"profiles,omitempty"`
+
+	// AllowedBuckets lists extra buckets a project's .envsecrets may select
+	// with a bucket: directive. Buckets already named in this config are
+	// always allowed; anything else is refused so a cloned repository
+	// cannot redirect uploads to a bucket the user never configured.
+	AllowedBuckets []string `yaml:"allowed_buckets,omitempty"`
+
+	// Remotes lists git remotes whose URL identifies a project, in order of
+	// preference (e.g. ["upstream", "origin"] so forks share the upstream
+	// project's secrets). A project's remote: directive is tried first;
+	// origin, then the first remote by name, are used when none exist.
+	Remotes []string `yaml:"remotes,omitempty"`
+
 	// configPath is the path this config was loaded from (not serialized)
 	configPath string `yaml:"-"`
+
+	// profile is the name of the selected profile, empty for top-level fields
+	profile string `yaml:"-"`
+
+	// persisted is the config as loaded from disk, before profile selection
+	persisted *Config `yaml:"-"`
+
+	// sources records fields overridden by environment variables, keyed by
+	// config key (set by ApplyEnv)
+	sources map[string]string `yaml:"-"`
 }
 
-// Load reads configuration from the specified path
+// Load reads configuration from the specified path. A missing file is not an
+// error when ENVSECRETS_BUCKET is set; the config is then built from the
+// environment by ApplyEnv.
 func Load(path string) (*Config, error) {
 	if path == "" {
 		path = getConfigPath()
@@ -44,6 +83,9 @@ func Load(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
+			if EnvConfigured() {
+				return &Config{configPath: path}, nil
+			}
 			return nil, domain.Errorf(domain.ErrNotConfigured, "config file not found at %s", path)
 		}
 		return nil, domain.Errorf(domain.ErrInvalidConfig, "failed to read config: %v", err)
@@ -69,18 +111,26 @@ func (c *Config) Save(path string) error {
 		path = getConfigPath()
 	}
 
-	// Ensure directory exists
-	dir := filepath.Dir(path)
-	if err := os.MkdirAll(dir, 0700); err != nil {
-		return domain.Errorf(domain.ErrInvalidConfig, "failed to create config directory: %v", err)
-	}
-
 	data, err := yaml.Marshal(c)
 	if err != nil {
 		return domain.Errorf(domain.ErrInvalidConfig, "failed to marshal config: %v", err)
 	}
 
-	// Atomic write: write to temp file, then rename
+	if err := writeConfigFile(path, data); err != nil {
+		return err
+	}
+
+	c.configPath = path
+	return nil
+}
+
+// writeConfigFile atomically replaces the config file: write to a temp file,
+// then rename
+func writeConfigFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return domain.Errorf(domain.ErrInvalidConfig, "failed to create config directory: %v", err)
+	}
+
 	tempPath := path + ".tmp"
 	if err := os.WriteFile(tempPath, data, 0600); err != nil {
 		return domain.Errorf(domain.ErrInvalidConfig, "failed to write config: %v", err)
@@ -90,17 +140,24 @@ func (c *Config) Save(path string) error {
 		os.Remove(tempPath) // Clean up on failure
 		return domain.Errorf(domain.ErrInvalidConfig, "failed to save config: %v", err)
 	}
-
-	c.configPath = path
 	return nil
 }
 
-// Validate checks that the configuration is valid
+// Validate checks that the configuration is valid. The bucket may be left
+// out of the file when ENVSECRETS_BUCKET provides it.
 func (c *Config) Validate() error {
-	if c.Bucket == "" {
+	if len(c.Profiles) == 0 && c.Bucket == "" && !EnvConfigured() {
 		return domain.Errorf(domain.ErrInvalidConfig, "bucket is required")
 	}
 
+	if err := validateKeyringBackend(c.KeyringBackend); err != nil {
+		return err
+	}
+
+	if err := c.validateProfiles(); err != nil {
+		return err
+	}
+
 	// At least one passphrase method should be configured, but we allow
 	// interactive input as fallback, so this is not strictly required
 	return nil
@@ -113,7 +170,7 @@ func (c *Config) Path() string {
 
 // HasPassphraseConfig returns true if a passphrase retrieval method is configured
 func (c *Config) HasPassphraseConfig() bool {
-	return c.PassphraseEnv != "" || len(c.PassphraseCommandArgs) > 0
+	return c.PassphraseEnv != "" || len(c.PassphraseCommandArgs) > 0 || c.PassphraseKeyring
 }
 
 // getConfigPath returns the config path from env var or default
@@ -141,6 +198,16 @@ func ConfigPath(override string) string {
 	return getConfigPath()
 }
 
+// validateKeyringBackend checks a keyring_backend value
+func validateKeyringBackend(backend string) error {
+	switch backend {
+	case "", KeyringBackendAuto, KeyringBackendSystem, KeyringBackendFile:
+		return nil
+	default:
+		return domain.Errorf(domain.ErrInvalidConfig, "keyring_backend must be auto, system, or file (got %q)", backend)
+	}
+}
+
 // String returns a string representation (for debugging, hides sensitive data)
 func (c *Config) String() string {
 	creds := ""
@@ -155,6 +222,6 @@ func (c *Config) String() string {
 	if len(c.PassphraseCommandArgs) > 0 {
 		passCmdArgs = "[set]"
 	}
-	return fmt.Sprintf("Config{Bucket: %q, PassphraseEnv: %s, PassphraseCommandArgs: %s, GCSCredentials: %s}",
-		c.Bucket, passEnv, passCmdArgs, creds)
+	return fmt.Sprintf("Config{Profile: %q, Bucket: %q, PassphraseEnv: %s, PassphraseCommandArgs: %s, PassphraseKeyring: %t, GCSCredentials: %s}",
+		c.profile, c.Bucket, passEnv, passCmdArgs, c.PassphraseKeyring, creds)
 }
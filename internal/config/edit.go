@@ -0,0 +1,439 @@
+package config
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charliek/envsecrets/internal/domain"
+	"gopkg.in/yaml.v3"
+)
+
+// keyKind is the YAML shape of a settable config key
+type keyKind int
+
+const (
+	kindString keyKind = iota
+	kindBool
+	kindList
+)
+
+// topLevelKeys are the keys settable at the top level of config.yaml
+var topLevelKeys = map[string]keyKind{
+	"bucket":                  kindString,
+	"passphrase_env":          kindString,
+	"passphrase_command_args": kindList,
+	"passphrase_keyring":      kindBool,
+	"keyring_backend":         kindString,
+	"gcs_credentials":         kindString,
+	"machine_id":              kindString,
+	"default_profile":         kindString,
+	"allowed_buckets":         kindList,
+	"remotes":                 kindList,
+}
+
+// profileKeys are the keys settable under profiles.<name>
+var profileKeys = map[string]keyKind{
+	"bucket":                  kindString,
+	"passphrase_env":          kindString,
+	"passphrase_command_args": kindList,
+	"passphrase_keyring":      kindBool,
+	"keyring_backend":         kindString,
+	"gcs_credentials":         kindString,
+	"machine_id":              kindString,
+}
+
+// secretKeys are redacted on display, matching Config.String
+var secretKeys = map[string]bool{
+	"gcs_credentials":         true,
+	"passphrase_env":          true,
+	"passphrase_command_args": true,
+}
+
+// Entry is one leaf value in the config file, as shown by `config list`
+type Entry struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// Editor edits config.yaml in place through its YAML node tree, so comments,
+// key order, and keys this version does not know about are preserved.
+type Editor struct {
+	path string
+	doc  *yaml.Node
+}
+
+// OpenEditor reads the config file for editing. A missing file starts an
+// empty document that Save will create.
+func OpenEditor(path string) (*Editor, error) {
+	if path == "" {
+		path = getConfigPath()
+	}
+
+	doc := &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, domain.Errorf(domain.ErrInvalidConfig, "failed to read config: %v", err)
+	}
+	if len(bytes.TrimSpace(data)) > 0 {
+		var parsed yaml.Node
+		if err := yaml.Unmarshal(data, &parsed); err != nil {
+			return nil, domain.Errorf(domain.ErrInvalidConfig, "failed to parse config: %v", err)
+		}
+		if len(parsed.Content) != 1 || parsed.Content[0].Kind != yaml.MappingNode {
+			return nil, domain.Errorf(domain.ErrInvalidConfig, "failed to parse config: top level must be a mapping")
+		}
+		doc = &parsed
+	}
+
+	return &Editor{path: path, doc: doc}, nil
+}
+
+// Path returns the file this editor reads and writes
+func (e *Editor) Path() string {
+	return e.path
+}
+
+// Get returns the value stored at key, with lists rendered as a JSON array.
+// found is false when the key is not set in the file.
+func (e *Editor) Get(key string) (value string, found bool, err error) {
+	path, _, err := parseConfigKey(key)
+	if err != nil {
+		return "", false, err
+	}
+	node := lookupNode(e.doc.Content[0], path)
+	if node == nil {
+		return "", false, nil
+	}
+	return renderNode(node), true, nil
+}
+
+// Set stores values at key. Scalar keys take exactly one value; list keys
+// take one or more, or a single JSON array.
+func (e *Editor) Set(key string, values []string) error {
+	path, kind, err := parseConfigKey(key)
+	if err != nil {
+		return err
+	}
+
+	var node *yaml.Node
+	switch kind {
+	case kindList:
+		items, err := parseListValue(key, values)
+		if err != nil {
+			return err
+		}
+		if err := validateListValue(path[len(path)-1], items); err != nil {
+			return err
+		}
+		node = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Style: yaml.FlowStyle}
+		for _, item := range items {
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: item})
+		}
+	case kindBool:
+		if len(values) != 1 {
+			return domain.Errorf(domain.ErrInvalidArgs, "%s takes a single value", key)
+		}
+		enabled, err := strconv.ParseBool(values[0])
+		if err != nil {
+			return domain.Errorf(domain.ErrInvalidArgs, "%s must be true or false (got %q)", key, values[0])
+		}
+		node = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(enabled)}
+	default:
+		if len(values) != 1 {
+			return domain.Errorf(domain.ErrInvalidArgs, "%s takes a single value", key)
+		}
+		if err := validateScalarValue(path[len(path)-1], values[0]); err != nil {
+			return err
+		}
+		node = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: values[0]}
+	}
+
+	setNode(e.doc.Content[0], path, node)
+	return nil
+}
+
+// Unset removes key from the file. It reports whether the key was present.
+func (e *Editor) Unset(key string) (bool, error) {
+	path, _, err := parseConfigKey(key)
+	if err != nil {
+		return false, err
+	}
+
+	parent := e.doc.Content[0]
+	if len(path) > 1 {
+		parent = lookupNode(parent, path[:len(path)-1])
+		if parent == nil || parent.Kind != yaml.MappingNode {
+			return false, nil
+		}
+	}
+	name := path[len(path)-1]
+	for i := 0; i+1 < len(parent.Content); i += 2 {
+		if parent.Content[i].Value == name {
+			parent.Content = append(parent.Content[:i], parent.Content[i+2:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Entries returns every leaf value in the file as dotted keys, including keys
+// this version does not recognize. Secrets are redacted unless reveal is set.
+func (e *Editor) Entries(reveal bool) []Entry {
+	var entries []Entry
+	var walk func(prefix string, node *yaml.Node)
+	walk = func(prefix string, node *yaml.Node) {
+		if node.Kind != yaml.MappingNode {
+			value := renderNode(node)
+			if !reveal {
+				value = RedactValue(prefix, value)
+			}
+			entries = append(entries, Entry{Key: prefix, Value: value})
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			walk(key, node.Content[i+1])
+		}
+	}
+	walk("", e.doc.Content[0])
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return entries
+}
+
+// Save validates the edited document as a Config and writes it atomically
+func (e *Editor) Save() error {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(e.doc); err != nil {
+		return domain.Errorf(domain.ErrInvalidConfig, "failed to marshal config: %v", err)
+	}
+	if err := enc.Close(); err != nil {
+		return domain.Errorf(domain.ErrInvalidConfig, "failed to marshal config: %v", err)
+	}
+	data := buf.Bytes()
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return domain.Errorf(domain.ErrInvalidConfig, "failed to parse config: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	return writeConfigFile(e.path, data)
+}
+
+// RedactValue masks the value of secret keys, as Config.String does
+func RedactValue(key, value string) string {
+	name := key
+	if i := strings.LastIndex(key, "."); i >= 0 {
+		name = key[i+1:]
+	}
+	if secretKeys[name] && value != "" {
+		return "[set]"
+	}
+	return value
+}
+
+// parseConfigKey splits a dotted key into its YAML path and checks it names a
+// settable field: a top-level key or profiles.<name>.<key>
+func parseConfigKey(key string) ([]string, keyKind, error) {
+	parts := strings.Split(key, ".")
+	if len(parts) == 1 {
+		if kind, ok := topLevelKeys[key]; ok {
+			return parts, kind, nil
+		}
+	}
+	if len(parts) >= 3 && parts[0] == "profiles" {
+		// Profile names may themselves contain dots
+		name := strings.Join(parts[1:len(parts)-1], ".")
+		field := parts[len(parts)-1]
+		if !validProfileName.MatchString(name) {
+			return nil, 0, domain.Errorf(domain.ErrInvalidArgs, "invalid profile name %q: only alphanumeric, hyphens, underscores, and dots allowed", name)
+		}
+		if kind, ok := profileKeys[field]; ok {
+			return []string{"profiles", name, field}, kind, nil
+		}
+	}
+	return nil, 0, domain.Errorf(domain.ErrInvalidArgs, "unknown config key %q (valid: %s, or profiles.<name>.<key>)", key, strings.Join(sortedKeys(topLevelKeys), ", "))
+}
+
+// parseListValue accepts either several arguments or one JSON array
+func parseListValue(key string, values []string) ([]string, error) {
+	if len(values) == 1 && strings.HasPrefix(strings.TrimSpace(values[0]), "[") {
+		var items []string
+		if err := json.Unmarshal([]byte(values[0]), &items); err != nil {
+			return nil, domain.Errorf(domain.ErrInvalidArgs, "%s: invalid JSON array: %v", key, err)
+		}
+		values = items
+	}
+	if len(values) == 0 {
+		return nil, domain.Errorf(domain.ErrInvalidArgs, "%s requires at least one value", key)
+	}
+	return values, nil
+}
+
+// validateListValue checks list values before they are written
+func validateListValue(name string, items []string) error {
+	switch name {
+	case "passphrase_command_args":
+		if _, err := exec.LookPath(items[0]); err != nil {
+			return domain.Errorf(domain.ErrInvalidArgs, "passphrase command %q not found in PATH", items[0])
+		}
+	case "allowed_buckets":
+		for _, bucket := range items {
+			if strings.TrimSpace(bucket) == "" {
+				return domain.Errorf(domain.ErrInvalidArgs, "allowed_buckets entries cannot be empty")
+			}
+		}
+	case "remotes":
+		for _, remote := range items {
+			if strings.TrimSpace(remote) == "" {
+				return domain.Errorf(domain.ErrInvalidArgs, "remotes entries cannot be empty")
+			}
+		}
+	}
+	return nil
+}
+
+// validateScalarValue checks scalar values before they are written. Checks
+// that span keys (default_profile, bucket presence) run on Save.
+func validateScalarValue(name, value string) error {
+	switch name {
+	case "bucket":
+		if value == "" {
+			return domain.Errorf(domain.ErrInvalidArgs, "bucket cannot be empty")
+		}
+	case "keyring_backend":
+		if err := validateKeyringBackend(value); err != nil {
+			return err
+		}
+	case "gcs_credentials":
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return domain.Errorf(domain.ErrInvalidArgs, "gcs_credentials must be base64-encoded: %v", err)
+		}
+		if !json.Valid(decoded) {
+			return domain.Errorf(domain.ErrInvalidArgs, "gcs_credentials must decode to service account JSON")
+		}
+	}
+	return nil
+}
+
+// lookupNode follows path through nested mappings
+func lookupNode(node *yaml.Node, path []string) *yaml.Node {
+	for _, name := range path {
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		var next *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == name {
+				next = node.Content[i+1]
+				break
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		node = next
+	}
+	return node
+}
+
+// setNode replaces (or appends) the value at path, creating intermediate
+// mappings as needed. Comments attached to a replaced value are kept.
+func setNode(node *yaml.Node, path []string, value *yaml.Node) {
+	for depth, name := range path {
+		idx := -1
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == name {
+				idx = i + 1
+				break
+			}
+		}
+
+		if depth == len(path)-1 {
+			if idx < 0 {
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}, value)
+				return
+			}
+			old := node.Content[idx]
+			value.HeadComment = old.HeadComment
+			value.LineComment = old.LineComment
+			value.FootComment = old.FootComment
+			node.Content[idx] = value
+			return
+		}
+
+		if idx < 0 {
+			child := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}, child)
+			node = child
+			continue
+		}
+		if node.Content[idx].Kind != yaml.MappingNode {
+			node.Content[idx] = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		}
+		node = node.Content[idx]
+	}
+}
+
+// renderNode formats a leaf node for display
+func renderNode(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return node.Value
+	case yaml.SequenceNode:
+		items := make([]string, 0, len(node.Content))
+		for _, item := range node.Content {
+			items = append(items, item.Value)
+		}
+		data, _ := json.Marshal(items)
+		return string(data)
+	default:
+		data, _ := yaml.Marshal(node)
+		return strings.TrimSpace(string(data))
+	}
+}
+
+func sortedKeys(m map[string]keyKind) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// CheckCommands verifies that every configured passphrase command, top level
+// and per profile, resolves to an executable. Load does not check this since
+// the command may only exist on some machines.
+func (c *Config) CheckCommands() error {
+	if len(c.PassphraseCommandArgs) > 0 {
+		if err := validateListValue("passphrase_command_args", c.PassphraseCommandArgs); err != nil {
+			return err
+		}
+	}
+	for _, name := range c.ProfileNames() {
+		args := c.Profiles[name].PassphraseCommandArgs
+		if len(args) == 0 {
+			continue
+		}
+		if err := validateListValue("passphrase_command_args", args); err != nil {
+			return domain.Errorf(domain.ErrInvalidConfig, "profile %q: %v", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const commentedConfig = `# Team bucket, see the wiki
+bucket: team-secrets # do not change
+passphrase_env: TEAM_PASS
+
+# Set by a newer version
+future_option: keep-me
+profiles:
+  work: {bucket: work-secrets}
+`
+
+func writeEditorConfig(t *testing.T, content string) string {
+	t.Helper()
+	clearConfigEnv(t)
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestEditor_SetPreservesCommentsAndUnknownKeys(t *testing.T) {
+	path := writeEditorConfig(t, commentedConfig)
+
+	editor, err := OpenEditor(path)
+	require.NoError(t, err)
+	require.NoError(t, editor.Set("bucket", []string{"new-secrets"}))
+	require.NoError(t, editor.Set("passphrase_keyring", []string{"1"}), "bools accept strconv forms")
+	require.Error(t, editor.Set("passphrase_keyring", []string{"maybe"}))
+	require.NoError(t, editor.Set("profiles.home.bucket", []string{"home-secrets"}))
+	require.NoError(t, editor.Set("allowed_buckets", []string{`["a-bucket", "b-bucket"]`}))
+	require.NoError(t, editor.Set("remotes", []string{"upstream", "origin"}))
+	require.NoError(t, editor.Save())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	content := string(data)
+	require.Contains(t, content, "# Team bucket, see the wiki")
+	require.Contains(t, content, "bucket: new-secrets # do not change")
+	require.Contains(t, content, "future_option: keep-me")
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	require.Equal(t, "new-secrets", loaded.Bucket)
+	require.True(t, loaded.PassphraseKeyring)
+	require.Equal(t, "home-secrets", loaded.Profiles["home"].Bucket)
+	require.Equal(t, "work-secrets", loaded.Profiles["work"].Bucket)
+	require.Equal(t, []string{"a-bucket", "b-bucket"}, loaded.AllowedBuckets)
+	require.Equal(t, []string{"upstream", "origin"}, loaded.Remotes)
+}
+
+func TestEditor_GetAndEntries(t *testing.T) {
+	path := writeEditorConfig(t, commentedConfig+"gcs_credentials: c2VjcmV0\n")
+
+	editor, err := OpenEditor(path)
+	require.NoError(t, err)
+
+	value, found, err := editor.Get("profiles.work.bucket")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, "work-secrets", value)
+
+	_, found, err = editor.Get("machine_id")
+	require.NoError(t, err)
+	require.False(t, found)
+
+	_, _, err = editor.Get("no_such_key")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unknown config key")
+
+	entries := editor.Entries(false)
+	byKey := map[string]string{}
+	for _, e := range entries {
+		byKey[e.Key] = e.Value
+	}
+	require.Equal(t, "[set]", byKey["gcs_credentials"])
+	require.Equal(t, "[set]", byKey["passphrase_env"])
+	require.Equal(t, "keep-me", byKey["future_option"])
+	require.Equal(t, "work-secrets", byKey["profiles.work.bucket"])
+
+	for _, e := range editor.Entries(true) {
+		if e.Key == "gcs_credentials" {
+			require.Equal(t, "c2VjcmV0", e.Value)
+		}
+	}
+}
+
+func TestEditor_Unset(t *testing.T) {
+	path := writeEditorConfig(t, commentedConfig)
+
+	editor, err := OpenEditor(path)
+	require.NoError(t, err)
+
+	removed, err := editor.Unset("passphrase_env")
+	require.NoError(t, err)
+	require.True(t, removed)
+
+	removed, err = editor.Unset("profiles.nope.bucket")
+	require.NoError(t, err)
+	require.False(t, removed)
+	require.NoError(t, editor.Save())
+
+	// Removing the only bucket leaves an invalid config, which Save refuses
+	editor, err = OpenEditor(path)
+	require.NoError(t, err)
+	_, err = editor.Unset("bucket")
+	require.NoError(t, err)
+	_, err = editor.Unset("profiles.work.bucket")
+	require.NoError(t, err)
+	err = editor.Save()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "bucket is required")
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	require.Equal(t, "team-secrets", loaded.Bucket, "failed save must not touch the file")
+	require.Empty(t, loaded.PassphraseEnv)
+}
+
+func TestEditor_ValidatesValues(t *testing.T) {
+	path := writeEditorConfig(t, "bucket: b\n")
+	editor, err := OpenEditor(path)
+	require.NoError(t, err)
+
+	tests := []struct {
+		key         string
+		values      []string
+		errContains string
+	}{
+		{"passphrase_command_args", []string{"definitely-not-a-real-command-xyz"}, "not found in PATH"},
+		{"keyring_backend", []string{"vault"}, "keyring_backend"},
+		{"gcs_credentials", []string{"not base64!"}, "base64"},
+		{"bucket", []string{"a", "b"}, "single value"},
+		{"profiles.bad name.bucket", []string{"x"}, "invalid profile name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			err := editor.Set(tt.key, tt.values)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tt.errContains)
+		})
+	}
+
+	require.NoError(t, editor.Set("passphrase_command_args", []string{"echo", "hi"}))
+}
+
+func TestEditor_MissingFile(t *testing.T) {
+	clearConfigEnv(t)
+	path := filepath.Join(t.TempDir(), "nested", "config.yaml")
+
+	editor, err := OpenEditor(path)
+	require.NoError(t, err)
+	require.Empty(t, editor.Entries(false))
+	require.NoError(t, editor.Set("bucket", []string{"fresh"}))
+	require.NoError(t, editor.Save())
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	require.Equal(t, "fresh", loaded.Bucket)
+}
+
+func TestEditor_KeepsTwoSpaceIndent(t *testing.T) {
+	path := writeEditorConfig(t, "bucket: b\nprofiles:\n  work:\n    bucket: w\n")
+
+	editor, err := OpenEditor(path)
+	require.NoError(t, err)
+	require.NoError(t, editor.Set("machine_id", []string{"box"}))
+	require.NoError(t, editor.Save())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "bucket: b\nprofiles:\n  work:\n    bucket: w\nmachine_id: box\n", string(data))
+}
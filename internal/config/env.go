@@ -0,0 +1,178 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/charliek/envsecrets/internal/constants"
+	"github.com/charliek/envsecrets/internal/domain"
+)
+
+// Sources describing where an effective config value came from
+const (
+	SourceFile    = "config file"
+	SourceDefault = "default"
+)
+
+// ValueSource describes one effective config value and where it came from
+type ValueSource struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// sourceKeys lists the environment-overridable fields in display order
+var sourceKeys = []string{
+	"bucket",
+	"gcs_credentials",
+	"passphrase_env",
+	"passphrase_command_args",
+	"passphrase_keyring",
+	"keyring_backend",
+	"machine_id",
+}
+
+// EnvConfigured reports whether the environment alone names a bucket, which
+// is enough to run without a config file
+func EnvConfigured() bool {
+	return os.Getenv(constants.BucketEnvVar) != ""
+}
+
+// ApplyEnv returns a copy of the effective config with ENVSECRETS_* variables
+// overriding individual fields. The persisted config is left untouched so
+// environment values are never written back to disk.
+func (c *Config) ApplyEnv() (*Config, error) {
+	resolved := *c
+	resolved.PassphraseCommandArgs = append([]string(nil), c.PassphraseCommandArgs...)
+	resolved.persisted = c.Persisted()
+	resolved.sources = make(map[string]string)
+
+	if v := os.Getenv(constants.BucketEnvVar); v != "" {
+		resolved.Bucket = v
+		resolved.sources["bucket"] = "env " + constants.BucketEnvVar
+	}
+	if v := os.Getenv(constants.GCSCredentialsEnvVar); v != "" {
+		resolved.GCSCredentials = v
+		resolved.sources["gcs_credentials"] = "env " + constants.GCSCredentialsEnvVar
+	}
+	if v := os.Getenv(constants.PassphraseEnvEnvVar); v != "" {
+		resolved.PassphraseEnv = v
+		resolved.sources["passphrase_env"] = "env " + constants.PassphraseEnvEnvVar
+	} else if resolved.PassphraseEnv == "" && os.Getenv(constants.DefaultPassphraseEnv) != "" {
+		// With nothing configured, a set ENVSECRETS_PASSPHRASE is used directly
+		resolved.PassphraseEnv = constants.DefaultPassphraseEnv
+		resolved.sources["passphrase_env"] = "env " + constants.DefaultPassphraseEnv
+	}
+	if v := os.Getenv(constants.PassphraseCommandEnvVar); v != "" {
+		args, err := parseCommandEnv(v)
+		if err != nil {
+			return nil, err
+		}
+		resolved.PassphraseCommandArgs = args
+		resolved.sources["passphrase_command_args"] = "env " + constants.PassphraseCommandEnvVar
+	}
+	if v := os.Getenv(constants.PassphraseKeyringEnvVar); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, domain.Errorf(domain.ErrInvalidConfig, "%s must be true or false (got %q)", constants.PassphraseKeyringEnvVar, v)
+		}
+		resolved.PassphraseKeyring = enabled
+		resolved.sources["passphrase_keyring"] = "env " + constants.PassphraseKeyringEnvVar
+	}
+	if v := os.Getenv(constants.KeyringBackendEnvVar); v != "" {
+		if err := validateKeyringBackend(v); err != nil {
+			return nil, err
+		}
+		resolved.KeyringBackend = v
+		resolved.sources["keyring_backend"] = "env " + constants.KeyringBackendEnvVar
+	}
+	if v := os.Getenv(constants.MachineIDEnvVar); v != "" {
+		resolved.MachineID = v
+		resolved.sources["machine_id"] = "env " + constants.MachineIDEnvVar
+	}
+
+	if resolved.Bucket == "" {
+		return nil, domain.Errorf(domain.ErrNotConfigured,
+			"no bucket configured; set bucket in %s or %s", c.Persisted().Path(), constants.BucketEnvVar)
+	}
+
+	return &resolved, nil
+}
+
+// parseCommandEnv parses ENVSECRETS_PASSPHRASE_COMMAND, either a JSON array
+// of arguments or a whitespace-separated command line (no shell quoting)
+func parseCommandEnv(v string) ([]string, error) {
+	v = strings.TrimSpace(v)
+	if strings.HasPrefix(v, "[") {
+		var args []string
+		if err := json.Unmarshal([]byte(v), &args); err != nil || len(args) == 0 {
+			return nil, domain.Errorf(domain.ErrInvalidConfig, "%s must be a JSON array of strings or a command line", constants.PassphraseCommandEnvVar)
+		}
+		return args, nil
+	}
+	return strings.Fields(v), nil
+}
+
+// Sources returns each overridable field with its effective value and origin:
+// an environment variable, the selected profile, the config file, or the default.
+// Secret values are masked.
+func (c *Config) Sources() []ValueSource {
+	base := c.Persisted()
+	var profile Profile
+	if c.profile != "" {
+		profile = base.Profiles[c.profile]
+	}
+
+	values := map[string]string{
+		"bucket":                  c.Bucket,
+		"gcs_credentials":         maskSet(c.GCSCredentials),
+		"passphrase_env":          c.PassphraseEnv,
+		"passphrase_command_args": strings.Join(c.PassphraseCommandArgs, " "),
+		"passphrase_keyring":      strconv.FormatBool(c.PassphraseKeyring),
+		"keyring_backend":         c.KeyringBackend,
+		"machine_id":              c.MachineID,
+	}
+	inProfile := map[string]bool{
+		"bucket":                  profile.Bucket != "",
+		"gcs_credentials":         profile.GCSCredentials != "",
+		"passphrase_env":          profile.PassphraseEnv != "",
+		"passphrase_command_args": len(profile.PassphraseCommandArgs) > 0,
+		"passphrase_keyring":      profile.PassphraseKeyring != nil,
+		"keyring_backend":         profile.KeyringBackend != "",
+		"machine_id":              profile.MachineID != "",
+	}
+	inFile := map[string]bool{
+		"bucket":                  base.Bucket != "",
+		"gcs_credentials":         base.GCSCredentials != "",
+		"passphrase_env":          base.PassphraseEnv != "",
+		"passphrase_command_args": len(base.PassphraseCommandArgs) > 0,
+		"passphrase_keyring":      base.PassphraseKeyring,
+		"keyring_backend":         base.KeyringBackend != "",
+		"machine_id":              base.MachineID != "",
+	}
+
+	result := make([]ValueSource, 0, len(sourceKeys))
+	for _, key := range sourceKeys {
+		source := SourceDefault
+		switch {
+		case c.sources[key] != "":
+			source = c.sources[key]
+		case inProfile[key]:
+			source = "profile " + c.profile
+		case inFile[key]:
+			source = SourceFile
+		}
+		result = append(result, ValueSource{Key: key, Value: values[key], Source: source})
+	}
+	return result
+}
+
+// maskSet hides a secret value, reporting only whether it is set
+func maskSet(v string) string {
+	if v == "" {
+		return ""
+	}
+	return "[set]"
+}
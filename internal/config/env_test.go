@@ -0,0 +1,130 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// clearConfigEnv blanks every ENVSECRETS_* override for the test
+func clearConfigEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{
+		"ENVSECRETS_BUCKET", "ENVSECRETS_GCS_CREDENTIALS", "ENVSECRETS_PASSPHRASE_ENV",
+		"ENVSECRETS_PASSPHRASE_COMMAND", "ENVSECRETS_PASSPHRASE_KEYRING",
+		"ENVSECRETS_KEYRING_BACKEND", "ENVSECRETS_MACHINE_ID", "ENVSECRETS_PASSPHRASE",
+		"ENVSECRETS_PROFILE",
+	} {
+		t.Setenv(name, "")
+	}
+}
+
+func sourceOf(t *testing.T, c *Config, key string) ValueSource {
+	t.Helper()
+	for _, src := range c.Sources() {
+		if src.Key == key {
+			return src
+		}
+	}
+	t.Fatalf("no source for %s", key)
+	return ValueSource{}
+}
+
+func TestLoad_EnvOnly(t *testing.T) {
+	clearConfigEnv(t)
+	path := filepath.Join(t.TempDir(), "config.yaml")
+
+	_, err := Load(path)
+	require.Error(t, err, "missing file without env is still not configured")
+
+	t.Setenv("ENVSECRETS_BUCKET", "ci-bucket")
+	t.Setenv("ENVSECRETS_GCS_CREDENTIALS", "Y3JlZHM=")
+	t.Setenv("ENVSECRETS_MACHINE_ID", "runner-1")
+	t.Setenv("ENVSECRETS_PASSPHRASE", "s3cret")
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	cfg, err := loaded.ApplyEnv()
+	require.NoError(t, err)
+
+	require.Equal(t, "ci-bucket", cfg.Bucket)
+	require.Equal(t, "Y3JlZHM=", cfg.GCSCredentials)
+	require.Equal(t, "runner-1", cfg.MachineID)
+	require.Equal(t, "ENVSECRETS_PASSPHRASE", cfg.PassphraseEnv)
+	require.Equal(t, "env ENVSECRETS_BUCKET", sourceOf(t, cfg, "bucket").Source)
+	require.Equal(t, "[set]", sourceOf(t, cfg, "gcs_credentials").Value)
+
+	pass, err := NewPassphraseResolver(cfg).Resolve()
+	require.NoError(t, err)
+	require.Equal(t, "s3cret", pass)
+}
+
+func TestApplyEnv_OverridesFieldByField(t *testing.T) {
+	clearConfigEnv(t)
+	cfg := loadProfilesConfig(t, "bucket: file-bucket\npassphrase_env: FILE_PASS\nmachine_id: laptop\n")
+
+	t.Setenv("ENVSECRETS_BUCKET", "env-bucket")
+	t.Setenv("ENVSECRETS_PASSPHRASE_COMMAND", `["pass", "show", "team secret"]`)
+	t.Setenv("ENVSECRETS_PASSPHRASE_KEYRING", "true")
+
+	effective, err := cfg.ApplyEnv()
+	require.NoError(t, err)
+	require.Equal(t, "env-bucket", effective.Bucket)
+	require.Equal(t, "FILE_PASS", effective.PassphraseEnv)
+	require.Equal(t, []string{"pass", "show", "team secret"}, effective.PassphraseCommandArgs)
+	require.True(t, effective.PassphraseKeyring)
+
+	require.Equal(t, "env ENVSECRETS_BUCKET", sourceOf(t, effective, "bucket").Source)
+	require.Equal(t, SourceFile, sourceOf(t, effective, "passphrase_env").Source)
+	require.Equal(t, SourceDefault, sourceOf(t, effective, "keyring_backend").Source)
+
+	// Environment values never reach the persisted config
+	require.Equal(t, "file-bucket", cfg.Bucket)
+	require.NoError(t, effective.SetPassphraseKeyring(true))
+	data, err := os.ReadFile(cfg.Path())
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "env-bucket")
+	require.NotContains(t, string(data), "team secret")
+}
+
+func TestApplyEnv_ProfileSources(t *testing.T) {
+	clearConfigEnv(t)
+	cfg := loadProfilesConfig(t, profilesYAML)
+	t.Setenv("ENVSECRETS_MACHINE_ID", "ci")
+
+	personal, err := cfg.SelectProfile("personal")
+	require.NoError(t, err)
+	effective, err := personal.ApplyEnv()
+	require.NoError(t, err)
+
+	require.Equal(t, "personal", effective.Profile())
+	require.Equal(t, "profile personal", sourceOf(t, effective, "bucket").Source)
+	require.Equal(t, SourceFile, sourceOf(t, effective, "passphrase_env").Source)
+	require.Equal(t, "env ENVSECRETS_MACHINE_ID", sourceOf(t, effective, "machine_id").Source)
+	require.Equal(t, "ci", effective.MachineID)
+}
+
+func TestApplyEnv_InvalidValues(t *testing.T) {
+	tests := []struct {
+		name        string
+		env         string
+		value       string
+		errContains string
+	}{
+		{"keyring flag", "ENVSECRETS_PASSPHRASE_KEYRING", "maybe", "true or false"},
+		{"keyring backend", "ENVSECRETS_KEYRING_BACKEND", "vault", "keyring_backend"},
+		{"command json", "ENVSECRETS_PASSPHRASE_COMMAND", "[not json", "JSON array"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearConfigEnv(t)
+			t.Setenv(tt.env, tt.value)
+			_, err := (&Config{Bucket: "b"}).ApplyEnv()
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tt.errContains)
+		})
+	}
+}
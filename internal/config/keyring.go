@@ -0,0 +1,56 @@
+package config
+
+import (
+	"errors"
+
+	"github.com/charliek/envsecrets/internal/constants"
+	"github.com/charliek/envsecrets/internal/domain"
+)
+
+// Keyring backend names accepted by the keyring_backend config field
+const (
+	KeyringBackendAuto   = "auto"
+	KeyringBackendSystem = "system"
+	KeyringBackendFile   = "file"
+)
+
+// KeyringPassphraseAccount is the keyring account the passphrase is stored under
+const KeyringPassphraseAccount = "passphrase"
+
+// ErrKeyringNotFound is returned when no secret is stored for an account
+var ErrKeyringNotFound = errors.New("secret not found in keyring")
+
+// Keyring stores secrets for the envsecrets service, keyed by account name
+type Keyring interface {
+	// Get returns the secret stored for account, or ErrKeyringNotFound
+	Get(account string) (string, error)
+	// Set stores (or replaces) the secret for account
+	Set(account, secret string) error
+	// Delete removes the secret for account. Deleting a missing secret is not an error.
+	Delete(account string) error
+	// Name returns a human-readable backend description
+	Name() string
+}
+
+// OpenKeyring returns the keyring for the given backend name.
+// "auto" (or empty) selects the OS keyring when available and falls back to
+// the encrypted file keyring on headless machines.
+func OpenKeyring(backend string) (Keyring, error) {
+	switch backend {
+	case "", KeyringBackendAuto:
+		if sys := newSystemKeyring(); sys.Available() {
+			return sys, nil
+		}
+		return NewFileKeyring(constants.DefaultConfigDir()), nil
+	case KeyringBackendSystem:
+		sys := newSystemKeyring()
+		if !sys.Available() {
+			return nil, domain.Errorf(domain.ErrInvalidConfig, "system keyring is not available on this machine")
+		}
+		return sys, nil
+	case KeyringBackendFile:
+		return NewFileKeyring(constants.DefaultConfigDir()), nil
+	default:
+		return nil, domain.Errorf(domain.ErrInvalidConfig, "unknown keyring backend %q (expected auto, system, or file)", backend)
+	}
+}
@@ -0,0 +1,196 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/charliek/envsecrets/internal/constants"
+	"github.com/charliek/envsecrets/internal/domain"
+	limitedio "github.com/charliek/envsecrets/internal/io"
+	"gopkg.in/yaml.v3"
+)
+
+// maxKeyringFileSize bounds how much of the keyring store is read into memory
+const maxKeyringFileSize = 64 * 1024
+
+// FileKeyring is the headless fallback keyring. Secrets are kept in an
+// age-encrypted YAML map whose X25519 identity lives in a sibling key file.
+// Both files are 0600; the split keeps secrets out of config.yaml and out of
+// anything that copies the store without its key.
+type FileKeyring struct {
+	dir string
+}
+
+// NewFileKeyring creates a file keyring rooted at dir (normally ~/.envsecrets)
+func NewFileKeyring(dir string) *FileKeyring {
+	return &FileKeyring{dir: dir}
+}
+
+// Name implements Keyring
+func (k *FileKeyring) Name() string {
+	return "encrypted file (" + k.storePath() + ")"
+}
+
+func (k *FileKeyring) storePath() string {
+	return filepath.Join(k.dir, constants.KeyringFileName)
+}
+
+func (k *FileKeyring) keyPath() string {
+	return filepath.Join(k.dir, constants.KeyringKeyFileName)
+}
+
+// Get implements Keyring
+func (k *FileKeyring) Get(account string) (string, error) {
+	secrets, err := k.load()
+	if err != nil {
+		return "", err
+	}
+	secret, ok := secrets[account]
+	if !ok || secret == "" {
+		return "", ErrKeyringNotFound
+	}
+	return secret, nil
+}
+
+// Set implements Keyring
+func (k *FileKeyring) Set(account, secret string) error {
+	secrets, err := k.load()
+	if err != nil {
+		return err
+	}
+	secrets[account] = secret
+	return k.save(secrets)
+}
+
+// Delete implements Keyring
+func (k *FileKeyring) Delete(account string) error {
+	secrets, err := k.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := secrets[account]; !ok {
+		return nil
+	}
+	delete(secrets, account)
+	if len(secrets) == 0 {
+		if err := os.Remove(k.storePath()); err != nil && !os.IsNotExist(err) {
+			return domain.Errorf(domain.ErrInvalidConfig, "failed to remove keyring file: %v", err)
+		}
+		return nil
+	}
+	return k.save(secrets)
+}
+
+// load decrypts the store. A missing store is an empty keyring.
+func (k *FileKeyring) load() (map[string]string, error) {
+	secrets := make(map[string]string)
+
+	f, err := os.Open(k.storePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return secrets, nil
+		}
+		return nil, domain.Errorf(domain.ErrInvalidConfig, "failed to open keyring file: %v", err)
+	}
+	defer f.Close()
+
+	identity, err := k.readIdentity()
+	if err != nil {
+		return nil, err
+	}
+	if identity == nil {
+		return nil, domain.Errorf(domain.ErrInvalidConfig, "keyring key file %s is missing; run 'envsecrets keyring clear' and set the passphrase again", k.keyPath())
+	}
+
+	r, err := age.Decrypt(f, identity)
+	if err != nil {
+		return nil, domain.Errorf(domain.ErrDecryptFailed, "failed to decrypt keyring file: %v", err)
+	}
+	data, err := limitedio.LimitedReadAll(r, maxKeyringFileSize, "keyring file")
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, &secrets); err != nil {
+		return nil, domain.Errorf(domain.ErrInvalidConfig, "failed to parse keyring file: %v", err)
+	}
+	return secrets, nil
+}
+
+// save encrypts secrets to the store using atomic write
+func (k *FileKeyring) save(secrets map[string]string) error {
+	if err := os.MkdirAll(k.dir, 0700); err != nil {
+		return domain.Errorf(domain.ErrInvalidConfig, "failed to create keyring directory: %v", err)
+	}
+
+	identity, err := k.readIdentity()
+	if err != nil {
+		return err
+	}
+	if identity == nil {
+		if identity, err = k.createIdentity(); err != nil {
+			return err
+		}
+	}
+
+	data, err := yaml.Marshal(secrets)
+	if err != nil {
+		return domain.Errorf(domain.ErrInvalidConfig, "failed to marshal keyring: %v", err)
+	}
+
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, identity.Recipient())
+	if err != nil {
+		return domain.Errorf(domain.ErrEncryptFailed, "failed to encrypt keyring: %v", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return domain.Errorf(domain.ErrEncryptFailed, "failed to encrypt keyring: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		return domain.Errorf(domain.ErrEncryptFailed, "failed to encrypt keyring: %v", err)
+	}
+
+	return writeFileAtomic(k.storePath(), buf.Bytes())
+}
+
+// readIdentity returns the keyring identity, or nil if no key file exists yet
+func (k *FileKeyring) readIdentity() (*age.X25519Identity, error) {
+	data, err := os.ReadFile(k.keyPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, domain.Errorf(domain.ErrInvalidConfig, "failed to read keyring key: %v", err)
+	}
+	identity, err := age.ParseX25519Identity(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, domain.Errorf(domain.ErrInvalidConfig, "invalid keyring key: %v", err)
+	}
+	return identity, nil
+}
+
+func (k *FileKeyring) createIdentity() (*age.X25519Identity, error) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		return nil, domain.Errorf(domain.ErrInvalidConfig, "failed to generate keyring key: %v", err)
+	}
+	if err := writeFileAtomic(k.keyPath(), []byte(identity.String()+"\n")); err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
+
+// writeFileAtomic writes data with 0600 permissions via temp file + rename
+func writeFileAtomic(path string, data []byte) error {
+	tempPath := path + ".tmp"
+	if err := os.WriteFile(tempPath, data, 0600); err != nil {
+		return domain.Errorf(domain.ErrInvalidConfig, "failed to write %s: %v", filepath.Base(path), err)
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath) // Clean up on failure
+		return domain.Errorf(domain.ErrInvalidConfig, "failed to save %s: %v", filepath.Base(path), err)
+	}
+	return nil
+}
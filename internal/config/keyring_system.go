@@ -0,0 +1,171 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/charliek/envsecrets/internal/constants"
+	"github.com/charliek/envsecrets/internal/domain"
+)
+
+// keyringCommandTimeout is the maximum time allowed for keyring helper commands
+const keyringCommandTimeout = 10 * time.Second
+
+// systemKeyring stores secrets in the macOS Keychain (via security) or the
+// Linux Secret Service (via secret-tool). Both helpers are invoked directly
+// without shell interpolation, and secrets are passed on stdin so they never
+// appear in the process table.
+type systemKeyring struct {
+	goos string
+}
+
+func newSystemKeyring() *systemKeyring {
+	return &systemKeyring{goos: runtime.GOOS}
+}
+
+// Available reports whether the OS keyring helper can be used on this machine
+func (k *systemKeyring) Available() bool {
+	switch k.goos {
+	case "darwin":
+		_, err := exec.LookPath("security")
+		return err == nil
+	case "linux":
+		// secret-tool needs a session bus; headless machines usually lack one
+		if os.Getenv("DBUS_SESSION_BUS_ADDRESS") == "" {
+			return false
+		}
+		_, err := exec.LookPath("secret-tool")
+		return err == nil
+	default:
+		return false
+	}
+}
+
+// Name implements Keyring
+func (k *systemKeyring) Name() string {
+	if k.goos == "darwin" {
+		return "macOS Keychain"
+	}
+	return "Secret Service"
+}
+
+// Get implements Keyring
+func (k *systemKeyring) Get(account string) (string, error) {
+	var (
+		out []byte
+		err error
+	)
+	switch k.goos {
+	case "darwin":
+		out, err = runKeyringCommand("", "security", "find-generic-password",
+			"-s", constants.KeyringService, "-a", account, "-w")
+		// security exits 44 when the item does not exist
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 44 {
+			return "", ErrKeyringNotFound
+		}
+	case "linux":
+		out, err = runKeyringCommand("", "secret-tool", "lookup",
+			"service", constants.KeyringService, "account", account)
+		// secret-tool exits 1 with no output when nothing matches
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 && len(out) == 0 {
+			return "", ErrKeyringNotFound
+		}
+	default:
+		return "", k.unsupported()
+	}
+	if err != nil {
+		return "", domain.Errorf(domain.ErrNoPassphrase, "%s lookup failed: %v", k.Name(), err)
+	}
+
+	secret := strings.TrimRight(string(out), "\r\n")
+	if secret == "" {
+		return "", ErrKeyringNotFound
+	}
+	return secret, nil
+}
+
+// Set implements Keyring
+func (k *systemKeyring) Set(account, secret string) error {
+	var err error
+	switch k.goos {
+	case "darwin":
+		// Interactive mode reads commands from stdin; -X takes the password
+		// hex-encoded, which sidesteps quoting and keeps it out of argv.
+		script := fmt.Sprintf("add-generic-password -U -s %q -a %q -X %s\n",
+			constants.KeyringService, account, hex.EncodeToString([]byte(secret)))
+		_, err = runKeyringCommand(script, "security", "-i")
+	case "linux":
+		_, err = runKeyringCommand(secret, "secret-tool", "store",
+			"--label="+constants.KeyringService+" "+account,
+			"service", constants.KeyringService, "account", account)
+	default:
+		return k.unsupported()
+	}
+	if err != nil {
+		return domain.Errorf(domain.ErrInvalidConfig, "failed to store secret in %s: %v", k.Name(), err)
+	}
+	return nil
+}
+
+// Delete implements Keyring
+func (k *systemKeyring) Delete(account string) error {
+	var err error
+	switch k.goos {
+	case "darwin":
+		_, err = runKeyringCommand("", "security", "delete-generic-password",
+			"-s", constants.KeyringService, "-a", account)
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 44 {
+			return nil
+		}
+	case "linux":
+		_, err = runKeyringCommand("", "secret-tool", "clear",
+			"service", constants.KeyringService, "account", account)
+	default:
+		return k.unsupported()
+	}
+	if err != nil {
+		return domain.Errorf(domain.ErrInvalidConfig, "failed to remove secret from %s: %v", k.Name(), err)
+	}
+	return nil
+}
+
+func (k *systemKeyring) unsupported() error {
+	return domain.Errorf(domain.ErrInvalidConfig, "system keyring not supported on %s", k.goos)
+}
+
+// runKeyringCommand runs a keyring helper with optional stdin and returns stdout.
+// Stderr is folded into the returned error so failures are diagnosable.
+func runKeyringCommand(stdin string, name string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), keyringCommandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("%s timed out after %v", name, keyringCommandTimeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return stdout.Bytes(), fmt.Errorf("%w: %s", err, msg)
+		}
+		return stdout.Bytes(), err
+	}
+	return stdout.Bytes(), nil
+}
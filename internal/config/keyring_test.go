@@ -0,0 +1,123 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileKeyring_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	kr := NewFileKeyring(dir)
+
+	_, err := kr.Get(KeyringPassphraseAccount)
+	require.ErrorIs(t, err, ErrKeyringNotFound)
+
+	require.NoError(t, kr.Set(KeyringPassphraseAccount, "s3cret"))
+
+	got, err := NewFileKeyring(dir).Get(KeyringPassphraseAccount)
+	require.NoError(t, err)
+	require.Equal(t, "s3cret", got)
+
+	// Store must be encrypted and both files private
+	data, err := os.ReadFile(filepath.Join(dir, "keyring.age"))
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "s3cret")
+	for _, name := range []string{"keyring.age", "keyring.key"} {
+		info, err := os.Stat(filepath.Join(dir, name))
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0600), info.Mode().Perm(), name)
+	}
+
+	require.NoError(t, kr.Delete(KeyringPassphraseAccount))
+	_, err = kr.Get(KeyringPassphraseAccount)
+	require.ErrorIs(t, err, ErrKeyringNotFound)
+
+	// Deleting again is not an error
+	require.NoError(t, kr.Delete(KeyringPassphraseAccount))
+}
+
+func TestFileKeyring_MissingKeyFile(t *testing.T) {
+	dir := t.TempDir()
+	kr := NewFileKeyring(dir)
+	require.NoError(t, kr.Set(KeyringPassphraseAccount, "s3cret"))
+	require.NoError(t, os.Remove(filepath.Join(dir, "keyring.key")))
+
+	_, err := kr.Get(KeyringPassphraseAccount)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "key file")
+}
+
+func TestOpenKeyring_UnknownBackend(t *testing.T) {
+	_, err := OpenKeyring("vault")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unknown keyring backend")
+}
+
+func TestPassphraseResolver_ResolveFromKeyring(t *testing.T) {
+	kr := NewMockKeyring()
+	kr.Secrets[KeyringPassphraseAccount] = "from-keyring"
+
+	cfg := &Config{Bucket: "test", PassphraseKeyring: true}
+	pass, err := NewPassphraseResolverWithKeyring(cfg, kr).Resolve()
+
+	require.NoError(t, err)
+	require.Equal(t, "from-keyring", pass)
+}
+
+func TestPassphraseResolver_EnvTakesPrecedenceOverKeyring(t *testing.T) {
+	t.Setenv("TEST_PASS_KEYRING", "from-env")
+	kr := NewMockKeyring()
+	kr.Secrets[KeyringPassphraseAccount] = "from-keyring"
+
+	cfg := &Config{Bucket: "test", PassphraseEnv: "TEST_PASS_KEYRING", PassphraseKeyring: true}
+	pass, err := NewPassphraseResolverWithKeyring(cfg, kr).Resolve()
+
+	require.NoError(t, err)
+	require.Equal(t, "from-env", pass)
+}
+
+func TestPassphraseResolver_KeyringMissFallsThrough(t *testing.T) {
+	tests := []struct {
+		name    string
+		keyring *MockKeyring
+	}{
+		{name: "no entry", keyring: NewMockKeyring()},
+		{name: "backend error", keyring: &MockKeyring{Secrets: map[string]string{}, GetError: errors.New("dbus unavailable")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				Bucket:                "test",
+				PassphraseKeyring:     true,
+				PassphraseCommandArgs: []string{"echo", "from-command"},
+			}
+			pass, err := NewPassphraseResolverWithKeyring(cfg, tt.keyring).Resolve()
+
+			require.NoError(t, err)
+			require.Equal(t, "from-command", pass)
+		})
+	}
+}
+
+func TestPassphraseResolver_KeyringDisabledIgnoresEntry(t *testing.T) {
+	kr := NewMockKeyring()
+	kr.Secrets[KeyringPassphraseAccount] = "from-keyring"
+
+	cfg := &Config{Bucket: "test"}
+	_, err := NewPassphraseResolverWithKeyring(cfg, kr).Resolve()
+
+	// Falls through to the interactive prompt, which fails without a terminal
+	require.Error(t, err)
+}
+
+func TestConfig_Validate_KeyringBackend(t *testing.T) {
+	require.NoError(t, (&Config{Bucket: "b", KeyringBackend: "file"}).Validate())
+	err := (&Config{Bucket: "b", KeyringBackend: "bogus"}).Validate()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "keyring_backend")
+}
@@ -0,0 +1,47 @@
+package config
+
+// MockKeyring is an in-memory Keyring for testing
+type MockKeyring struct {
+	Secrets map[string]string
+
+	// For simple use cases
+	GetError error
+	SetError error
+}
+
+// NewMockKeyring creates an empty in-memory keyring
+func NewMockKeyring() *MockKeyring {
+	return &MockKeyring{Secrets: make(map[string]string)}
+}
+
+// Name implements Keyring
+func (m *MockKeyring) Name() string {
+	return "mock"
+}
+
+// Get implements Keyring
+func (m *MockKeyring) Get(account string) (string, error) {
+	if m.GetError != nil {
+		return "", m.GetError
+	}
+	secret, ok := m.Secrets[account]
+	if !ok {
+		return "", ErrKeyringNotFound
+	}
+	return secret, nil
+}
+
+// Set implements Keyring
+func (m *MockKeyring) Set(account, secret string) error {
+	if m.SetError != nil {
+		return m.SetError
+	}
+	m.Secrets[account] = secret
+	return nil
+}
+
+// Delete implements Keyring
+func (m *MockKeyring) Delete(account string) error {
+	delete(m.Secrets, account)
+	return nil
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/charliek/envsecrets/internal/domain"
+	"github.com/charliek/envsecrets/internal/ui"
 	"golang.org/x/term"
 )
 
@@ -18,7 +19,8 @@ const PassphraseCommandTimeout = 30 * time.Second
 
 // PassphraseResolver handles passphrase retrieval from various sources
 type PassphraseResolver struct {
-	config *Config
+	config  *Config
+	keyring Keyring
 }
 
 // NewPassphraseResolver creates a new resolver for the given config
@@ -26,11 +28,17 @@ func NewPassphraseResolver(cfg *Config) *PassphraseResolver {
 	return &PassphraseResolver{config: cfg}
 }
 
+// NewPassphraseResolverWithKeyring creates a resolver with a custom keyring (for testing)
+func NewPassphraseResolverWithKeyring(cfg *Config, kr Keyring) *PassphraseResolver {
+	return &PassphraseResolver{config: cfg, keyring: kr}
+}
+
 // Resolve attempts to get the passphrase using the configured method
 // Resolution order:
 // 1. Environment variable (if passphrase_env is set)
-// 2. Command args (if passphrase_command_args is set)
-// 3. Interactive prompt (if terminal is available)
+// 2. Keyring (if passphrase_keyring is set and an entry exists)
+// 3. Command args (if passphrase_command_args is set)
+// 4. Interactive prompt (if terminal is available and prompts are enabled)
 func (r *PassphraseResolver) Resolve() (string, error) {
 	// Try environment variable first
 	if r.config.PassphraseEnv != "" {
@@ -39,6 +47,14 @@ func (r *PassphraseResolver) Resolve() (string, error) {
 		}
 	}
 
+	// Try keyring. A missing entry or unavailable backend falls through to
+	// the remaining sources rather than failing outright.
+	if r.config.PassphraseKeyring {
+		if pass, err := r.resolveKeyring(); err == nil {
+			return pass, nil
+		}
+	}
+
 	// Try command args
 	if len(r.config.PassphraseCommandArgs) > 0 {
 		pass, err := r.runCommandArgs()
@@ -48,14 +64,27 @@ func (r *PassphraseResolver) Resolve() (string, error) {
 		return pass, nil
 	}
 
-	// Try interactive prompt
-	if term.IsTerminal(int(os.Stdin.Fd())) {
+	// Try interactive prompt, unless prompts are disabled
+	if ui.CanPrompt() {
 		return r.promptInteractive()
 	}
 
 	return "", domain.ErrNoPassphrase
 }
 
+// resolveKeyring reads the passphrase from the configured keyring
+func (r *PassphraseResolver) resolveKeyring() (string, error) {
+	kr := r.keyring
+	if kr == nil {
+		var err error
+		kr, err = OpenKeyring(r.config.KeyringBackend)
+		if err != nil {
+			return "", err
+		}
+	}
+	return kr.Get(r.config.KeyringAccount())
+}
+
 // runCommandArgs executes the passphrase command with explicit arguments (secure method)
 func (r *PassphraseResolver) runCommandArgs() (string, error) {
 	args := r.config.PassphraseCommandArgs
@@ -109,7 +138,7 @@ func (r *PassphraseResolver) promptInteractive() (string, error) {
 
 // PromptNewPassphrase prompts for a new passphrase with confirmation
 func PromptNewPassphrase() (string, error) {
-	if !term.IsTerminal(int(os.Stdin.Fd())) {
+	if !ui.CanPrompt() {
 		return "", domain.Errorf(domain.ErrNoPassphrase, "cannot prompt for passphrase in non-interactive mode")
 	}
 
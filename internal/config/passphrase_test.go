@@ -3,6 +3,8 @@ package config
 import (
 	"testing"
 
+	"github.com/charliek/envsecrets/internal/domain"
+	"github.com/charliek/envsecrets/internal/ui"
 	"github.com/stretchr/testify/require"
 )
 
@@ -113,6 +115,16 @@ func TestPassphraseResolver_NoConfig(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestPassphraseResolver_NonInteractive(t *testing.T) {
+	ui.SetNonInteractive(true)
+	t.Cleanup(func() { ui.SetNonInteractive(false) })
+
+	_, err := NewPassphraseResolver(&Config{Bucket: "test"}).Resolve()
+	require.ErrorIs(t, err, domain.ErrNoPassphrase)
+	_, err = PromptNewPassphrase()
+	require.ErrorIs(t, err, domain.ErrNoPassphrase)
+}
+
 func TestPassphraseResolver_EnvTakesPrecedenceOverCommand(t *testing.T) {
 	t.Setenv("TEST_PASS_PRECEDENCE", "from-env")
 
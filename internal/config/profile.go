@@ -0,0 +1,282 @@
+package config
+
+import (
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charliek/envsecrets/internal/constants"
+	"github.com/charliek/envsecrets/internal/domain"
+)
+
+// validProfileName restricts profile names to characters that are safe in
+// flags, environment variables, and keyring account names
+var validProfileName = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+
+// Profile is a named backend configuration. Empty fields inherit the
+// top-level value from Config.
+type Profile struct {
+	// Bucket is the GCS bucket name
+	Bucket string `yaml:"bucket,omitempty"`
+
+	// PassphraseEnv is the environment variable containing the passphrase
+	PassphraseEnv string `yaml:"passphrase_env,omitempty"`
+
+	// PassphraseCommandArgs specifies a command to retrieve the passphrase
+	PassphraseCommandArgs []string `yaml:"passphrase_command_args,omitempty"`
+
+	// PassphraseKeyring reads the passphrase from the keyring. Unlike the
+	// other fields, an explicit false overrides the top-level value.
+	PassphraseKeyring *bool `yaml:"passphrase_keyring,omitempty"`
+
+	// KeyringBackend selects the keyring: auto (default), system, or file
+	KeyringBackend string `yaml:"keyring_backend,omitempty"`
+
+	// GCSCredentials is base64-encoded service account JSON
+	GCSCredentials string `yaml:"gcs_credentials,omitempty"`
+
+	// MachineID is an optional friendly identifier for this machine
+	MachineID string `yaml:"machine_id,omitempty"`
+}
+
+// validateProfiles checks profile names, per-profile fields, and default_profile
+func (c *Config) validateProfiles() error {
+	for name, p := range c.Profiles {
+		if !validProfileName.MatchString(name) {
+			return domain.Errorf(domain.ErrInvalidConfig, "invalid profile name %q: only alphanumeric, hyphens, underscores, and dots allowed", name)
+		}
+		if p.Bucket == "" && c.Bucket == "" {
+			return domain.Errorf(domain.ErrInvalidConfig, "profile %q: bucket is required", name)
+		}
+		if err := validateKeyringBackend(p.KeyringBackend); err != nil {
+			return domain.Errorf(domain.ErrInvalidConfig, "profile %q: %v", name, err)
+		}
+	}
+
+	if c.DefaultProfile != "" {
+		if _, ok := c.Profiles[c.DefaultProfile]; !ok {
+			return domain.Errorf(domain.ErrInvalidConfig, "default_profile %q is not defined in profiles", c.DefaultProfile)
+		}
+	}
+
+	return nil
+}
+
+// SelectProfile returns the effective config for the requested profile.
+// Selection order: name (the --profile flag), ENVSECRETS_PROFILE,
+// default_profile, then the top-level fields. When nothing is selected and
+// there is no top-level bucket, a single defined profile is used implicitly.
+func (c *Config) SelectProfile(name string) (*Config, error) {
+	if name == "" {
+		name = os.Getenv(constants.ProfileEnvVar)
+	}
+	if name == "" {
+		name = c.DefaultProfile
+	}
+	if name == "" {
+		if c.Bucket != "" || len(c.Profiles) == 0 {
+			return c, nil
+		}
+		names := c.ProfileNames()
+		if len(names) > 1 {
+			return nil, domain.Errorf(domain.ErrInvalidConfig,
+				"no profile selected; use --profile, %s, or default_profile (available: %s)",
+				constants.ProfileEnvVar, strings.Join(names, ", "))
+		}
+		name = names[0]
+	}
+	return c.ForProfile(name)
+}
+
+// SelectForProject returns the effective config for a project whose
+// .envsecrets carries profile: and/or bucket: directives. Callers should
+// only use this when the user has not selected a profile explicitly.
+//
+// A profile directive must name a profile defined in this config. A bucket
+// directive must match a bucket this config already names (top level or any
+// profile) or appear in allowed_buckets; when it matches a profile's bucket,
+// that profile is selected so its credentials and passphrase apply.
+func (c *Config) SelectForProject(profile, bucket string) (*Config, error) {
+	base := c.Persisted()
+
+	if profile != "" {
+		if _, ok := base.Profiles[profile]; !ok {
+			return nil, domain.Errorf(domain.ErrInvalidConfig,
+				"project .envsecrets requests profile %q, which is not defined in %s", profile, base.Path())
+		}
+		resolved, err := base.ForProfile(profile)
+		if err != nil {
+			return nil, err
+		}
+		if bucket == "" || bucket == resolved.Bucket {
+			return resolved, nil
+		}
+		if !base.isAllowedBucket(bucket) {
+			return nil, disallowedBucketError(bucket)
+		}
+		resolved.Bucket = bucket
+		return resolved, nil
+	}
+
+	resolved, err := base.SelectProfile("")
+	if err != nil {
+		return nil, err
+	}
+	if bucket == "" || bucket == resolved.Bucket {
+		return resolved, nil
+	}
+
+	// Prefer the profile that owns this bucket so matching credentials apply
+	for _, name := range base.ProfileNames() {
+		if base.Profiles[name].Bucket == bucket {
+			return base.ForProfile(name)
+		}
+	}
+	if base.Bucket == bucket {
+		return base, nil
+	}
+
+	if !base.isAllowedBucket(bucket) {
+		return nil, disallowedBucketError(bucket)
+	}
+	if resolved == base {
+		copied := *base
+		copied.persisted = base
+		resolved = &copied
+	}
+	resolved.Bucket = bucket
+	return resolved, nil
+}
+
+// isAllowedBucket reports whether a project may select bucket
+func (c *Config) isAllowedBucket(bucket string) bool {
+	if bucket == c.Bucket {
+		return true
+	}
+	for _, p := range c.Profiles {
+		if p.Bucket == bucket {
+			return true
+		}
+	}
+	for _, allowed := range c.AllowedBuckets {
+		if allowed == bucket {
+			return true
+		}
+	}
+	return false
+}
+
+func disallowedBucketError(bucket string) error {
+	return domain.Errorf(domain.ErrPermissionDenied,
+		"project .envsecrets requests bucket %q, which is not in your config; add it to allowed_buckets if you trust this project", bucket)
+}
+
+// ForProfile returns the effective config for a named profile: top-level
+// fields overlaid with the profile's non-empty fields.
+func (c *Config) ForProfile(name string) (*Config, error) {
+	base := c.Persisted()
+	p, ok := base.Profiles[name]
+	if !ok {
+		available := "none defined"
+		if names := base.ProfileNames(); len(names) > 0 {
+			available = strings.Join(names, ", ")
+		}
+		return nil, domain.Errorf(domain.ErrInvalidConfig, "unknown profile %q (available: %s)", name, available)
+	}
+
+	resolved := *base
+	resolved.PassphraseCommandArgs = append([]string(nil), base.PassphraseCommandArgs...)
+	if p.Bucket != "" {
+		resolved.Bucket = p.Bucket
+	}
+	if p.PassphraseEnv != "" {
+		resolved.PassphraseEnv = p.PassphraseEnv
+	}
+	if len(p.PassphraseCommandArgs) > 0 {
+		resolved.PassphraseCommandArgs = append([]string(nil), p.PassphraseCommandArgs...)
+	}
+	if p.PassphraseKeyring != nil {
+		resolved.PassphraseKeyring = *p.PassphraseKeyring
+	}
+	if p.KeyringBackend != "" {
+		resolved.KeyringBackend = p.KeyringBackend
+	}
+	if p.GCSCredentials != "" {
+		resolved.GCSCredentials = p.GCSCredentials
+	}
+	if p.MachineID != "" {
+		resolved.MachineID = p.MachineID
+	}
+	resolved.profile = name
+	resolved.persisted = base
+
+	return &resolved, nil
+}
+
+// Profile returns the selected profile name, or "" when using top-level fields
+func (c *Config) Profile() string {
+	return c.profile
+}
+
+// Persisted returns the config as loaded from disk, before profile selection.
+// Changes that should be saved must be made on this value.
+func (c *Config) Persisted() *Config {
+	if c.persisted != nil {
+		return c.persisted
+	}
+	return c
+}
+
+// ProfileNames returns the defined profile names in sorted order
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// KeyringAccount returns the keyring account holding this config's passphrase.
+// Each profile gets its own entry so different buckets can use different passphrases.
+func (c *Config) KeyringAccount() string {
+	if c.profile == "" {
+		return KeyringPassphraseAccount
+	}
+	return KeyringPassphraseAccount + ":" + c.profile
+}
+
+// SetPassphraseKeyring enables or disables passphrase_keyring for the selected
+// profile (or the top level) and saves it to the config file, preserving
+// comments and unrelated keys. A profile stores false explicitly so it
+// overrides a top-level true.
+func (c *Config) SetPassphraseKeyring(enabled bool) error {
+	c.PassphraseKeyring = enabled
+
+	stored := c.Persisted()
+	key := "passphrase_keyring"
+	if c.profile == "" {
+		stored.PassphraseKeyring = enabled
+	} else {
+		p := stored.Profiles[c.profile]
+		p.PassphraseKeyring = &enabled
+		stored.Profiles[c.profile] = p
+		key = "profiles." + c.profile + "." + key
+	}
+
+	editor, err := OpenEditor(stored.Path())
+	if err != nil {
+		return err
+	}
+	if enabled || c.profile != "" {
+		err = editor.Set(key, []string{strconv.FormatBool(enabled)})
+	} else {
+		_, err = editor.Unset(key)
+	}
+	if err != nil {
+		return err
+	}
+	return editor.Save()
+}
@@ -0,0 +1,250 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const profilesYAML = `passphrase_env: SHARED_PASS
+machine_id: laptop
+default_profile: work
+profiles:
+  work:
+    bucket: company-secrets
+    gcs_credentials: d29yaw==
+  personal:
+    bucket: my-secrets
+    passphrase_command_args: ["pass", "show", "envsecrets"]
+    machine_id: home
+`
+
+func loadProfilesConfig(t *testing.T, content string) *Config {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	cfg, err := Load(path)
+	require.NoError(t, err)
+	return cfg
+}
+
+func TestConfig_SelectProfile(t *testing.T) {
+	t.Setenv("ENVSECRETS_PROFILE", "")
+	cfg := loadProfilesConfig(t, profilesYAML)
+
+	// default_profile applies when nothing else selects
+	work, err := cfg.SelectProfile("")
+	require.NoError(t, err)
+	require.Equal(t, "work", work.Profile())
+	require.Equal(t, "company-secrets", work.Bucket)
+	require.Equal(t, "d29yaw==", work.GCSCredentials)
+	require.Equal(t, "SHARED_PASS", work.PassphraseEnv, "top-level fields are inherited")
+	require.Equal(t, "laptop", work.MachineID)
+
+	// Explicit name wins over default_profile
+	personal, err := cfg.SelectProfile("personal")
+	require.NoError(t, err)
+	require.Equal(t, "my-secrets", personal.Bucket)
+	require.Equal(t, []string{"pass", "show", "envsecrets"}, personal.PassphraseCommandArgs)
+	require.Equal(t, "home", personal.MachineID)
+	require.Empty(t, personal.GCSCredentials)
+
+	// Selection never mutates the persisted config
+	require.Empty(t, cfg.Bucket)
+	require.Same(t, cfg, personal.Persisted())
+}
+
+func TestConfig_SelectProfile_EnvVar(t *testing.T) {
+	t.Setenv("ENVSECRETS_PROFILE", "personal")
+	cfg := loadProfilesConfig(t, profilesYAML)
+
+	selected, err := cfg.SelectProfile("")
+	require.NoError(t, err)
+	require.Equal(t, "personal", selected.Profile())
+
+	// The flag still beats the environment
+	selected, err = cfg.SelectProfile("work")
+	require.NoError(t, err)
+	require.Equal(t, "work", selected.Profile())
+}
+
+func TestConfig_SelectProfile_Errors(t *testing.T) {
+	t.Setenv("ENVSECRETS_PROFILE", "")
+
+	cfg := loadProfilesConfig(t, profilesYAML)
+	_, err := cfg.SelectProfile("missing")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `unknown profile "missing"`)
+	require.Contains(t, err.Error(), "personal, work")
+
+	// Two profiles, no default, no top-level bucket: ambiguous
+	cfg = loadProfilesConfig(t, `profiles:
+  a: {bucket: a}
+  b: {bucket: b}
+`)
+	_, err = cfg.SelectProfile("")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "no profile selected")
+}
+
+func TestConfig_SelectProfile_Implicit(t *testing.T) {
+	t.Setenv("ENVSECRETS_PROFILE", "")
+
+	// Flat config: top-level fields, no profile
+	cfg := loadProfilesConfig(t, "bucket: flat\n")
+	selected, err := cfg.SelectProfile("")
+	require.NoError(t, err)
+	require.Equal(t, "", selected.Profile())
+	require.Equal(t, "flat", selected.Bucket)
+
+	// Single profile without top-level bucket is used implicitly
+	cfg = loadProfilesConfig(t, "profiles:\n  only: {bucket: only-bucket}\n")
+	selected, err = cfg.SelectProfile("")
+	require.NoError(t, err)
+	require.Equal(t, "only", selected.Profile())
+}
+
+func TestConfig_ValidateProfiles(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		errContains string
+	}{
+		{
+			name:        "profile without bucket",
+			content:     "profiles:\n  work: {machine_id: x}\n",
+			errContains: `profile "work": bucket is required`,
+		},
+		{
+			name:        "undefined default",
+			content:     "default_profile: nope\nprofiles:\n  work: {bucket: b}\n",
+			errContains: `default_profile "nope"`,
+		},
+		{
+			name:        "invalid name",
+			content:     "profiles:\n  \"a b\": {bucket: b}\n",
+			errContains: "invalid profile name",
+		},
+		{
+			name:        "invalid keyring backend",
+			content:     "profiles:\n  work: {bucket: b, keyring_backend: bogus}\n",
+			errContains: "keyring_backend",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0600))
+			_, err := Load(path)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tt.errContains)
+		})
+	}
+}
+
+func TestConfig_KeyringAccount(t *testing.T) {
+	t.Setenv("ENVSECRETS_PROFILE", "")
+	cfg := loadProfilesConfig(t, profilesYAML)
+	require.Equal(t, "passphrase", cfg.KeyringAccount())
+
+	work, err := cfg.ForProfile("work")
+	require.NoError(t, err)
+	require.Equal(t, "passphrase:work", work.KeyringAccount())
+}
+
+func TestConfig_SetPassphraseKeyring_Profile(t *testing.T) {
+	t.Setenv("ENVSECRETS_PROFILE", "")
+	cfg := loadProfilesConfig(t, profilesYAML)
+	work, err := cfg.ForProfile("work")
+	require.NoError(t, err)
+
+	require.NoError(t, work.SetPassphraseKeyring(true))
+	require.True(t, work.PassphraseKeyring)
+
+	reloaded, err := Load(cfg.Path())
+	require.NoError(t, err)
+	require.False(t, reloaded.PassphraseKeyring, "top level must be untouched")
+	require.NotNil(t, reloaded.Profiles["work"].PassphraseKeyring)
+	require.True(t, *reloaded.Profiles["work"].PassphraseKeyring)
+	require.Nil(t, reloaded.Profiles["personal"].PassphraseKeyring)
+	require.Empty(t, reloaded.Bucket, "resolved fields must not leak into the saved file")
+}
+
+func TestConfig_SetPassphraseKeyring_ProfileOverridesTopLevel(t *testing.T) {
+	t.Setenv("ENVSECRETS_PROFILE", "")
+	cfg := loadProfilesConfig(t, "passphrase_keyring: true\n"+profilesYAML)
+	work, err := cfg.ForProfile("work")
+	require.NoError(t, err)
+	require.True(t, work.PassphraseKeyring, "profiles inherit a top-level true")
+
+	require.NoError(t, work.SetPassphraseKeyring(false))
+	require.False(t, work.PassphraseKeyring)
+
+	reloaded, err := Load(cfg.Path())
+	require.NoError(t, err)
+	require.True(t, reloaded.PassphraseKeyring, "top level must be untouched")
+	require.NotNil(t, reloaded.Profiles["work"].PassphraseKeyring, "false must be written, not unset")
+	require.False(t, *reloaded.Profiles["work"].PassphraseKeyring)
+
+	work, err = reloaded.ForProfile("work")
+	require.NoError(t, err)
+	require.False(t, work.PassphraseKeyring)
+	personal, err := reloaded.ForProfile("personal")
+	require.NoError(t, err)
+	require.True(t, personal.PassphraseKeyring)
+}
+
+func TestConfig_SelectForProject(t *testing.T) {
+	t.Setenv("ENVSECRETS_PROFILE", "")
+	cfg := loadProfilesConfig(t, profilesYAML+"allowed_buckets: [partner-secrets]\n")
+
+	tests := []struct {
+		name        string
+		profile     string
+		bucket      string
+		wantProfile string
+		wantBucket  string
+		errContains string
+	}{
+		{name: "no directives uses default", wantProfile: "work", wantBucket: "company-secrets"},
+		{name: "profile directive", profile: "personal", wantProfile: "personal", wantBucket: "my-secrets"},
+		{name: "bucket owned by a profile selects it", bucket: "my-secrets", wantProfile: "personal", wantBucket: "my-secrets"},
+		{name: "allow-listed bucket keeps default credentials", bucket: "partner-secrets", wantProfile: "work", wantBucket: "partner-secrets"},
+		{name: "profile plus allow-listed bucket", profile: "personal", bucket: "partner-secrets", wantProfile: "personal", wantBucket: "partner-secrets"},
+		{name: "unknown bucket refused", bucket: "attacker-bucket", errContains: "allowed_buckets"},
+		{name: "unknown bucket refused with profile", profile: "work", bucket: "attacker-bucket", errContains: "allowed_buckets"},
+		{name: "unknown profile refused", profile: "ghost", errContains: `profile "ghost"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selected, err := cfg.SelectForProject(tt.profile, tt.bucket)
+			if tt.errContains != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.errContains)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantProfile, selected.Profile())
+			require.Equal(t, tt.wantBucket, selected.Bucket)
+		})
+	}
+
+	// Overriding the bucket must never mutate the persisted config
+	require.Equal(t, "company-secrets", cfg.Profiles["work"].Bucket)
+	require.Empty(t, cfg.Bucket)
+}
+
+func TestConfig_SelectForProject_FlatConfig(t *testing.T) {
+	t.Setenv("ENVSECRETS_PROFILE", "")
+	cfg := loadProfilesConfig(t, "bucket: flat\nallowed_buckets: [other]\n")
+
+	selected, err := cfg.SelectForProject("", "other")
+	require.NoError(t, err)
+	require.Equal(t, "other", selected.Bucket)
+	require.Equal(t, "flat", cfg.Bucket)
+	require.Same(t, cfg, selected.Persisted())
+}
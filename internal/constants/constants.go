@@ -27,6 +27,39 @@ const (
 	// ConfigEnvVar is the environment variable to override config path
 	ConfigEnvVar = "ENVSECRETS_CONFIG"
 
+	// ProfileEnvVar is the environment variable that selects a config profile
+	ProfileEnvVar = "ENVSECRETS_PROFILE"
+
+	// BucketEnvVar overrides the bucket config field
+	BucketEnvVar = "ENVSECRETS_BUCKET"
+
+	// GCSCredentialsEnvVar overrides the gcs_credentials config field
+	GCSCredentialsEnvVar = "ENVSECRETS_GCS_CREDENTIALS"
+
+	// PassphraseEnvEnvVar overrides the passphrase_env config field
+	PassphraseEnvEnvVar = "ENVSECRETS_PASSPHRASE_ENV"
+
+	// PassphraseCommandEnvVar overrides passphrase_command_args (JSON array or command line)
+	PassphraseCommandEnvVar = "ENVSECRETS_PASSPHRASE_COMMAND"
+
+	// PassphraseKeyringEnvVar overrides the passphrase_keyring config field
+	PassphraseKeyringEnvVar = "ENVSECRETS_PASSPHRASE_KEYRING"
+
+	// KeyringBackendEnvVar overrides the keyring_backend config field
+	KeyringBackendEnvVar = "ENVSECRETS_KEYRING_BACKEND"
+
+	// MachineIDEnvVar overrides the machine_id config field
+	MachineIDEnvVar = "ENVSECRETS_MACHINE_ID"
+
+	// KeyringService is the service name secrets are stored under in OS keyrings
+	KeyringService = "envsecrets"
+
+	// KeyringFileName is the encrypted store used by the file keyring fallback
+	KeyringFileName = "keyring.age"
+
+	// KeyringKeyFileName holds the age identity that decrypts KeyringFileName
+	KeyringKeyFileName = "keyring.key"
+
 	// DefaultLogCount is the default number of log entries to show
 	DefaultLogCount = 10
 
@@ -111,3 +111,21 @@ type testError struct {
 func (e *testError) Error() string {
 	return e.msg
 }
+
+func TestFingerprinter(t *testing.T) {
+	f, err := NewFingerprinter("passphrase", "acme/api")
+	require.NoError(t, err)
+	same, err := NewFingerprinter("passphrase", "acme/api")
+	require.NoError(t, err)
+	otherScope, err := NewFingerprinter("passphrase", "acme/web")
+	require.NoError(t, err)
+	otherPass, err := NewFingerprinter("other", "acme/api")
+	require.NoError(t, err)
+
+	fp := f.Fingerprint("secret")
+	require.Len(t, fp, 8)
+	require.Equal(t, fp, same.Fingerprint("secret"))
+	require.NotEqual(t, fp, f.Fingerprint("secret2"))
+	require.NotEqual(t, fp, otherScope.Fingerprint("secret"))
+	require.NotEqual(t, fp, otherPass.Fingerprint("secret"))
+}
@@ -0,0 +1,43 @@
+package crypto
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/charliek/envsecrets/internal/domain"
+	"golang.org/x/crypto/scrypt"
+)
+
+// fingerprintScryptN is the scrypt cost for the fingerprint key: cheap
+// enough to derive on every diff, costly enough that a fingerprint seen in
+// a log is not a fast passphrase oracle
+const fingerprintScryptN = 1 << 15
+
+// fingerprintSize is the number of HMAC bytes shown, as hex
+const fingerprintSize = 4
+
+// Fingerprinter computes short keyed fingerprints of secret values, so two
+// values can be compared without showing either. Everyone with the same
+// passphrase and scope gets the same fingerprints.
+type Fingerprinter struct {
+	key []byte
+}
+
+// NewFingerprinter derives a fingerprint key from the passphrase. Scope
+// (the repository identity) keeps fingerprints of the same value from
+// matching across projects.
+func NewFingerprinter(passphrase, scope string) (*Fingerprinter, error) {
+	key, err := scrypt.Key([]byte(passphrase), []byte("envsecrets-fingerprint:"+scope), fingerprintScryptN, 8, 1, sha256.Size)
+	if err != nil {
+		return nil, domain.Errorf(domain.ErrEncryptFailed, "failed to derive fingerprint key: %v", err)
+	}
+	return &Fingerprinter{key: key}, nil
+}
+
+// Fingerprint returns a short hex HMAC-SHA256 of value
+func (f *Fingerprinter) Fingerprint(value string) string {
+	mac := hmac.New(sha256.New, f.key)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil)[:fingerprintSize])
+}
@@ -36,6 +36,7 @@ var (
 	ErrFileSizeTooLarge = errors.New("file size exceeds limit")
 	ErrVersionTooNew    = errors.New("storage format version not supported")
 	ErrVersionUnknown   = errors.New("storage format not recognized")
+	ErrCommandFailed    = errors.New("command failed")
 )
 
 // ExitCodeError wraps an error with an exit code
@@ -4,6 +4,9 @@ import "time"
 
 // RepoInfo identifies a repository
 type RepoInfo struct {
+	// Host is the git host (e.g. github.com) for host-qualified identities,
+	// empty for the default owner/name identity
+	Host string `json:"host,omitempty"`
 	// Owner is the repository owner (user or organization)
 	Owner string `json:"owner"`
 	// Name is the repository name
@@ -12,14 +15,17 @@ type RepoInfo struct {
 	RemoteURL string `json:"remote_url,omitempty"`
 }
 
-// String returns the owner/name format
+// String returns the owner/name format, prefixed by the host when qualified
 func (r RepoInfo) String() string {
+	if r.Host != "" {
+		return r.Host + "/" + r.Owner + "/" + r.Name
+	}
 	return r.Owner + "/" + r.Name
 }
 
 // CachePath returns the relative cache path for this repo
 func (r RepoInfo) CachePath() string {
-	return r.Owner + "/" + r.Name
+	return r.String()
 }
 
 // Commit represents a git commit
@@ -80,6 +86,36 @@ type FileStatus struct {
 	CacheExists bool `json:"cache_exists"`
 	// Modified indicates if local differs from cache
 	Modified bool `json:"modified"`
+	// Pattern is the .envsecrets glob that tracks this file, empty for literal entries
+	Pattern string `json:"pattern,omitempty"`
+}
+
+// SafetyProblem identifies how a tracked file could leak into the project's git
+type SafetyProblem string
+
+const (
+	// SafetyNotIgnored means the project's .gitignore does not ignore the file
+	SafetyNotIgnored SafetyProblem = "not_ignored"
+	// SafetyInIndex means the file is committed or staged in the project's git
+	SafetyInIndex SafetyProblem = "in_index"
+	// SafetyInHistory means a past commit of the project's git contains the file
+	SafetyInHistory SafetyProblem = "in_history"
+	// SafetySecretValue means staged content contains a value from a tracked file
+	SafetySecretValue SafetyProblem = "secret_value"
+)
+
+// SafetyIssue is one accidental-commit risk for a tracked file
+type SafetyIssue struct {
+	// Path is the file path relative to project root
+	Path string `json:"path"`
+	// Problem is the kind of risk
+	Problem SafetyProblem `json:"problem"`
+	// Commit is the most recent commit containing the file (SafetyInHistory only)
+	Commit string `json:"commit,omitempty"`
+	// Key is the variable whose value was found (SafetySecretValue only)
+	Key string `json:"key,omitempty"`
+	// Source is the tracked file the value comes from (SafetySecretValue only)
+	Source string `json:"source,omitempty"`
 }
 
 // SyncAction represents the recommended next action for the user
@@ -147,6 +183,8 @@ type PushResult struct {
 	FilesAdded int `json:"files_added"`
 	// FilesDeleted is the number of files deleted
 	FilesDeleted int `json:"files_deleted"`
+	// ExamplesUpdated lists the .example files regenerated after the push
+	ExamplesUpdated []string `json:"examples_updated,omitempty"`
 	// Warning is a non-fatal advisory the caller should surface to the user.
 	// Currently used to flag when the post-push baseline marker write failed
 	// (push succeeded remotely but this machine kept a stale LAST_SYNCED).
@@ -170,6 +208,9 @@ type PullResult struct {
 	// delete) that pull intentionally does not overwrite. These will be
 	// published by the next push.
 	FilesKeptLocal int `json:"files_kept_local,omitempty"`
+	// FilesMerged is the number of files both sides edited that were
+	// merged key by key against this machine's last-synced baseline
+	FilesMerged int `json:"files_merged,omitempty"`
 	// FilesSkippedConflict is the number of conflicting files that were skipped
 	FilesSkippedConflict int `json:"files_skipped_conflict,omitempty"`
 	// Ref is the commit ref that was pulled
@@ -195,6 +236,19 @@ type StorageFormatInfo struct {
 type EnvSecretsConfig struct {
 	// RepoOverride from "repo: owner/name" directive
 	RepoOverride string `json:"repo_override,omitempty"`
-	// Files is the list of tracked file paths
+	// Profile from "profile: name" directive, selecting a user config profile
+	Profile string `json:"profile,omitempty"`
+	// Bucket from "bucket: name" directive; must be allowed by the user config
+	Bucket string `json:"bucket,omitempty"`
+	// Identity from "identity: host|owner" directive; "host" qualifies the
+	// repository identity with the git host
+	Identity string `json:"identity,omitempty"`
+	// Remotes from "remote: a, b" directive; git remotes whose URL
+	// identifies the project, in order of preference
+	Remotes []string `json:"remotes,omitempty"`
+	// Examples from "example: path" directives; tracked files (or patterns)
+	// whose .example sibling is regenerated on push
+	Examples []string `json:"examples,omitempty"`
+	// Files is the list of tracked file paths and glob patterns
 	Files []string `json:"files"`
 }
@@ -0,0 +1,60 @@
+package dotenv
+
+// ChangeKind is how a key differs between two files
+type ChangeKind string
+
+// Kinds of change reported by Diff
+const (
+	Added   ChangeKind = "added"
+	Removed ChangeKind = "removed"
+	Changed ChangeKind = "changed"
+)
+
+// Change is one key that differs between two files
+type Change struct {
+	Key  string
+	Kind ChangeKind
+	// Old is the value in the old file; empty when added
+	Old string
+	// New is the value in the new file; empty when removed
+	New string
+}
+
+// Diff returns the keys whose values differ from old to new: added and
+// changed keys in the new file's order, then removed keys in the old
+// file's order. Either file may be nil, meaning it does not exist.
+func Diff(old, new *File) []Change {
+	oldValues, newValues := values(old), values(new)
+
+	var changes []Change
+	for _, key := range keys(new) {
+		value := newValues[key]
+		prev, ok := oldValues[key]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Key: key, Kind: Added, New: value})
+		case prev != value:
+			changes = append(changes, Change{Key: key, Kind: Changed, Old: prev, New: value})
+		}
+	}
+	for _, key := range keys(old) {
+		if _, ok := newValues[key]; !ok {
+			changes = append(changes, Change{Key: key, Kind: Removed, Old: oldValues[key]})
+		}
+	}
+	return changes
+}
+
+func keys(f *File) []string {
+	if f == nil {
+		return nil
+	}
+	return f.Keys()
+}
+
+func values(f *File) map[string]string {
+	if f == nil {
+		return nil
+	}
+	return f.Map()
+}
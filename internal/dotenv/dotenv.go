@@ -0,0 +1,471 @@
+package dotenv
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/charliek/envsecrets/internal/domain"
+)
+
+// Kind is the kind of a line (or, for multi-line values, lines) in a file
+type Kind int
+
+const (
+	// Blank is an empty or whitespace-only line
+	Blank Kind = iota
+	// Comment is a full-line "#" comment
+	Comment
+	// Assignment is a KEY=value line
+	Assignment
+)
+
+// Quote characters a value can be written with; 0 means unquoted
+const (
+	SingleQuote = '\''
+	DoubleQuote = '"'
+	Backtick    = '`'
+)
+
+// Entry is one blank line, comment, or assignment. Its raw text is kept so
+// an unmodified file serializes byte for byte.
+type Entry struct {
+	Kind Kind
+	// Key is the assigned name
+	Key string
+	// Value is the decoded value, without quotes or escapes
+	Value string
+	// Export is set when the assignment has an "export " prefix
+	Export bool
+	// Quote is the quote character the value is written with, or 0
+	Quote byte
+	// Comment is the text after "#" of a comment line or an assignment's
+	// inline comment, trimmed
+	Comment string
+	// Line is the 1-based line the entry starts on
+	Line int
+
+	raw string
+	// prefix and suffix surround the encoded value in raw, so setting a
+	// value keeps the key's spacing, export prefix, and inline comment
+	prefix string
+	suffix string
+}
+
+// Raw returns the entry's text, including its trailing newline
+func (e *Entry) Raw() string {
+	return e.raw
+}
+
+// SetValue replaces an assignment's value, keeping its quote style when the
+// value can be written with it
+func (e *Entry) SetValue(value string) {
+	encoded, quote := encodeValue(value, e.Quote)
+	if quote == 0 && encoded != "" && strings.HasPrefix(e.suffix, "#") {
+		// "KEY= #note" had an empty value; keep the note a comment
+		e.suffix = " " + e.suffix
+	}
+	e.Value = value
+	e.Quote = quote
+	e.raw = e.prefix + encoded + e.suffix
+}
+
+// File is a parsed dotenv file
+type File struct {
+	Entries []*Entry
+}
+
+// ParseError reports a line that is not a blank line, comment, or
+// assignment. It matches domain.ErrInvalidArgs.
+type ParseError struct {
+	Line int
+	Msg  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+func (e *ParseError) Unwrap() error {
+	return domain.ErrInvalidArgs
+}
+
+// Parse parses dotenv content. Values may be unquoted, or single-,
+// double-, or backtick-quoted; quoted values may span lines. Only double
+// quotes process escapes (\n, \r, \t, \", \\, \$). For every input Parse
+// accepts, Bytes returns the input unchanged.
+func Parse(data []byte) (*File, error) {
+	p := &parser{data: string(data), line: 1}
+	f := &File{}
+	for p.pos < len(p.data) {
+		e, err := p.entry()
+		if err != nil {
+			return nil, err
+		}
+		f.Entries = append(f.Entries, e)
+	}
+	return f, nil
+}
+
+// Bytes serializes the file
+func (f *File) Bytes() []byte {
+	var b bytes.Buffer
+	for _, e := range f.Entries {
+		b.WriteString(e.raw)
+	}
+	return b.Bytes()
+}
+
+// Lookup returns the value of key. When a key is assigned more than once
+// the last assignment wins, as when the file is sourced by a shell.
+func (f *File) Lookup(key string) (string, bool) {
+	if e := f.last(key); e != nil {
+		return e.Value, true
+	}
+	return "", false
+}
+
+// Keys returns the assigned keys in order of first assignment
+func (f *File) Keys() []string {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, e := range f.Entries {
+		if e.Kind == Assignment && !seen[e.Key] {
+			seen[e.Key] = true
+			keys = append(keys, e.Key)
+		}
+	}
+	return keys
+}
+
+// Map returns every key's value
+func (f *File) Map() map[string]string {
+	m := make(map[string]string)
+	for _, e := range f.Entries {
+		if e.Kind == Assignment {
+			m[e.Key] = e.Value
+		}
+	}
+	return m
+}
+
+// Set assigns value to key, updating its last assignment in place or
+// appending a new one at the end of the file
+func (f *File) Set(key, value string) {
+	if e := f.last(key); e != nil {
+		e.SetValue(value)
+		return
+	}
+
+	if n := len(f.Entries); n > 0 && !strings.HasSuffix(f.Entries[n-1].raw, "\n") {
+		last := f.Entries[n-1]
+		last.raw += "\n"
+		last.suffix += "\n"
+	}
+	e := &Entry{Kind: Assignment, Key: key, Line: f.nextLine(), prefix: key + "=", suffix: "\n"}
+	e.SetValue(value)
+	f.Entries = append(f.Entries, e)
+}
+
+// Unset removes every assignment of key and reports whether there was one
+func (f *File) Unset(key string) bool {
+	kept := f.Entries[:0]
+	found := false
+	for _, e := range f.Entries {
+		if e.Kind == Assignment && e.Key == key {
+			found = true
+			continue
+		}
+		kept = append(kept, e)
+	}
+	f.Entries = kept
+	if found {
+		f.renumber()
+	}
+	return found
+}
+
+func (f *File) last(key string) *Entry {
+	if i := f.lastIndex(key); i >= 0 {
+		return f.Entries[i]
+	}
+	return nil
+}
+
+// nextLine returns the line number an appended entry starts on
+func (f *File) nextLine() int {
+	if len(f.Entries) == 0 {
+		return 1
+	}
+	last := f.Entries[len(f.Entries)-1]
+	return last.Line + strings.Count(last.raw, "\n")
+}
+
+// QuoteValue returns value as it is written in a dotenv file: unquoted
+// when that reads back the same, otherwise double-quoted
+func QuoteValue(value string) string {
+	encoded, _ := encodeValue(value, 0)
+	return encoded
+}
+
+// ValidKey reports whether key can be assigned in a dotenv file
+func ValidKey(key string) bool {
+	if key == "" || !isKeyStart(key[0]) {
+		return false
+	}
+	for i := 1; i < len(key); i++ {
+		if !isKeyChar(key[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+type parser struct {
+	data string
+	pos  int
+	line int
+}
+
+// entry parses the entry starting at p.pos
+func (p *parser) entry() (*Entry, error) {
+	start, line := p.pos, p.line
+	end := p.lineEnd(start)
+	content := trimNewline(p.data[start:end])
+	trimmed := strings.TrimLeft(content, " \t")
+
+	e := &Entry{Line: line}
+	switch {
+	case strings.TrimRight(trimmed, " \t") == "":
+		e.Kind = Blank
+	case trimmed[0] == '#':
+		e.Kind = Comment
+		e.Comment = strings.TrimSpace(trimmed[1:])
+	default:
+		if err := p.assignment(e, start, start+len(content)-len(trimmed), end); err != nil {
+			return nil, err
+		}
+		return e, nil
+	}
+	e.raw = p.data[start:end]
+	p.advance(end)
+	return e, nil
+}
+
+// assignment parses KEY=value starting at the key (or "export") at i
+func (p *parser) assignment(e *Entry, start, i, end int) error {
+	e.Kind = Assignment
+	keyStart := i
+	if rest, ok := strings.CutPrefix(p.data[i:end], "export"); ok && rest != "" && isBlank(rest[0]) {
+		j := i + len("export")
+		for j < end && isBlank(p.data[j]) {
+			j++
+		}
+		if k := p.keyEnd(j, end); k > j && p.equalsAt(k, end) {
+			e.Export = true
+			keyStart = j
+		}
+	}
+
+	keyEnd := p.keyEnd(keyStart, end)
+	if keyEnd == keyStart || !p.equalsAt(keyEnd, end) {
+		return &ParseError{Line: p.line, Msg: fmt.Sprintf("expected KEY=value, got %q", trimNewline(p.data[start:end]))}
+	}
+	e.Key = p.data[keyStart:keyEnd]
+
+	eq := keyEnd
+	for p.data[eq] != '=' {
+		eq++
+	}
+	v := eq + 1
+	for v < end && isBlank(p.data[v]) {
+		v++
+	}
+	e.prefix = p.data[start:v]
+
+	if v < end && isQuote(p.data[v]) {
+		return p.quoted(e, start, v)
+	}
+
+	content := start + len(trimNewline(p.data[start:end]))
+	valueEnd := content
+	for j := v; j < content; j++ {
+		if p.data[j] == '#' && (j > eq+1 && isBlank(p.data[j-1])) {
+			valueEnd = j
+			e.Comment = strings.TrimSpace(p.data[j+1 : content])
+			break
+		}
+	}
+	for valueEnd > v && isBlank(p.data[valueEnd-1]) {
+		valueEnd--
+	}
+	e.Value = p.data[v:valueEnd]
+	e.suffix = p.data[valueEnd:end]
+	e.raw = p.data[start:end]
+	p.advance(end)
+	return nil
+}
+
+// quoted parses a quoted value opening at v, which may span lines
+func (p *parser) quoted(e *Entry, start, v int) error {
+	quote := p.data[v]
+	closing := -1
+	for j := v + 1; j < len(p.data); j++ {
+		if p.data[j] == '\\' && quote == DoubleQuote {
+			j++
+			continue
+		}
+		if p.data[j] == quote {
+			closing = j
+			break
+		}
+	}
+	if closing < 0 {
+		return &ParseError{Line: p.line, Msg: fmt.Sprintf("unterminated %c-quoted value for %s", quote, e.Key)}
+	}
+
+	end := p.lineEnd(closing)
+	rest := trimNewline(p.data[closing+1 : end])
+	if trimmed := strings.TrimLeft(rest, " \t"); trimmed != "" {
+		if trimmed[0] != '#' {
+			line := p.line + strings.Count(p.data[start:closing], "\n")
+			return &ParseError{Line: line, Msg: fmt.Sprintf("unexpected text after quoted value for %s", e.Key)}
+		}
+		e.Comment = strings.TrimSpace(trimmed[1:])
+	}
+
+	e.Quote = quote
+	e.Value = decodeValue(p.data[v+1:closing], quote)
+	e.suffix = p.data[closing+1 : end]
+	e.raw = p.data[start:end]
+	p.advance(end)
+	return nil
+}
+
+// lineEnd returns the index just past the newline ending the line at i
+func (p *parser) lineEnd(i int) int {
+	if n := strings.IndexByte(p.data[i:], '\n'); n >= 0 {
+		return i + n + 1
+	}
+	return len(p.data)
+}
+
+// keyEnd returns the index just past the key starting at i, or i
+func (p *parser) keyEnd(i, end int) int {
+	if i >= end || !isKeyStart(p.data[i]) {
+		return i
+	}
+	j := i + 1
+	for j < end && isKeyChar(p.data[j]) {
+		j++
+	}
+	return j
+}
+
+// equalsAt reports whether "=" follows i after optional blanks
+func (p *parser) equalsAt(i, end int) bool {
+	for i < end && isBlank(p.data[i]) {
+		i++
+	}
+	return i < end && p.data[i] == '='
+}
+
+func (p *parser) advance(end int) {
+	p.line += strings.Count(p.data[p.pos:end], "\n")
+	p.pos = end
+}
+
+// decodeValue removes the escapes of a double-quoted value
+func decodeValue(s string, quote byte) string {
+	if quote != DoubleQuote || !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case '"', '\\', '$':
+			b.WriteByte(s[i])
+		default:
+			b.WriteByte('\\')
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// encodeValue writes value with the preferred quote when it reads back the
+// same, falling back to double quotes
+func encodeValue(value string, quote byte) (string, byte) {
+	switch quote {
+	case 0:
+		if !needsQuoting(value) {
+			return value, 0
+		}
+	case SingleQuote, Backtick:
+		if !strings.ContainsRune(value, rune(quote)) {
+			return string(quote) + value + string(quote), quote
+		}
+	}
+
+	var b strings.Builder
+	b.WriteByte(DoubleQuote)
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; c {
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '"', '\\':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	b.WriteByte(DoubleQuote)
+	return b.String(), DoubleQuote
+}
+
+// needsQuoting reports whether an unquoted value would read back differently
+func needsQuoting(value string) bool {
+	if value == "" {
+		return false
+	}
+	if isBlank(value[0]) || isBlank(value[len(value)-1]) || isQuote(value[0]) || value[0] == '#' {
+		return true
+	}
+	return strings.ContainsAny(value, "\n\r") || strings.Contains(value, " #") || strings.Contains(value, "\t#")
+}
+
+// trimNewline strips a trailing "\n" or "\r\n"
+func trimNewline(s string) string {
+	s = strings.TrimSuffix(s, "\n")
+	return strings.TrimSuffix(s, "\r")
+}
+
+func isBlank(c byte) bool {
+	return c == ' ' || c == '\t'
+}
+
+func isQuote(c byte) bool {
+	return c == SingleQuote || c == DoubleQuote || c == Backtick
+}
+
+func isKeyStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isKeyChar(c byte) bool {
+	return isKeyStart(c) || (c >= '0' && c <= '9') || c == '.' || c == '-'
+}
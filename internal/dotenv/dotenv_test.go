@@ -0,0 +1,238 @@
+package dotenv
+
+import (
+	"testing"
+
+	"github.com/charliek/envsecrets/internal/domain"
+	"github.com/stretchr/testify/require"
+)
+
+const sample = `# Database settings
+DATABASE_URL=postgres://user:pass@db/app # primary
+export API_KEY="abc\"123\n"
+
+  SPACED = value with spaces
+SINGLE='no $escapes \n here'
+TICKS=` + "`back tick`" + `
+CERT="-----BEGIN-----
+MIIB
+-----END-----"
+EMPTY=
+HASH=a#b
+NOTE= # only a comment
+DUP=first
+DUP=second
+`
+
+func TestParse(t *testing.T) {
+	f, err := Parse([]byte(sample))
+	require.NoError(t, err)
+	require.Equal(t, sample, string(f.Bytes()))
+
+	require.Equal(t, []string{"DATABASE_URL", "API_KEY", "SPACED", "SINGLE", "TICKS", "CERT", "EMPTY", "HASH", "NOTE", "DUP"}, f.Keys())
+
+	tests := map[string]string{
+		"DATABASE_URL": "postgres://user:pass@db/app",
+		"API_KEY":      "abc\"123\n",
+		"SPACED":       "value with spaces",
+		"SINGLE":       `no $escapes \n here`,
+		"TICKS":        "back tick",
+		"CERT":         "-----BEGIN-----\nMIIB\n-----END-----",
+		"EMPTY":        "",
+		"HASH":         "a#b",
+		"NOTE":         "",
+		"DUP":          "second",
+	}
+	for key, want := range tests {
+		got, ok := f.Lookup(key)
+		require.True(t, ok, key)
+		require.Equal(t, want, got, key)
+	}
+	_, ok := f.Lookup("MISSING")
+	require.False(t, ok)
+
+	require.Equal(t, Comment, f.Entries[0].Kind)
+	require.Equal(t, "Database settings", f.Entries[0].Comment)
+	require.Equal(t, "primary", f.Entries[1].Comment)
+	require.True(t, f.Entries[2].Export)
+	require.Equal(t, byte(DoubleQuote), f.Entries[2].Quote)
+	require.Equal(t, Blank, f.Entries[3].Kind)
+	require.Equal(t, 8, f.Entries[7].Line)
+	require.Equal(t, 11, f.Entries[8].Line)
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		line    int
+	}{
+		{"no equals", "A=1\nJUSTAKEY\n", 2},
+		{"bad key", "1KEY=x\n", 1},
+		{"unterminated quote", "A=1\nB=\"open\nC=2\n", 2},
+		{"text after quote", "A='x' y\n", 1},
+		{"text after multi-line quote", "A=\"x\ny\" z\n", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.content))
+			var parseErr *ParseError
+			require.ErrorAs(t, err, &parseErr)
+			require.Equal(t, tt.line, parseErr.Line)
+			require.ErrorIs(t, err, domain.ErrInvalidArgs)
+		})
+	}
+}
+
+func TestFile_Set(t *testing.T) {
+	f, err := Parse([]byte("export A='one' # keep\nB=two\nNOTE= #note\nC=3"))
+	require.NoError(t, err)
+
+	f.Set("A", "uno")
+	f.Set("B", "two words # not a comment")
+	f.Set("NOTE", "set")
+	f.Set("D", "line1\nline2")
+	require.Equal(t, "export A='uno' # keep\nB=\"two words # not a comment\"\nNOTE= set #note\nC=3\nD=\"line1\\nline2\"\n", string(f.Bytes()))
+
+	// Single quotes cannot hold a single quote
+	f.Set("A", "it's")
+	require.Equal(t, "export A=\"it's\" # keep\n", f.Entries[0].Raw())
+
+	require.True(t, f.Unset("B"))
+	require.False(t, f.Unset("B"))
+	require.Equal(t, []string{"A", "NOTE", "C", "D"}, f.Keys())
+
+	reparsed, err := Parse(f.Bytes())
+	require.NoError(t, err)
+	require.Equal(t, f.Map(), reparsed.Map())
+}
+
+func TestQuoteValue(t *testing.T) {
+	require.Equal(t, "plain", QuoteValue("plain"))
+	require.Equal(t, "", QuoteValue(""))
+	require.Equal(t, `" padded"`, QuoteValue(" padded"))
+	require.Equal(t, `"#hash"`, QuoteValue("#hash"))
+	require.Equal(t, `a\b"c`, QuoteValue(`a\b"c`))
+	require.Equal(t, `"a\\b\"c\n"`, QuoteValue("a\\b\"c\n"))
+}
+
+func TestValidKey(t *testing.T) {
+	require.True(t, ValidKey("API_KEY"))
+	require.True(t, ValidKey("_x.y-z9"))
+	require.False(t, ValidKey(""))
+	require.False(t, ValidKey("9LIVES"))
+	require.False(t, ValidKey("A B"))
+}
+
+// FuzzParse checks that every file Parse accepts serializes unchanged, and
+// that re-encoding every value reads back the same keys and values
+func FuzzParse(f *testing.F) {
+	f.Add([]byte(sample))
+	f.Add([]byte("A=1\r\nB=\"x\r\ny\"\r\n"))
+	f.Add([]byte("export export=1\nexport  B = 'q' #c"))
+	f.Add([]byte("NOTE= #x\nK=`a\n#b`"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		file, err := Parse(data)
+		if err != nil {
+			return
+		}
+		require.Equal(t, string(data), string(file.Bytes()))
+
+		want := file.Map()
+		keys := file.Keys()
+		for _, e := range file.Entries {
+			if e.Kind == Assignment {
+				e.SetValue(e.Value)
+			}
+		}
+		reparsed, err := Parse(file.Bytes())
+		require.NoError(t, err, "%q", file.Bytes())
+		require.Equal(t, want, reparsed.Map())
+		require.Equal(t, keys, reparsed.Keys())
+	})
+}
+
+// FuzzQuoteValue checks that any value survives being written and parsed
+func FuzzQuoteValue(f *testing.F) {
+	f.Add("plain")
+	f.Add(" lead # and \"quotes\" \\n\n")
+	f.Add("'`")
+	f.Fuzz(func(t *testing.T, value string) {
+		for _, quote := range []byte{0, SingleQuote, DoubleQuote, Backtick} {
+			file := &File{}
+			file.Set("KEY", "")
+			file.Entries[0].Quote = quote
+			file.Entries[0].SetValue(value)
+
+			reparsed, err := Parse(file.Bytes())
+			require.NoError(t, err, "%q", file.Bytes())
+			got, ok := reparsed.Lookup("KEY")
+			require.True(t, ok)
+			require.Equal(t, value, got)
+		}
+	})
+}
+
+func TestDiff(t *testing.T) {
+	old, err := Parse([]byte("A=1\nB=2\nC=3\n"))
+	require.NoError(t, err)
+	new, err := Parse([]byte("D=4\nB=two\nA=1\n"))
+	require.NoError(t, err)
+
+	require.Equal(t, []Change{
+		{Key: "D", Kind: Added, New: "4"},
+		{Key: "B", Kind: Changed, Old: "2", New: "two"},
+		{Key: "C", Kind: Removed, Old: "3"},
+	}, Diff(old, new))
+
+	require.Equal(t, []Change{{Key: "A", Kind: Removed, Old: "1"}, {Key: "B", Kind: Removed, Old: "2"}, {Key: "C", Kind: Removed, Old: "3"}}, Diff(old, nil))
+	require.Empty(t, Diff(old, old))
+}
+
+func TestMerge(t *testing.T) {
+	parse := func(s string) *File {
+		f, err := Parse([]byte(s))
+		require.NoError(t, err)
+		return f
+	}
+	base := parse("# api\nAPI_KEY=a\nDB_URL=db1\nOLD=x\n")
+	local := parse("# api\nAPI_KEY=local\n\n# database\nDB_URL=db1\nOLD=x\nLOCAL_ONLY=1\n")
+	remote := parse("# api\nAPI_KEY=a\nDB_URL=db2\n# cache\nREDIS=r\nOLD=x\n")
+
+	merged, conflicts := Merge(base, local, remote)
+	require.Empty(t, conflicts)
+	require.Equal(t, "# api\nAPI_KEY=local\n\n# database\nDB_URL=db2\n# cache\nREDIS=r\nOLD=x\nLOCAL_ONLY=1\n", string(merged.Bytes()))
+
+	// Local is not modified
+	require.Equal(t, "db1", local.Map()["DB_URL"])
+
+	// Removal on one side is merged; edits on both sides conflict
+	remote = parse("API_KEY=remote\nDB_URL=db1\n")
+	merged, conflicts = Merge(base, local, remote)
+	require.Equal(t, []string{"API_KEY"}, conflicts)
+	require.Equal(t, map[string]string{"API_KEY": "local", "DB_URL": "db1", "LOCAL_ONLY": "1"}, merged.Map())
+
+	// The same change on both sides is not a conflict
+	remote = parse("# api\nAPI_KEY=local\nDB_URL=db1\nOLD=x\n")
+	_, conflicts = Merge(base, local, remote)
+	require.Empty(t, conflicts)
+
+	// Adding a key after a file without a trailing newline
+	merged = parse("A=1")
+	merged.Adopt(parse("A=1\nB=2"), "B")
+	require.Equal(t, "A=1\nB=2\n", string(merged.Bytes()))
+	require.Equal(t, 2, merged.Entries[1].Line)
+}
+
+func TestMarkConflicts(t *testing.T) {
+	local, err := Parse([]byte("# a\nA=local\nB=1"))
+	require.NoError(t, err)
+	remote, err := Parse([]byte("A=remote\nB=1\nC=new\n"))
+	require.NoError(t, err)
+
+	marked := MarkConflicts(local, remote, []string{"A", "C"})
+	require.Equal(t, "# a\n<<<<<<< local\nA=local\n=======\nA=remote\n>>>>>>> remote\nB=1\n<<<<<<< local\n=======\nC=new\n>>>>>>> remote\n", string(marked))
+	require.True(t, HasConflictMarkers(marked))
+	require.False(t, HasConflictMarkers(local.Bytes()))
+	require.Equal(t, "<<<<<<< local\nx\n=======\n>>>>>>> remote\n", string(MarkConflict([]byte("x"), nil)))
+}
@@ -0,0 +1,191 @@
+package dotenv
+
+import "strings"
+
+// Merge combines the key changes made on two sides since base. The result
+// starts from local, so its ordering, comments, and formatting are kept;
+// each key only remote changed is taken from remote, and keys remote added
+// are placed after the key they follow there, with the comments directly
+// above them. Keys both sides changed differently are returned as
+// conflicts and keep their local value.
+func Merge(base, local, remote *File) (*File, []string) {
+	merged := local.Clone()
+	baseValues, localValues, remoteValues := base.Map(), local.Map(), remote.Map()
+
+	var conflicts []string
+	seen := make(map[string]bool)
+	for _, key := range append(remote.Keys(), base.Keys()...) {
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		b, inBase := baseValues[key]
+		l, inLocal := localValues[key]
+		r, inRemote := remoteValues[key]
+		switch {
+		case inRemote == inBase && r == b:
+			// Remote did not change the key
+		case inLocal == inBase && l == b:
+			merged.Adopt(remote, key)
+		case inLocal == inRemote && l == r:
+			// Both made the same change
+		default:
+			conflicts = append(conflicts, key)
+		}
+	}
+	return merged, conflicts
+}
+
+// Clone returns a copy of the file that can be modified independently
+func (f *File) Clone() *File {
+	c := &File{Entries: make([]*Entry, len(f.Entries))}
+	for i, e := range f.Entries {
+		copied := *e
+		c.Entries[i] = &copied
+	}
+	return c
+}
+
+// Adopt makes key's value match src: set when src assigns it, removed when
+// it does not. A key new to f is inserted after the key preceding it in
+// src, together with the comment lines directly above it.
+func (f *File) Adopt(src *File, key string) {
+	value, ok := src.Lookup(key)
+	if !ok {
+		f.Unset(key)
+		return
+	}
+	if e := f.last(key); e != nil {
+		e.SetValue(value)
+		return
+	}
+
+	// The key's assignment in src and the comments directly above it
+	i := len(src.Entries) - 1
+	for src.Entries[i].Kind != Assignment || src.Entries[i].Key != key {
+		i--
+	}
+	start := i
+	for start > 0 && src.Entries[start-1].Kind == Comment {
+		start--
+	}
+	block := make([]*Entry, 0, i-start+1)
+	for _, e := range src.Entries[start : i+1] {
+		copied := *e
+		if !strings.HasSuffix(copied.raw, "\n") {
+			copied.raw += "\n"
+			copied.suffix += "\n"
+		}
+		block = append(block, &copied)
+	}
+
+	// Insert after the nearest preceding key f also has, else at the end
+	at := len(f.Entries)
+	for j := start - 1; j >= 0; j-- {
+		if prev := src.Entries[j]; prev.Kind == Assignment {
+			if k := f.lastIndex(prev.Key); k >= 0 {
+				at = k + 1
+				break
+			}
+		}
+	}
+	if at > 0 && !strings.HasSuffix(f.Entries[at-1].raw, "\n") {
+		prev := f.Entries[at-1]
+		prev.raw += "\n"
+		prev.suffix += "\n"
+	}
+	f.Entries = append(f.Entries[:at], append(block, f.Entries[at:]...)...)
+	f.renumber()
+}
+
+func (f *File) lastIndex(key string) int {
+	for i := len(f.Entries) - 1; i >= 0; i-- {
+		if e := f.Entries[i]; e.Kind == Assignment && e.Key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// renumber recomputes entry line numbers after entries moved
+func (f *File) renumber() {
+	line := 1
+	for _, e := range f.Entries {
+		e.Line = line
+		line += strings.Count(e.raw, "\n")
+	}
+}
+
+// Conflict marker lines, as written by MarkConflict
+const (
+	MarkerLocal     = "<<<<<<< local"
+	MarkerSeparator = "======="
+	MarkerRemote    = ">>>>>>> remote"
+)
+
+// MarkConflict returns the local and remote text between conflict markers,
+// for a person to resolve in an editor
+func MarkConflict(local, remote []byte) []byte {
+	var b strings.Builder
+	b.WriteString(MarkerLocal + "\n")
+	b.WriteString(withNewline(string(local)))
+	b.WriteString(MarkerSeparator + "\n")
+	b.WriteString(withNewline(string(remote)))
+	b.WriteString(MarkerRemote + "\n")
+	return []byte(b.String())
+}
+
+// MarkConflicts returns local's content with the assignment of each given
+// key replaced by conflict markers around its local and remote assignment.
+// Keys only remote assigns are marked at the end.
+func MarkConflicts(local, remote *File, keys []string) []byte {
+	marked := make(map[int]string)
+	var trailing []string
+	for _, key := range keys {
+		if i := local.lastIndex(key); i >= 0 {
+			marked[i] = key
+		} else {
+			trailing = append(trailing, key)
+		}
+	}
+	assignment := func(f *File, key string) []byte {
+		if i := f.lastIndex(key); i >= 0 {
+			return []byte(f.Entries[i].raw)
+		}
+		return nil
+	}
+
+	var b strings.Builder
+	for i, e := range local.Entries {
+		if key, ok := marked[i]; ok {
+			b.Write(MarkConflict([]byte(e.raw), assignment(remote, key)))
+		} else {
+			b.WriteString(e.raw)
+		}
+	}
+	out := b.String()
+	for _, key := range trailing {
+		out = withNewline(out) + string(MarkConflict(nil, assignment(remote, key)))
+	}
+	return []byte(out)
+}
+
+// HasConflictMarkers reports whether content still has a conflict marker line
+func HasConflictMarkers(content []byte) bool {
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if strings.HasPrefix(line, "<<<<<<<") || strings.HasPrefix(line, ">>>>>>>") || line == MarkerSeparator {
+			return true
+		}
+	}
+	return false
+}
+
+// withNewline terminates non-empty text with a newline
+func withNewline(s string) string {
+	if s != "" && !strings.HasSuffix(s, "\n") {
+		return s + "\n"
+	}
+	return s
+}
@@ -0,0 +1,100 @@
+package export
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/charliek/envsecrets/internal/domain"
+)
+
+// CI providers
+const (
+	ProviderGitHub = "github"
+	ProviderGitLab = "gitlab"
+)
+
+// Providers lists the supported CI providers
+var Providers = []string{ProviderGitHub, ProviderGitLab}
+
+// WriteGitHubMasks writes an ::add-mask:: workflow command for every line of
+// every value, since the runner masks log lines one at a time
+func WriteGitHubMasks(w io.Writer, vars []Var) error {
+	escaper := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	var b bytes.Buffer
+	for _, v := range vars {
+		for _, line := range strings.Split(v.Value, "\n") {
+			line = strings.TrimSuffix(line, "\r")
+			if line == "" {
+				continue
+			}
+			fmt.Fprintf(&b, "::add-mask::%s\n", escaper.Replace(line))
+		}
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// WriteGitHubEnv writes vars for the $GITHUB_ENV file. Every value uses the
+// multi-line form with a random delimiter that no key or value contains.
+func WriteGitHubEnv(w io.Writer, vars []Var) error {
+	var b bytes.Buffer
+	for _, v := range vars {
+		if !isEnvName(v.Key) {
+			return invalidName(v.Key, ProviderGitHub)
+		}
+		delimiter, err := githubDelimiter(v)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&b, "%s<<%s\n%s\n%s\n", v.Key, delimiter, v.Value, delimiter)
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// githubDelimiter returns a heredoc delimiter that does not occur in v
+func githubDelimiter(v Var) (string, error) {
+	for {
+		buf := make([]byte, 16)
+		if _, err := rand.Read(buf); err != nil {
+			return "", fmt.Errorf("failed to generate delimiter: %w", err)
+		}
+		delimiter := "ghadelimiter_" + hex.EncodeToString(buf)
+		if !strings.Contains(v.Key, delimiter) && !strings.Contains(v.Value, delimiter) {
+			return delimiter, nil
+		}
+	}
+}
+
+// WriteGitLabDotenv writes vars as a GitLab dotenv report artifact. The
+// report has no escaping: GitLab trims whitespace around values and strips
+// surrounding quotes, so values it would change are rejected.
+func WriteGitLabDotenv(w io.Writer, vars []Var) error {
+	var b bytes.Buffer
+	for _, v := range vars {
+		if !isEnvName(v.Key) {
+			return invalidName(v.Key, ProviderGitLab)
+		}
+		if strings.ContainsAny(v.Value, "\r\n") {
+			return domain.Errorf(domain.ErrInvalidArgs, "%s has a multi-line value, which GitLab dotenv reports cannot hold", v.Key)
+		}
+		if strings.TrimSpace(v.Value) != v.Value {
+			return domain.Errorf(domain.ErrInvalidArgs, "%s has leading or trailing whitespace, which GitLab dotenv reports drop", v.Key)
+		}
+		if quoted(v.Value) {
+			return domain.Errorf(domain.ErrInvalidArgs, "%s is wrapped in quotes, which GitLab dotenv reports strip", v.Key)
+		}
+		fmt.Fprintf(&b, "%s=%s\n", v.Key, v.Value)
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// quoted reports whether s starts and ends with the same quote character
+func quoted(s string) bool {
+	return len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0]
+}
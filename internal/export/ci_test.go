@@ -0,0 +1,56 @@
+package export
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+
+	"github.com/charliek/envsecrets/internal/domain"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteGitHubMasks(t *testing.T) {
+	var b bytes.Buffer
+	require.NoError(t, WriteGitHubMasks(&b, []Var{
+		{Key: "A", Value: "100%"},
+		{Key: "EMPTY", Value: ""},
+		{Key: "CERT", Value: "line1\r\nline2\n"},
+	}))
+	require.Equal(t, "::add-mask::100%25\n::add-mask::line1\n::add-mask::line2\n", b.String())
+}
+
+func TestWriteGitHubEnv(t *testing.T) {
+	var b bytes.Buffer
+	require.NoError(t, WriteGitHubEnv(&b, []Var{
+		{Key: "A", Value: "one"},
+		{Key: "CERT", Value: "line1\nline2"},
+	}))
+	pattern := regexp.MustCompile(`^A<<(ghadelimiter_[0-9a-f]{32})\none\n(ghadelimiter_[0-9a-f]{32})\nCERT<<(ghadelimiter_[0-9a-f]{32})\nline1\nline2\n(ghadelimiter_[0-9a-f]{32})\n$`)
+	m := pattern.FindStringSubmatch(b.String())
+	require.NotNil(t, m, b.String())
+	require.Equal(t, m[1], m[2])
+	require.Equal(t, m[3], m[4])
+
+	err := WriteGitHubEnv(&bytes.Buffer{}, []Var{{Key: "app.name", Value: "x"}})
+	require.ErrorIs(t, err, domain.ErrInvalidArgs)
+}
+
+func TestWriteGitLabDotenv(t *testing.T) {
+	var b bytes.Buffer
+	require.NoError(t, WriteGitLabDotenv(&b, []Var{{Key: "A", Value: "it's \"raw\""}, {Key: "B", Value: ""}}))
+	require.Equal(t, "A=it's \"raw\"\nB=\n", b.String())
+
+	err := WriteGitLabDotenv(&bytes.Buffer{}, []Var{{Key: "CERT", Value: "line1\nline2"}})
+	require.ErrorIs(t, err, domain.ErrInvalidArgs)
+
+	err = WriteGitLabDotenv(&bytes.Buffer{}, []Var{{Key: "app-name", Value: "x"}})
+	require.ErrorIs(t, err, domain.ErrInvalidArgs)
+
+	for _, value := range []string{" x", "x ", "\tx", `"x"`, `'x'`, `""`} {
+		err = WriteGitLabDotenv(&bytes.Buffer{}, []Var{{Key: "A", Value: value}})
+		require.ErrorIs(t, err, domain.ErrInvalidArgs, "value %q", value)
+	}
+	b.Reset()
+	require.NoError(t, WriteGitLabDotenv(&b, []Var{{Key: "A", Value: `"x`}, {Key: "B", Value: `'x"`}, {Key: "C", Value: "a b"}}))
+	require.Equal(t, "A=\"x\nB='x\"\nC=a b\n", b.String())
+}
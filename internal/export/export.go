@@ -0,0 +1,214 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/charliek/envsecrets/internal/domain"
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats
+const (
+	FormatJSON       = "json"
+	FormatYAML       = "yaml"
+	FormatShell      = "shell"
+	FormatDockerEnv  = "docker-env"
+	FormatSystemd    = "systemd"
+	FormatProperties = "properties"
+)
+
+// Formats lists the supported output formats
+var Formats = []string{FormatJSON, FormatYAML, FormatShell, FormatDockerEnv, FormatSystemd, FormatProperties}
+
+// Var is one variable to export
+type Var struct {
+	Key   string
+	Value string
+}
+
+// Write encodes vars in format, keeping their order
+func Write(w io.Writer, format string, vars []Var) error {
+	var data []byte
+	var err error
+	switch format {
+	case FormatJSON:
+		data = encodeJSON(vars)
+	case FormatYAML:
+		data, err = encodeYAML(vars)
+	case FormatShell:
+		data, err = encodeShell(vars)
+	case FormatDockerEnv:
+		data, err = encodeDockerEnv(vars)
+	case FormatSystemd:
+		data, err = encodeSystemd(vars)
+	case FormatProperties:
+		data = encodeProperties(vars)
+	default:
+		return domain.Errorf(domain.ErrInvalidArgs, "unknown format %q (supported: %s)", format, strings.Join(Formats, ", "))
+	}
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// encodeJSON writes a JSON object with keys in order
+func encodeJSON(vars []Var) []byte {
+	if len(vars) == 0 {
+		return []byte("{}\n")
+	}
+	var b bytes.Buffer
+	b.WriteString("{\n")
+	for i, v := range vars {
+		fmt.Fprintf(&b, "  %s: %s", jsonString(v.Key), jsonString(v.Value))
+		if i < len(vars)-1 {
+			b.WriteByte(',')
+		}
+		b.WriteByte('\n')
+	}
+	b.WriteString("}\n")
+	return b.Bytes()
+}
+
+// jsonString quotes s as a JSON string without HTML escaping
+func jsonString(s string) string {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// encodeYAML writes a YAML mapping of strings. The encoder quotes values
+// that would otherwise read as numbers, booleans, or null.
+func encodeYAML(vars []Var) ([]byte, error) {
+	doc := varsMapping(vars)
+	if len(vars) == 0 {
+		doc.Style = yaml.FlowStyle
+	}
+	var b bytes.Buffer
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+// encodeShell writes POSIX export statements with single-quoted values,
+// safe to eval
+func encodeShell(vars []Var) ([]byte, error) {
+	var b bytes.Buffer
+	for _, v := range vars {
+		if !isEnvName(v.Key) {
+			return nil, invalidName(v.Key, FormatShell)
+		}
+		fmt.Fprintf(&b, "export %s='%s'\n", v.Key, strings.ReplaceAll(v.Value, "'", `'\''`))
+	}
+	return b.Bytes(), nil
+}
+
+// encodeDockerEnv writes a file for docker --env-file, which takes each
+// line's value verbatim and has no quoting or multi-line values
+func encodeDockerEnv(vars []Var) ([]byte, error) {
+	var b bytes.Buffer
+	for _, v := range vars {
+		if strings.ContainsAny(v.Value, "\r\n") {
+			return nil, domain.Errorf(domain.ErrInvalidArgs, "%s has a multi-line value, which docker env files cannot hold", v.Key)
+		}
+		fmt.Fprintf(&b, "%s=%s\n", v.Key, v.Value)
+	}
+	return b.Bytes(), nil
+}
+
+// encodeSystemd writes a file for systemd's EnvironmentFile=. Values are
+// double-quoted, where newlines are kept and \ " ` $ need a backslash.
+func encodeSystemd(vars []Var) ([]byte, error) {
+	escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "`", "\\`", `$`, `\$`)
+	var b bytes.Buffer
+	for _, v := range vars {
+		if !isEnvName(v.Key) {
+			return nil, invalidName(v.Key, FormatSystemd)
+		}
+		fmt.Fprintf(&b, "%s=\"%s\"\n", v.Key, escaper.Replace(v.Value))
+	}
+	return b.Bytes(), nil
+}
+
+// encodeProperties writes a Java properties file, escaping as
+// Properties.store does so it loads with either ISO-8859-1 or UTF-8
+func encodeProperties(vars []Var) []byte {
+	var b bytes.Buffer
+	for _, v := range vars {
+		b.WriteString(propertiesEscape(v.Key, true))
+		b.WriteByte('=')
+		b.WriteString(propertiesEscape(v.Value, false))
+		b.WriteByte('\n')
+	}
+	return b.Bytes()
+}
+
+// propertiesEscape escapes s for a properties key or value. Spaces are
+// escaped everywhere in a key and only at the start of a value.
+func propertiesEscape(s string, key bool) string {
+	var b strings.Builder
+	for i, r := range s {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '=', ':', '#', '!':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case ' ':
+			if key || i == 0 {
+				b.WriteByte('\\')
+			}
+			b.WriteByte(' ')
+		default:
+			if r < 0x20 || r > 0x7e {
+				for _, u := range utf16.Encode([]rune{r}) {
+					fmt.Fprintf(&b, `\u%04X`, u)
+				}
+				continue
+			}
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// isEnvName reports whether key is a valid shell variable name. Dotenv keys
+// may also hold dots and dashes.
+func isEnvName(key string) bool {
+	if key == "" || (key[0] >= '0' && key[0] <= '9') {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if c != '_' && (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+func invalidName(key, format string) error {
+	return domain.Errorf(domain.ErrInvalidArgs, "%s is not a valid variable name for %s output", key, format)
+}
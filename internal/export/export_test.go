@@ -0,0 +1,114 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/charliek/envsecrets/internal/domain"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+var testVars = []Var{
+	{Key: "PLAIN", Value: "value"},
+	{Key: "QUOTES", Value: `it's "quoted"`},
+	{Key: "SPECIAL", Value: "$HOME `cmd` \\n <&>"},
+	{Key: "BOOL", Value: "true"},
+	{Key: "EMPTY", Value: ""},
+}
+
+func write(t *testing.T, format string, vars []Var) string {
+	t.Helper()
+	var b bytes.Buffer
+	require.NoError(t, Write(&b, format, vars))
+	return b.String()
+}
+
+func TestWrite_JSON(t *testing.T) {
+	vars := append(testVars, Var{Key: "MULTI", Value: "line1\nline2"})
+	got := write(t, FormatJSON, vars)
+	require.True(t, strings.HasPrefix(got, "{\n  \"PLAIN\": \"value\",\n"), got)
+
+	var decoded map[string]string
+	require.NoError(t, json.Unmarshal([]byte(got), &decoded))
+	require.Len(t, decoded, len(vars))
+	for _, v := range vars {
+		require.Equal(t, v.Value, decoded[v.Key])
+	}
+
+	require.Equal(t, "{}\n", write(t, FormatJSON, nil))
+}
+
+func TestWrite_YAML(t *testing.T) {
+	vars := append(testVars,
+		Var{Key: "MULTI", Value: "line1\nline2\n"},
+		Var{Key: "NUMBER", Value: "0123"},
+		Var{Key: "on", Value: "null"},
+	)
+	got := write(t, FormatYAML, vars)
+	require.Contains(t, got, "PLAIN: value\n")
+
+	var decoded map[string]any
+	require.NoError(t, yaml.Unmarshal([]byte(got), &decoded))
+	require.Len(t, decoded, len(vars))
+	for _, v := range vars {
+		require.Equal(t, v.Value, decoded[v.Key], v.Key)
+	}
+
+	require.Equal(t, "{}\n", write(t, FormatYAML, nil))
+}
+
+func TestWrite_Shell(t *testing.T) {
+	got := write(t, FormatShell, []Var{
+		{Key: "A", Value: "it's $HOME"},
+		{Key: "B", Value: "line1\nline2"},
+	})
+	require.Equal(t, "export A='it'\\''s $HOME'\nexport B='line1\nline2'\n", got)
+
+	err := Write(&bytes.Buffer{}, FormatShell, []Var{{Key: "A.B", Value: "x"}})
+	require.ErrorIs(t, err, domain.ErrInvalidArgs)
+}
+
+func TestWrite_DockerEnv(t *testing.T) {
+	got := write(t, FormatDockerEnv, []Var{
+		{Key: "A", Value: `"kept" as is`},
+		{Key: "app.name", Value: ""},
+	})
+	require.Equal(t, "A=\"kept\" as is\napp.name=\n", got)
+
+	err := Write(&bytes.Buffer{}, FormatDockerEnv, []Var{{Key: "A", Value: "line1\nline2"}})
+	require.ErrorIs(t, err, domain.ErrInvalidArgs)
+}
+
+func TestWrite_Systemd(t *testing.T) {
+	got := write(t, FormatSystemd, []Var{
+		{Key: "A", Value: `say "hi" to $USER from \ and ` + "`x`"},
+		{Key: "B", Value: "line1\nline2"},
+	})
+	require.Equal(t, "A=\"say \\\"hi\\\" to \\$USER from \\\\ and \\`x\\`\"\nB=\"line1\nline2\"\n", got)
+
+	err := Write(&bytes.Buffer{}, FormatSystemd, []Var{{Key: "app-name", Value: "x"}})
+	require.ErrorIs(t, err, domain.ErrInvalidArgs)
+}
+
+func TestWrite_Properties(t *testing.T) {
+	got := write(t, FormatProperties, []Var{
+		{Key: "db.url", Value: "jdbc:pg://host/db?a=b"},
+		{Key: "key with space", Value: " leading and trailing "},
+		{Key: "multi", Value: "line1\nline2\tend\\"},
+		{Key: "unicode", Value: "café 😀"},
+		{Key: "comment", Value: "#!"},
+	})
+	require.Equal(t, `db.url=jdbc\:pg\://host/db?a\=b`+"\n"+
+		`key\ with\ space=\ leading and trailing `+"\n"+
+		`multi=line1\nline2\tend\\`+"\n"+
+		`unicode=caf\u00E9 \uD83D\uDE00`+"\n"+
+		`comment=\#\!`+"\n", got)
+}
+
+func TestWrite_UnknownFormat(t *testing.T) {
+	err := Write(&bytes.Buffer{}, "xml", testVars)
+	require.ErrorIs(t, err, domain.ErrInvalidArgs)
+}
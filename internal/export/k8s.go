@@ -0,0 +1,161 @@
+package export
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/charliek/envsecrets/internal/domain"
+	"gopkg.in/yaml.v3"
+)
+
+// Kubernetes output formats, which take SecretOptions
+const (
+	FormatK8sSecret = "k8s-secret"
+	FormatKustomize = "kustomize"
+)
+
+// SecretFormats lists the formats written by WriteSecrets
+var SecretFormats = []string{FormatK8sSecret, FormatKustomize}
+
+// DefaultSecretType is the type of generic Kubernetes Secrets
+const DefaultSecretType = "Opaque"
+
+// SecretOptions are shared by every Secret written
+type SecretOptions struct {
+	Namespace string
+	Type      string
+	Labels    []Var
+	// Base64 writes values base64-encoded under data instead of stringData
+	Base64 bool
+}
+
+// Secret is one Kubernetes Secret to generate
+type Secret struct {
+	Name string
+	Vars []Var
+}
+
+// WriteSecrets writes secrets as v1 Secret manifests (k8s-secret), or as
+// the secretGenerator list of a kustomization.yaml (kustomize)
+func WriteSecrets(w io.Writer, format string, opts SecretOptions, secrets []Secret) error {
+	if opts.Type == "" {
+		opts.Type = DefaultSecretType
+	}
+	var docs []*yaml.Node
+	switch format {
+	case FormatK8sSecret:
+		for _, s := range secrets {
+			docs = append(docs, secretManifest(opts, s))
+		}
+	case FormatKustomize:
+		generators := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, s := range secrets {
+			generators.Content = append(generators.Content, secretGenerator(opts, s))
+		}
+		doc := &yaml.Node{Kind: yaml.MappingNode}
+		addPair(doc, "secretGenerator", generators)
+		docs = append(docs, doc)
+	default:
+		return domain.Errorf(domain.ErrInvalidArgs, "unknown secret format %q (supported: %s)", format, strings.Join(SecretFormats, ", "))
+	}
+
+	var b bytes.Buffer
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	for _, doc := range docs {
+		if err := enc.Encode(doc); err != nil {
+			return fmt.Errorf("failed to encode YAML: %w", err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// secretManifest builds a v1 Secret
+func secretManifest(opts SecretOptions, s Secret) *yaml.Node {
+	metadata := &yaml.Node{Kind: yaml.MappingNode}
+	addPair(metadata, "name", str(s.Name))
+	if opts.Namespace != "" {
+		addPair(metadata, "namespace", str(opts.Namespace))
+	}
+	if len(opts.Labels) > 0 {
+		addPair(metadata, "labels", varsMapping(opts.Labels))
+	}
+
+	doc := &yaml.Node{Kind: yaml.MappingNode}
+	addPair(doc, "apiVersion", str("v1"))
+	addPair(doc, "kind", str("Secret"))
+	addPair(doc, "metadata", metadata)
+	addPair(doc, "type", str(opts.Type))
+	if len(s.Vars) == 0 {
+		return doc
+	}
+	if opts.Base64 {
+		encoded := make([]Var, len(s.Vars))
+		for i, v := range s.Vars {
+			encoded[i] = Var{Key: v.Key, Value: base64.StdEncoding.EncodeToString([]byte(v.Value))}
+		}
+		addPair(doc, "data", varsMapping(encoded))
+	} else {
+		addPair(doc, "stringData", varsMapping(s.Vars))
+	}
+	return doc
+}
+
+// secretGenerator builds a kustomize secretGenerator entry with the values
+// as literals. Kustomize generates base64 data itself, so Base64 is unused.
+func secretGenerator(opts SecretOptions, s Secret) *yaml.Node {
+	gen := &yaml.Node{Kind: yaml.MappingNode}
+	addPair(gen, "name", str(s.Name))
+	if opts.Namespace != "" {
+		addPair(gen, "namespace", str(opts.Namespace))
+	}
+	addPair(gen, "type", str(opts.Type))
+	if len(opts.Labels) > 0 {
+		options := &yaml.Node{Kind: yaml.MappingNode}
+		addPair(options, "labels", varsMapping(opts.Labels))
+		addPair(gen, "options", options)
+	}
+	if len(s.Vars) > 0 {
+		literals := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, v := range s.Vars {
+			literals.Content = append(literals.Content, str(v.Key+"="+kustomizeLiteral(v.Value)))
+		}
+		addPair(gen, "literals", literals)
+	}
+	return gen
+}
+
+// kustomizeLiteral protects a literal value from kustomize, which strips
+// one pair of matching quotes around it
+func kustomizeLiteral(value string) string {
+	if len(value) >= 2 && value[0] == value[len(value)-1] && strings.ContainsRune(`"'`, rune(value[0])) {
+		return `"` + value + `"`
+	}
+	return value
+}
+
+// addPair appends key and value to a YAML mapping
+func addPair(m *yaml.Node, key string, value *yaml.Node) {
+	m.Content = append(m.Content, str(key), value)
+}
+
+// varsMapping builds a YAML mapping of strings from vars
+func varsMapping(vars []Var) *yaml.Node {
+	m := &yaml.Node{Kind: yaml.MappingNode}
+	for _, v := range vars {
+		m.Content = append(m.Content, str(v.Key), str(v.Value))
+	}
+	return m
+}
+
+// str builds a YAML string, quoted where it would read as another type
+func str(s string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: s}
+}
@@ -0,0 +1,91 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/charliek/envsecrets/internal/domain"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteSecrets_Manifest(t *testing.T) {
+	opts := SecretOptions{
+		Namespace: "prod",
+		Labels:    []Var{{Key: "app", Value: "web"}},
+	}
+	secrets := []Secret{
+		{Name: "web", Vars: []Var{{Key: "API_KEY", Value: "abc"}, {Key: "PORT", Value: "8080"}}},
+		{Name: "empty"},
+	}
+
+	var b bytes.Buffer
+	require.NoError(t, WriteSecrets(&b, FormatK8sSecret, opts, secrets))
+	require.Equal(t, `apiVersion: v1
+kind: Secret
+metadata:
+  name: web
+  namespace: prod
+  labels:
+    app: web
+type: Opaque
+stringData:
+  API_KEY: abc
+  PORT: "8080"
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: empty
+  namespace: prod
+  labels:
+    app: web
+type: Opaque
+`, b.String())
+
+	b.Reset()
+	opts = SecretOptions{Type: "kubernetes.io/tls", Base64: true}
+	require.NoError(t, WriteSecrets(&b, FormatK8sSecret, opts, secrets[:1]))
+	require.Equal(t, `apiVersion: v1
+kind: Secret
+metadata:
+  name: web
+type: kubernetes.io/tls
+data:
+  API_KEY: YWJj
+  PORT: ODA4MA==
+`, b.String())
+}
+
+func TestWriteSecrets_Kustomize(t *testing.T) {
+	opts := SecretOptions{
+		Namespace: "prod",
+		Labels:    []Var{{Key: "app", Value: "web"}},
+	}
+	secrets := []Secret{{Name: "web", Vars: []Var{
+		{Key: "API_KEY", Value: "abc"},
+		{Key: "QUOTED", Value: `"kept"`},
+		{Key: "CERT", Value: "line1\nline2"},
+	}}}
+
+	var b bytes.Buffer
+	require.NoError(t, WriteSecrets(&b, FormatKustomize, opts, secrets))
+	require.Equal(t, `secretGenerator:
+  - name: web
+    namespace: prod
+    type: Opaque
+    options:
+      labels:
+        app: web
+    literals:
+      - API_KEY=abc
+      - QUOTED=""kept""
+      - |-
+        CERT=line1
+        line2
+`, b.String())
+}
+
+func TestWriteSecrets_UnknownFormat(t *testing.T) {
+	err := WriteSecrets(&bytes.Buffer{}, FormatJSON, SecretOptions{}, nil)
+	require.ErrorIs(t, err, domain.ErrInvalidArgs)
+}
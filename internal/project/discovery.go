@@ -9,12 +9,20 @@ import (
 	"github.com/charliek/envsecrets/internal/domain"
 	limitedio "github.com/charliek/envsecrets/internal/io"
 	"github.com/charliek/envsecrets/internal/pathutil"
-	"github.com/go-git/go-git/v5"
 )
 
-// Discovery handles project discovery operations
+// Discovery handles project discovery operations. In a monorepo the
+// project root is the directory of the nearest .envsecrets (a namespace),
+// which may be below the git root. Outside git, the nearest .envsecrets
+// defines the project and acts as its own root.
 type Discovery struct {
 	projectRoot string
+	gitRoot     string
+	// noGit is set for a project outside any git repository; gitRoot is
+	// then the project root
+	noGit bool
+	// remotes is the user's remote preference (config "remotes")
+	remotes []string
 }
 
 // NewDiscovery creates a new project discovery starting from the given path
@@ -28,14 +36,36 @@ func NewDiscovery(startPath string) (*Discovery, error) {
 	}
 
 	root, err := findGitRoot(startPath)
+	if err != nil {
+		if !errors.Is(err, domain.ErrNotInRepo) {
+			return nil, err
+		}
+		projectRoot, ok := findEnvSecretsRoot(startPath)
+		if !ok {
+			return nil, err
+		}
+		// Without git only the repo: directive identifies the project
+		path := filepath.Join(projectRoot, constants.EnvSecretsFile)
+		config, err := readEnvSecretsConfig(path)
+		if err != nil {
+			return nil, err
+		}
+		if config.RepoOverride == "" {
+			return nil, domain.Errorf(domain.ErrNotInRepo, "not in a git repository and %s has no repo: directive; add a \"repo: owner/name\" line", path)
+		}
+		return &Discovery{projectRoot: projectRoot, gitRoot: projectRoot, noGit: true}, nil
+	}
+
+	projectRoot, err := findNamespaceRoot(startPath, root)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Discovery{projectRoot: root}, nil
+	return &Discovery{projectRoot: projectRoot, gitRoot: root}, nil
 }
 
-// findGitRoot walks up the directory tree to find the git root
+// findGitRoot walks up the directory tree to find the root of the git
+// working tree: the main checkout, a linked worktree, or a submodule
 func findGitRoot(startPath string) (string, error) {
 	path, err := filepath.Abs(startPath)
 	if err != nil {
@@ -43,7 +73,7 @@ func findGitRoot(startPath string) (string, error) {
 	}
 
 	for {
-		if _, err := os.Stat(filepath.Join(path, ".git")); err == nil {
+		if isGitWorkTree(path) {
 			return path, nil
 		}
 
@@ -55,65 +85,170 @@ func findGitRoot(startPath string) (string, error) {
 	}
 }
 
+// findEnvSecretsRoot walks up from startPath and returns the first directory
+// with a .envsecrets file
+func findEnvSecretsRoot(startPath string) (string, bool) {
+	dir, err := filepath.Abs(startPath)
+	if err != nil {
+		return "", false
+	}
+	for {
+		if hasEnvSecretsFile(dir) {
+			return dir, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// IsGitRepo reports whether the project is in a git repository. Projects
+// outside git are identified by their repo: directive, and the git safety
+// checks and hooks do not apply to them.
+func (d *Discovery) IsGitRepo() bool {
+	return !d.noGit
+}
+
 // ProjectRoot returns the project root directory
 func (d *Discovery) ProjectRoot() string {
 	return d.projectRoot
 }
 
-// RepoInfo returns the repository information
+// GitRoot returns the root of the git repository containing the project
+func (d *Discovery) GitRoot() string {
+	return d.gitRoot
+}
+
+// RepoInfo returns the repository information. A nested namespace without
+// its own repo: directive is the git root's identity plus its path, e.g.
+// owner/name/services/api. With an "identity: host" directive (in the
+// namespace's .envsecrets, else the root's) the git remote's host is
+// prepended, e.g. github.com/owner/name. The remote is chosen as described
+// for selectRemote.
 func (d *Discovery) RepoInfo() (*domain.RepoInfo, error) {
-	// First check .envsecrets for repo: directive
-	envConfig, err := ParseEnvSecretsFile(d.EnvSecretsFile())
+	return d.repoInfo(nil)
+}
+
+// RepoInfoWithHost returns the repository information with host
+// qualification forced on or off, ignoring identity: directives
+func (d *Discovery) RepoInfoWithHost(qualify bool) (*domain.RepoInfo, error) {
+	return d.repoInfo(&qualify)
+}
+
+func (d *Discovery) repoInfo(qualify *bool) (*domain.RepoInfo, error) {
+	config, rootConfig, err := d.envSecretsConfigs()
 	if err != nil {
-		// If file doesn't exist, fall back to git detection
-		// For any other error (parse error, invalid config), surface it
-		if !errors.Is(err, domain.ErrNoEnvFiles) && !os.IsNotExist(err) {
+		return nil, err
+	}
+	remotes := d.remotePreference(config, rootConfig)
+
+	var info *domain.RepoInfo
+	switch {
+	case config.RepoOverride != "":
+		info, err = ParseRepoString(config.RepoOverride)
+	case d.IsNested() && rootConfig.RepoOverride != "":
+		if info, err = ParseRepoString(rootConfig.RepoOverride); err == nil {
+			info, err = withNamespace(info, d.Namespace())
+		}
+	default:
+		var remoteURL string
+		if _, remoteURL, _, err = d.selectRemote(remotes); err != nil {
 			return nil, err
 		}
-	} else if envConfig.RepoOverride != "" {
-		return ParseRepoString(envConfig.RepoOverride)
+		if info, err = ParseRemoteURL(remoteURL); err != nil {
+			return nil, err
+		}
+		if d.IsNested() {
+			info, err = withNamespace(info, d.Namespace())
+		}
 	}
-
-	// Fall back to git remote detection
-	repo, err := git.PlainOpen(d.projectRoot)
 	if err != nil {
-		return nil, domain.Errorf(domain.ErrGitError, "failed to open repository: %v", err)
+		return nil, err
 	}
 
-	remotes, err := repo.Remotes()
-	if err != nil {
-		return nil, domain.Errorf(domain.ErrGitError, "failed to get remotes: %v", err)
+	hostQualified := config.Identity == IdentityHost || (config.Identity == "" && rootConfig.Identity == IdentityHost)
+	if qualify != nil {
+		hostQualified = *qualify
+	}
+	// A host spelled out in repo: is used as is
+	if hostQualified && info.Host == "" {
+		_, remoteURL, _, err := d.selectRemote(remotes)
+		if err != nil {
+			return nil, domain.Errorf(domain.ErrNotInRepo, "host-qualified identity needs a git remote: %v", err)
+		}
+		if info.Host, err = RemoteHost(remoteURL); err != nil {
+			return nil, err
+		}
 	}
+	return info, nil
+}
 
-	if len(remotes) == 0 {
-		return nil, domain.Errorf(domain.ErrNotInRepo, "no remotes configured")
+// withNamespace returns the identity of a nested namespace under info
+func withNamespace(info *domain.RepoInfo, namespace string) (*domain.RepoInfo, error) {
+	nested, err := parseOwnerName(info.Owner + "/" + info.Name + "/" + namespace)
+	if err != nil {
+		return nil, err
 	}
+	nested.Host, nested.RemoteURL = info.Host, info.RemoteURL
+	return nested, nil
+}
 
-	// Prefer origin, fall back to first remote
-	var remoteURL string
-	for _, remote := range remotes {
-		if remote.Config().Name == "origin" {
-			if len(remote.Config().URLs) > 0 {
-				remoteURL = remote.Config().URLs[0]
-				break
-			}
+// SetIdentityScheme writes the identity directive that makes the project
+// use scheme (IdentityHost or IdentityOwner). A nested namespace that would
+// inherit scheme from the git root gets no directive of its own.
+func (d *Discovery) SetIdentityScheme(scheme string) error {
+	if scheme != IdentityHost && scheme != IdentityOwner {
+		return domain.Errorf(domain.ErrInvalidArgs, "invalid identity %q (expected %s or %s)", scheme, IdentityHost, IdentityOwner)
+	}
+	inherited := IdentityOwner
+	if d.IsNested() {
+		rootConfig, err := readEnvSecretsConfig(filepath.Join(d.gitRoot, constants.EnvSecretsFile))
+		if err != nil {
+			return err
+		}
+		if rootConfig.Identity == IdentityHost {
+			inherited = IdentityHost
 		}
 	}
-
-	if remoteURL == "" && len(remotes) > 0 && len(remotes[0].Config().URLs) > 0 {
-		remoteURL = remotes[0].Config().URLs[0]
+	directive := scheme
+	if scheme == inherited {
+		directive = ""
 	}
+	return SetIdentity(d.EnvSecretsFile(), directive)
+}
 
-	if remoteURL == "" {
-		return nil, domain.Errorf(domain.ErrNotInRepo, "no remote URL found")
+// envSecretsConfigs returns the project's .envsecrets config and the git
+// root's, which are the same for a project at the git root
+func (d *Discovery) envSecretsConfigs() (config, rootConfig *domain.EnvSecretsConfig, err error) {
+	config, err = readEnvSecretsConfig(d.EnvSecretsFile())
+	if err != nil {
+		return nil, nil, err
 	}
-
-	info, err := ParseRemoteURL(remoteURL)
+	if !d.IsNested() {
+		return config, config, nil
+	}
+	rootConfig, err = readEnvSecretsConfig(filepath.Join(d.gitRoot, constants.EnvSecretsFile))
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
+	return config, rootConfig, nil
+}
 
-	return info, nil
+// readEnvSecretsConfig parses the .envsecrets at path, returning an empty
+// config when the file is missing
+func readEnvSecretsConfig(path string) (*domain.EnvSecretsConfig, error) {
+	envConfig, err := ParseEnvSecretsFile(path)
+	if err != nil {
+		// If file doesn't exist, fall back to git detection
+		// For any other error (parse error, invalid config), surface it
+		if !errors.Is(err, domain.ErrNoEnvFiles) && !os.IsNotExist(err) {
+			return nil, err
+		}
+		return &domain.EnvSecretsConfig{}, nil
+	}
+	return envConfig, nil
 }
 
 // EnvSecretsFile returns the path to the .envsecrets file
@@ -121,8 +256,54 @@ func (d *Discovery) EnvSecretsFile() string {
 	return filepath.Join(d.projectRoot, constants.EnvSecretsFile)
 }
 
-// EnvFiles returns the list of tracked environment files
+// EnvFiles returns the list of tracked environment files, with glob
+// patterns expanded against the working tree
 func (d *Discovery) EnvFiles() ([]string, error) {
+	return d.EnvFilesWith(nil)
+}
+
+// EnvFilesWith returns the tracked files like EnvFiles, additionally
+// matching glob patterns against candidates. Callers pass the files already
+// in the cache so a pattern-matched file deleted locally (or only present
+// remotely) is still tracked.
+func (d *Discovery) EnvFilesWith(candidates []string) ([]string, error) {
+	entries, err := d.trackedEntries()
+	if err != nil {
+		return nil, err
+	}
+	files, err := d.expandEntries(entries, candidates)
+	if err != nil {
+		return nil, err
+	}
+	if config, err := ParseEnvSecretsFile(d.EnvSecretsFile()); err == nil {
+		files = withoutExamples(config.Examples, entries, files)
+	}
+	return files, nil
+}
+
+// MatchingPattern returns the glob pattern in .envsecrets that tracks file,
+// or "" when the file is listed literally (or not tracked at all)
+func (d *Discovery) MatchingPattern(file string) string {
+	entries, err := d.trackedEntries()
+	if err != nil {
+		return ""
+	}
+	for _, e := range entries {
+		if e == file {
+			return ""
+		}
+	}
+	for _, e := range entries {
+		if IsGlobPattern(e) && MatchPattern(e, filepath.ToSlash(file)) {
+			return e
+		}
+	}
+	return ""
+}
+
+// trackedEntries returns the raw tracked entries (paths and patterns) from
+// .envsecrets, falling back to the .gitignore marker
+func (d *Discovery) trackedEntries() ([]string, error) {
 	// Try .envsecrets file first
 	envFile := d.EnvSecretsFile()
 	config, envErr := ParseEnvSecretsFile(envFile)
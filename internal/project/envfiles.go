@@ -4,11 +4,16 @@ import (
 	"bufio"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/charliek/envsecrets/internal/domain"
 )
 
+// validBucketPattern matches GCS bucket names (lowercase letters, digits,
+// dashes, underscores, and dots; must start and end with a letter or digit)
+var validBucketPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{1,220}[a-z0-9]$`)
+
 // validateEnvSecretPath validates a path from .envsecrets file
 // Returns an error if the path is unsafe (absolute, contains .., or has control characters)
 func validateEnvSecretPath(path string) error {
@@ -75,6 +80,9 @@ func ParseGitignoreMarker(path string) ([]string, error) {
 			if err := validateEnvSecretPath(trimmed); err != nil {
 				continue // Skip invalid paths in gitignore
 			}
+			if IsGlobPattern(trimmed) && validatePattern(trimmed) != nil {
+				continue
+			}
 			files = append(files, trimmed)
 		}
 	}
@@ -124,10 +132,74 @@ func ParseEnvSecretsFile(path string) (*domain.EnvSecretsConfig, error) {
 			continue
 		}
 
-		// Validate path for security
+		// Check for profile: directive
+		if strings.HasPrefix(line, "profile:") {
+			profile := strings.TrimSpace(strings.TrimPrefix(line, "profile:"))
+			if !validOwnerPattern.MatchString(profile) {
+				return nil, domain.Errorf(domain.ErrInvalidArgs, "invalid profile directive at line %d: %q", lineNum, profile)
+			}
+			config.Profile = profile
+			continue
+		}
+
+		// Check for bucket: directive
+		if strings.HasPrefix(line, "bucket:") {
+			bucket := strings.TrimSpace(strings.TrimPrefix(line, "bucket:"))
+			if !validBucketPattern.MatchString(bucket) {
+				return nil, domain.Errorf(domain.ErrInvalidArgs, "invalid bucket directive at line %d: %q", lineNum, bucket)
+			}
+			config.Bucket = bucket
+			continue
+		}
+
+		// Check for remote: directive (comma-separated, in order of preference)
+		if strings.HasPrefix(line, "remote:") {
+			var remotes []string
+			for _, name := range strings.Split(strings.TrimPrefix(line, "remote:"), ",") {
+				name = strings.TrimSpace(name)
+				if !validRemoteName.MatchString(name) {
+					return nil, domain.Errorf(domain.ErrInvalidArgs, "invalid remote directive at line %d: %q", lineNum, name)
+				}
+				remotes = append(remotes, name)
+			}
+			config.Remotes = remotes
+			continue
+		}
+
+		// Check for example: directive (may repeat)
+		if strings.HasPrefix(line, "example:") {
+			example := strings.TrimSpace(strings.TrimPrefix(line, "example:"))
+			if err := validateEnvSecretPath(example); err != nil {
+				return nil, domain.Errorf(domain.ErrInvalidArgs, "invalid example directive at line %d: %v", lineNum, err)
+			}
+			if IsGlobPattern(example) {
+				if err := validatePattern(example); err != nil {
+					return nil, domain.Errorf(domain.ErrInvalidArgs, "invalid example directive at line %d: %v", lineNum, err)
+				}
+			}
+			config.Examples = append(config.Examples, example)
+			continue
+		}
+
+		// Check for identity: directive
+		if strings.HasPrefix(line, "identity:") {
+			identity := strings.TrimSpace(strings.TrimPrefix(line, "identity:"))
+			if identity != IdentityHost && identity != IdentityOwner {
+				return nil, domain.Errorf(domain.ErrInvalidArgs, "invalid identity directive at line %d: %q (expected %s or %s)", lineNum, identity, IdentityHost, IdentityOwner)
+			}
+			config.Identity = identity
+			continue
+		}
+
+		// Validate path for security; patterns get the same checks
 		if err := validateEnvSecretPath(line); err != nil {
 			return nil, domain.Errorf(domain.ErrInvalidArgs, "invalid path at line %d: %v", lineNum, err)
 		}
+		if IsGlobPattern(line) {
+			if err := validatePattern(line); err != nil {
+				return nil, domain.Errorf(domain.ErrInvalidArgs, "invalid path at line %d: %v", lineNum, err)
+			}
+		}
 
 		config.Files = append(config.Files, line)
 	}
@@ -152,9 +224,22 @@ func WriteEnvSecretsFileWithConfig(path string, config *domain.EnvSecretsConfig)
 	}
 	defer f.Close()
 
-	// Write repo directive if present
-	if config.RepoOverride != "" {
-		if _, err := f.WriteString("repo: " + config.RepoOverride + "\n"); err != nil {
+	// Write directives if present
+	directives := []struct{ name, value string }{
+		{"repo", config.RepoOverride},
+		{"profile", config.Profile},
+		{"bucket", config.Bucket},
+		{"identity", config.Identity},
+		{"remote", strings.Join(config.Remotes, ", ")},
+	}
+	for _, example := range config.Examples {
+		directives = append(directives, struct{ name, value string }{"example", example})
+	}
+	for _, d := range directives {
+		if d.value == "" {
+			continue
+		}
+		if _, err := f.WriteString(d.name + ": " + d.value + "\n"); err != nil {
 			return domain.Errorf(domain.ErrGitError, "failed to write .envsecrets: %v", err)
 		}
 	}
@@ -187,6 +272,10 @@ func IsTracked(envSecretsPath, filePath string) (bool, error) {
 
 // AddToTracked adds a file to the .envsecrets file if not already tracked
 func AddToTracked(envSecretsPath, filePath string) error {
+	if err := ValidateTrackedEntry(filePath); err != nil {
+		return err
+	}
+
 	config, err := ParseEnvSecretsFile(envSecretsPath)
 	if err != nil {
 		if err == domain.ErrNoEnvFiles {
@@ -231,3 +320,18 @@ func RemoveFromTracked(envSecretsPath, filePath string) error {
 	config.Files = newFiles
 	return WriteEnvSecretsFileWithConfig(envSecretsPath, config)
 }
+
+// SetIdentity sets or, when identity is empty, removes the identity
+// directive in the .envsecrets file
+func SetIdentity(envSecretsPath, identity string) error {
+	config, err := ParseEnvSecretsFile(envSecretsPath)
+	if err != nil {
+		if err == domain.ErrNoEnvFiles {
+			config = &domain.EnvSecretsConfig{}
+		} else {
+			return err
+		}
+	}
+	config.Identity = identity
+	return WriteEnvSecretsFileWithConfig(envSecretsPath, config)
+}
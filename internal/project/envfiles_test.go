@@ -202,3 +202,81 @@ func TestWriteEnvSecretsFileWithConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestParseEnvSecretsFile_BackendDirectives(t *testing.T) {
+	tests := []struct {
+		name         string
+		content      string
+		wantProfile  string
+		wantBucket   string
+		wantIdentity string
+		wantErrMatch string
+	}{
+		{
+			name:        "profile directive",
+			content:     "profile: work\n.env\n",
+			wantProfile: "work",
+		},
+		{
+			name:       "bucket directive",
+			content:    "bucket: acme-secrets\n.env\n",
+			wantBucket: "acme-secrets",
+		},
+		{
+			name:        "both directives",
+			content:     "repo: acme/api\nprofile: work\nbucket: acme.secrets_eu\n.env\n",
+			wantProfile: "work",
+			wantBucket:  "acme.secrets_eu",
+		},
+		{
+			name:         "identity directive",
+			content:      "identity: host\n.env\n",
+			wantIdentity: "host",
+		},
+		{
+			name:         "invalid identity",
+			content:      "identity: gitlab\n",
+			wantErrMatch: "invalid identity directive",
+		},
+		{
+			name:         "invalid profile",
+			content:      "profile: ../evil\n",
+			wantErrMatch: "invalid profile directive",
+		},
+		{
+			name:         "invalid bucket",
+			content:      "bucket: UPPER/case\n",
+			wantErrMatch: "invalid bucket directive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), ".envsecrets")
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0644))
+
+			config, err := ParseEnvSecretsFile(path)
+			if tt.wantErrMatch != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.wantErrMatch)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantProfile, config.Profile)
+			require.Equal(t, tt.wantBucket, config.Bucket)
+			require.Equal(t, tt.wantIdentity, config.Identity)
+			require.Equal(t, []string{".env"}, config.Files)
+		})
+	}
+}
+
+func TestAddToTracked_PreservesDirectives(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".envsecrets")
+	require.NoError(t, os.WriteFile(path, []byte("repo: acme/api\nprofile: work\nbucket: acme-secrets\n.env\n"), 0644))
+
+	require.NoError(t, AddToTracked(path, ".env.local"))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "repo: acme/api\nprofile: work\nbucket: acme-secrets\n.env\n.env.local\n", string(content))
+}
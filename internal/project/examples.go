@@ -0,0 +1,231 @@
+package project
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charliek/envsecrets/internal/domain"
+	"github.com/charliek/envsecrets/internal/dotenv"
+)
+
+// ExampleSuffix is appended to a tracked file's path to name its example
+const ExampleSuffix = ".example"
+
+// exampleDefaultPrefix marks a comment declaring the value a key gets in
+// the example file, e.g. "# example: 8080" on the line above PORT=8080
+const exampleDefaultPrefix = "example:"
+
+// StaleExample describes an example file whose keys no longer match its
+// tracked file
+type StaleExample struct {
+	// File is the tracked env file
+	File string `json:"file"`
+	// Example is the example file path
+	Example string `json:"example"`
+	// Missing lists keys in the tracked file but not the example; every key
+	// when the example does not exist
+	Missing []string `json:"missing,omitempty"`
+	// Extra lists keys in the example but no longer in the tracked file
+	Extra []string `json:"extra,omitempty"`
+}
+
+// ExamplePath returns the example file path for a tracked file
+func ExamplePath(file string) string {
+	return file + ExampleSuffix
+}
+
+// GenerateExample returns env file content with every value blanked, or
+// replaced by the value declared in a "# example: <value>" comment on the
+// line above. Comments, blank lines, ordering, quoting, and "export "
+// prefixes are kept.
+func GenerateExample(content []byte) ([]byte, error) {
+	f, err := dotenv.Parse(content)
+	if err != nil {
+		return nil, err
+	}
+	var declared *string
+	for _, e := range f.Entries {
+		switch e.Kind {
+		case dotenv.Assignment:
+			value := ""
+			if declared != nil {
+				value = *declared
+			}
+			e.SetValue(value)
+			declared = nil
+		case dotenv.Comment:
+			declared = nil
+			if rest, ok := strings.CutPrefix(e.Comment, exampleDefaultPrefix); ok {
+				value := strings.TrimSpace(rest)
+				declared = &value
+			}
+		default:
+			declared = nil
+		}
+	}
+	return f.Bytes(), nil
+}
+
+// readEnvKeys returns the keys assigned in a project file, in order
+func (d *Discovery) readEnvKeys(path string) ([]string, error) {
+	content, err := d.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := dotenv.Parse(content)
+	if err != nil {
+		return nil, domain.Errorf(domain.ErrInvalidArgs, "failed to parse %s: %v", path, err)
+	}
+	return f.Keys(), nil
+}
+
+// ExampleFiles returns the tracked files whose example is maintained, as
+// selected by "example:" directives in .envsecrets
+func (d *Discovery) ExampleFiles(files []string) ([]string, error) {
+	config, err := ParseEnvSecretsFile(d.EnvSecretsFile())
+	if err != nil {
+		if errors.Is(err, domain.ErrNoEnvFiles) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return selectExampleFiles(config.Examples, files), nil
+}
+
+// selectExampleFiles returns the files matching any example pattern
+func selectExampleFiles(patterns, files []string) []string {
+	var selected []string
+	for _, f := range files {
+		slashed := filepath.ToSlash(f)
+		for _, p := range patterns {
+			if p == slashed || (IsGlobPattern(p) && MatchPattern(p, slashed)) {
+				selected = append(selected, f)
+				break
+			}
+		}
+	}
+	return selected
+}
+
+// UpdateExamples regenerates the examples of the tracked files that have
+// one and exist in the working tree. It returns the example paths whose
+// content changed; with dryRun nothing is written.
+func (d *Discovery) UpdateExamples(files []string, dryRun bool) ([]string, error) {
+	selected, err := d.ExampleFiles(files)
+	if err != nil {
+		return nil, err
+	}
+
+	var updated []string
+	for _, f := range selected {
+		content, err := d.ReadFile(f)
+		if err != nil {
+			if errors.Is(err, domain.ErrFileNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		example, err := GenerateExample(content)
+		if err != nil {
+			return nil, domain.Errorf(domain.ErrInvalidArgs, "failed to parse %s: %v", f, err)
+		}
+		examplePath := ExamplePath(f)
+		if existing, err := d.ReadFile(examplePath); err == nil && bytes.Equal(existing, example) {
+			continue
+		}
+		if !dryRun {
+			fullPath, err := d.secureJoinPath(examplePath)
+			if err != nil {
+				return nil, err
+			}
+			// Examples are committed and hold no secrets
+			if err := os.WriteFile(fullPath, example, 0644); err != nil {
+				return nil, domain.Errorf(domain.ErrGitError, "failed to write %s: %v", examplePath, err)
+			}
+		}
+		updated = append(updated, examplePath)
+	}
+	return updated, nil
+}
+
+// StaleExamples reports the maintained examples whose key set differs from
+// their tracked file, including examples not generated yet. Tracked files
+// missing from the working tree are skipped.
+func (d *Discovery) StaleExamples(files []string) ([]StaleExample, error) {
+	selected, err := d.ExampleFiles(files)
+	if err != nil {
+		return nil, err
+	}
+
+	var stale []StaleExample
+	for _, f := range selected {
+		keys, err := d.readEnvKeys(f)
+		if err != nil {
+			if errors.Is(err, domain.ErrFileNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		examplePath := ExamplePath(f)
+		exampleKeys, err := d.readEnvKeys(examplePath)
+		if err != nil && !errors.Is(err, domain.ErrFileNotFound) {
+			return nil, err
+		}
+
+		missing := keysNotIn(keys, exampleKeys)
+		extra := keysNotIn(exampleKeys, keys)
+		if len(missing) > 0 || len(extra) > 0 {
+			stale = append(stale, StaleExample{File: f, Example: examplePath, Missing: missing, Extra: extra})
+		}
+	}
+	return stale, nil
+}
+
+// keysNotIn returns the sorted keys of a that are not in b
+func keysNotIn(a, b []string) []string {
+	inB := make(map[string]bool, len(b))
+	for _, k := range b {
+		inB[k] = true
+	}
+	seen := make(map[string]bool)
+	var out []string
+	for _, k := range a {
+		if !inB[k] && !seen[k] {
+			seen[k] = true
+			out = append(out, k)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// withoutExamples drops the maintained examples of tracked files from the
+// files a glob pattern matched, so ".env*" does not encrypt .env.example.
+// Examples listed literally in .envsecrets are kept.
+func withoutExamples(patterns, entries, files []string) []string {
+	if len(patterns) == 0 {
+		return files
+	}
+	literal := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		literal[e] = true
+	}
+	maintained := make(map[string]bool)
+	for _, f := range selectExampleFiles(patterns, files) {
+		maintained[ExamplePath(filepath.ToSlash(f))] = true
+	}
+
+	kept := files[:0:0]
+	for _, f := range files {
+		slashed := filepath.ToSlash(f)
+		if maintained[slashed] && !literal[slashed] {
+			continue
+		}
+		kept = append(kept, f)
+	}
+	return kept
+}
@@ -0,0 +1,113 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateExample(t *testing.T) {
+	content := `# Database
+DATABASE_URL=postgres://user:secret@db/app
+export API_KEY="abc123"
+
+# example: 8080
+PORT=3000
+CERT="-----BEGIN-----
+MIIB
+-----END-----"
+  DEBUG =true
+`
+	want := `# Database
+DATABASE_URL=
+export API_KEY=""
+
+# example: 8080
+PORT=8080
+CERT=""
+  DEBUG =
+`
+	example, err := GenerateExample([]byte(content))
+	require.NoError(t, err)
+	require.Equal(t, want, string(example))
+
+	_, err = GenerateExample([]byte("NOT AN ASSIGNMENT\n"))
+	require.Error(t, err)
+}
+
+func TestGenerateExample_DefaultOnlyAppliesToNextKey(t *testing.T) {
+	example, err := GenerateExample([]byte("# example: on\n\nFLAG=off\nOTHER=x\n"))
+	require.NoError(t, err)
+	require.Equal(t, "# example: on\n\nFLAG=\nOTHER=\n", string(example))
+}
+
+func TestParseEnvSecretsFile_ExampleDirective(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".envsecrets")
+	require.NoError(t, os.WriteFile(path, []byte("example: .env\nexample: services/*/.env\n.env\nservices/*/.env\n"), 0644))
+
+	config, err := ParseEnvSecretsFile(path)
+	require.NoError(t, err)
+	require.Equal(t, []string{".env", "services/*/.env"}, config.Examples)
+	require.Equal(t, []string{".env", "services/*/.env"}, config.Files)
+
+	require.NoError(t, WriteEnvSecretsFileWithConfig(path, config))
+	roundTrip, err := ParseEnvSecretsFile(path)
+	require.NoError(t, err)
+	require.Equal(t, config, roundTrip)
+
+	require.NoError(t, os.WriteFile(path, []byte("example: ../.env\n"), 0644))
+	_, err = ParseEnvSecretsFile(path)
+	require.ErrorContains(t, err, "invalid example directive")
+}
+
+func TestDiscovery_Examples(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".envsecrets"), []byte("repo: acme/api\nexample: .env\n.env\n.env.*\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".env"), []byte("A=1\nB=2\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".env.local"), []byte("C=3\n"), 0600))
+
+	d, err := NewDiscovery(root)
+	require.NoError(t, err)
+	files, err := d.EnvFiles()
+	require.NoError(t, err)
+	require.Equal(t, []string{".env", ".env.local"}, files)
+
+	stale, err := d.StaleExamples(files)
+	require.NoError(t, err)
+	require.Equal(t, []StaleExample{{File: ".env", Example: ".env.example", Missing: []string{"A", "B"}}}, stale)
+
+	updated, err := d.UpdateExamples(files, true)
+	require.NoError(t, err)
+	require.Equal(t, []string{".env.example"}, updated)
+	require.False(t, d.FileExists(".env.example"))
+
+	updated, err = d.UpdateExamples(files, false)
+	require.NoError(t, err)
+	require.Equal(t, []string{".env.example"}, updated)
+	data, err := os.ReadFile(filepath.Join(root, ".env.example"))
+	require.NoError(t, err)
+	require.Equal(t, "A=\nB=\n", string(data))
+
+	// The generated example is not picked up by the .env.* pattern
+	files, err = d.EnvFiles()
+	require.NoError(t, err)
+	require.Equal(t, []string{".env", ".env.local"}, files)
+
+	// Unchanged content is not rewritten
+	updated, err = d.UpdateExamples(files, false)
+	require.NoError(t, err)
+	require.Empty(t, updated)
+
+	// Changing a value keeps the example current; changing keys does not
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".env"), []byte("A=9\nB=2\n"), 0600))
+	stale, err = d.StaleExamples(files)
+	require.NoError(t, err)
+	require.Empty(t, stale)
+
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".env"), []byte("A=9\nD=4\n"), 0600))
+	stale, err = d.StaleExamples(files)
+	require.NoError(t, err)
+	require.Equal(t, []StaleExample{{File: ".env", Example: ".env.example", Missing: []string{"D"}, Extra: []string{"B"}}}, stale)
+}
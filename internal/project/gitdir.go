@@ -0,0 +1,109 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/charliek/envsecrets/internal/domain"
+	"github.com/go-git/go-git/v5"
+)
+
+// unsafeWorktreeChars are replaced in worktree names used as file name suffixes
+var unsafeWorktreeChars = regexp.MustCompile(`[^a-zA-Z0-9._-]`)
+
+// openRepo opens the git repository whose working tree is root. Linked
+// worktrees read their config and objects from the main repository's git
+// dir, so every worktree shares the repository's remotes and identity.
+func openRepo(root string) (*git.Repository, error) {
+	return git.PlainOpenWithOptions(root, &git.PlainOpenOptions{EnableDotGitCommonDir: true})
+}
+
+// isGitWorkTree reports whether dir has a .git directory, or a .git file
+// pointing at one as linked worktrees and submodules do
+func isGitWorkTree(dir string) bool {
+	dotGit := filepath.Join(dir, ".git")
+	info, err := os.Stat(dotGit)
+	if err != nil {
+		return false
+	}
+	if info.IsDir() {
+		return true
+	}
+	gitDir, err := readGitFile(dir)
+	if err != nil {
+		return false
+	}
+	info, err = os.Stat(gitDir)
+	return err == nil && info.IsDir()
+}
+
+// readGitFile returns the git dir named by the .git file in root
+func readGitFile(root string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(root, ".git"))
+	if err != nil {
+		return "", domain.Errorf(domain.ErrGitError, "failed to read .git file: %v", err)
+	}
+	gitDir, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir:")
+	if !ok {
+		return "", domain.Errorf(domain.ErrGitError, "invalid .git file in %s", root)
+	}
+	gitDir = strings.TrimSpace(gitDir)
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(root, gitDir)
+	}
+	return filepath.Clean(gitDir), nil
+}
+
+// gitDirs resolves the git dir of the working tree at root and the common
+// dir shared by all its worktrees. They are the same except in linked
+// worktrees, whose git dir has a commondir file pointing at the main one.
+func gitDirs(root string) (gitDir, commonDir string, err error) {
+	dotGit := filepath.Join(root, ".git")
+	info, err := os.Stat(dotGit)
+	if err != nil {
+		return "", "", domain.Errorf(domain.ErrGitError, "failed to find git directory: %v", err)
+	}
+	if info.IsDir() {
+		return dotGit, dotGit, nil
+	}
+
+	gitDir, err = readGitFile(root)
+	if err != nil {
+		return "", "", err
+	}
+	common, err := os.ReadFile(filepath.Join(gitDir, "commondir"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return gitDir, gitDir, nil
+		}
+		return "", "", domain.Errorf(domain.ErrGitError, "failed to read commondir: %v", err)
+	}
+	commonDir = strings.TrimSpace(string(common))
+	if !filepath.IsAbs(commonDir) {
+		commonDir = filepath.Join(gitDir, commonDir)
+	}
+	return gitDir, filepath.Clean(commonDir), nil
+}
+
+// commonGitDir resolves the git directory shared by all worktrees
+func commonGitDir(root string) (string, error) {
+	_, commonDir, err := gitDirs(root)
+	return commonDir, err
+}
+
+// WorktreeID returns the name of the linked git worktree containing the
+// project, or "" for the main checkout, a submodule, or a project outside
+// git. Worktrees of one repository share its identity and local cache but
+// each keeps its own sync baseline.
+func (d *Discovery) WorktreeID() string {
+	if d.noGit {
+		return ""
+	}
+	gitDir, commonDir, err := gitDirs(d.gitRoot)
+	if err != nil || gitDir == commonDir {
+		return ""
+	}
+	return unsafeWorktreeChars.ReplaceAllString(filepath.Base(gitDir), "_")
+}
@@ -0,0 +1,85 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/stretchr/testify/require"
+)
+
+// newRepoWithRemote creates a git repository at dir with an origin remote
+func newRepoWithRemote(t *testing.T, dir, url string, bare bool) {
+	t.Helper()
+	repo, err := git.PlainInit(dir, bare)
+	require.NoError(t, err)
+	_, err = repo.CreateRemote(&gitconfig.RemoteConfig{Name: "origin", URLs: []string{url}})
+	require.NoError(t, err)
+}
+
+func TestNewDiscovery_LinkedWorktree(t *testing.T) {
+	main := filepath.Join(t.TempDir(), "main")
+	newRepoWithRemote(t, main, "git@github.com:acme/api.git", false)
+
+	// Lay out a linked worktree the way `git worktree add` does
+	wt := filepath.Join(t.TempDir(), "feature-wt")
+	wtGitDir := filepath.Join(main, ".git", "worktrees", "feature")
+	require.NoError(t, os.MkdirAll(wtGitDir, 0755))
+	require.NoError(t, os.MkdirAll(wt, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(wtGitDir, "HEAD"), []byte("ref: refs/heads/feature\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(wtGitDir, "commondir"), []byte("../..\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(wtGitDir, "gitdir"), []byte(filepath.Join(wt, ".git")+"\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(wt, ".git"), []byte("gitdir: "+wtGitDir+"\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(wt, ".envsecrets"), []byte(".env\n"), 0644))
+
+	d, err := NewDiscovery(wt)
+	require.NoError(t, err)
+	require.Equal(t, wt, d.GitRoot())
+	require.Equal(t, "feature", d.WorktreeID())
+
+	info, err := d.RepoInfo()
+	require.NoError(t, err)
+	require.Equal(t, "acme/api", info.String(), "worktrees share the main checkout's identity")
+
+	hooks, err := d.HooksDir()
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(main, ".git", "hooks"), hooks)
+
+	mainDiscovery, err := NewDiscovery(main)
+	require.NoError(t, err)
+	require.Equal(t, "", mainDiscovery.WorktreeID())
+}
+
+func TestNewDiscovery_Submodule(t *testing.T) {
+	super := t.TempDir()
+	newRepoWithRemote(t, super, "git@github.com:acme/platform.git", false)
+
+	sub := filepath.Join(super, "libs", "billing")
+	subGitDir := filepath.Join(super, ".git", "modules", "billing")
+	newRepoWithRemote(t, subGitDir, "git@github.com:acme/billing.git", true)
+	require.NoError(t, os.MkdirAll(sub, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(sub, ".git"), []byte("gitdir: ../../.git/modules/billing\n"), 0644))
+
+	d, err := NewDiscovery(sub)
+	require.NoError(t, err)
+	require.Equal(t, sub, d.GitRoot())
+	require.Equal(t, "", d.WorktreeID())
+
+	info, err := d.RepoInfo()
+	require.NoError(t, err)
+	require.Equal(t, "acme/billing", info.String())
+}
+
+func TestNewDiscovery_IgnoresInvalidGitFile(t *testing.T) {
+	root := t.TempDir()
+	newRepoWithRemote(t, root, "git@github.com:acme/api.git", false)
+	sub := filepath.Join(root, "vendor")
+	require.NoError(t, os.MkdirAll(sub, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(sub, ".git"), []byte("not a gitdir\n"), 0644))
+
+	d, err := NewDiscovery(sub)
+	require.NoError(t, err)
+	require.Equal(t, root, d.GitRoot())
+}
@@ -0,0 +1,174 @@
+package project
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charliek/envsecrets/internal/domain"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+// gitignoreMarker opens the managed block of tracked files in .gitignore
+const gitignoreMarker = "# envsecrets"
+
+// ValidateTrackedEntry checks a path or glob pattern before it is written to
+// .envsecrets, applying the same rules as ParseEnvSecretsFile
+func ValidateTrackedEntry(entry string) error {
+	if err := validateEnvSecretPath(entry); err != nil {
+		return err
+	}
+	if IsGlobPattern(entry) {
+		return validatePattern(entry)
+	}
+	return nil
+}
+
+// RelPath converts a path given on the command line (relative to the
+// working directory, or absolute) to a slash-separated path relative to the
+// project root. Glob characters are passed through unchanged.
+func (d *Discovery) RelPath(p string) (string, error) {
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return "", domain.Errorf(domain.ErrInvalidArgs, "invalid path %q: %v", p, err)
+	}
+	rel, err := filepath.Rel(d.projectRoot, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", domain.Errorf(domain.ErrInvalidArgs, "%s is outside the project root %s", p, d.projectRoot)
+	}
+	if rel == "." {
+		return "", domain.Errorf(domain.ErrInvalidArgs, "%s is the project root, not a file", p)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// GitignorePath returns the path to the project's root .gitignore
+func (d *Discovery) GitignorePath() string {
+	return filepath.Join(d.projectRoot, ".gitignore")
+}
+
+// IsIgnored reports whether the project's .gitignore files ignore relPath.
+// Only .gitignore files committed with the project count: global excludes
+// and .git/info/exclude protect this machine but not teammates.
+func (d *Discovery) IsIgnored(relPath string) (bool, error) {
+	segments := strings.Split(d.gitPath(filepath.Clean(relPath)), "/")
+
+	// Only .gitignore files in the file's ancestor directories can apply,
+	// starting at the git root
+	var patterns []gitignore.Pattern
+	for depth := 0; depth < len(segments); depth++ {
+		domainPath := segments[:depth]
+		ps, err := readGitignore(filepath.Join(append([]string{d.gitRoot}, domainPath...)...), domainPath)
+		if err != nil {
+			return false, err
+		}
+		patterns = append(patterns, ps...)
+	}
+
+	return gitignore.NewMatcher(patterns).Match(segments, false), nil
+}
+
+// readGitignore parses the .gitignore in dir, scoping its patterns to domainPath
+func readGitignore(dir string, domainPath []string) ([]gitignore.Pattern, error) {
+	f, err := os.Open(filepath.Join(dir, ".gitignore"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, domain.Errorf(domain.ErrGitError, "failed to read .gitignore: %v", err)
+	}
+	defer f.Close()
+
+	var patterns []gitignore.Pattern
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") || strings.TrimSpace(line) == "" {
+			continue
+		}
+		patterns = append(patterns, gitignore.ParsePattern(line, append([]string(nil), domainPath...)))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, domain.Errorf(domain.ErrGitError, "failed to read .gitignore: %v", err)
+	}
+	return patterns, nil
+}
+
+// CommittedFiles returns the project files in the git index (committed or
+// staged) that match entry, which may be a literal path or a glob pattern
+func (d *Discovery) CommittedFiles(entry string) ([]string, error) {
+	if d.noGit {
+		return nil, nil
+	}
+	repo, err := openRepo(d.gitRoot)
+	if err != nil {
+		return nil, domain.Errorf(domain.ErrGitError, "failed to open repository: %v", err)
+	}
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, domain.Errorf(domain.ErrGitError, "failed to read git index: %v", err)
+	}
+
+	entry = filepath.ToSlash(entry)
+	var committed []string
+	for _, e := range idx.Entries {
+		name, ok := d.fromGitPath(e.Name)
+		if !ok {
+			continue
+		}
+		if name == entry || (IsGlobPattern(entry) && MatchPattern(entry, name)) {
+			committed = append(committed, name)
+		}
+	}
+	return committed, nil
+}
+
+// AddToGitignoreMarker appends entry to the managed "# envsecrets" block in
+// the .gitignore at path, creating the block (and file) if needed. It is a
+// no-op when the block already lists entry.
+func AddToGitignoreMarker(path, entry string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return domain.Errorf(domain.ErrGitError, "failed to read .gitignore: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(data) == 0 {
+		lines = nil
+	}
+
+	// Find the block and the line after its last entry
+	start, end := -1, -1
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if start < 0 {
+			if trimmed == gitignoreMarker {
+				start, end = i, i+1
+			}
+			continue
+		}
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			break
+		}
+		if trimmed == entry {
+			return nil
+		}
+		end = i + 1
+	}
+
+	if start < 0 {
+		if len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) != "" {
+			lines = append(lines, "")
+		}
+		lines = append(lines, gitignoreMarker, entry)
+	} else {
+		lines = append(lines[:end], append([]string{entry}, lines[end:]...)...)
+	}
+
+	content := strings.Join(lines, "\n") + "\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return domain.Errorf(domain.ErrGitError, "failed to write .gitignore: %v", err)
+	}
+	return nil
+}
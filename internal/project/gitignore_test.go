@@ -0,0 +1,143 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddToGitignoreMarker(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string
+		entry    string
+		expected string
+	}{
+		{
+			name:     "no gitignore",
+			entry:    ".env",
+			expected: "# envsecrets\n.env\n",
+		},
+		{
+			name:     "append new block",
+			existing: "node_modules/\n",
+			entry:    ".env",
+			expected: "node_modules/\n\n# envsecrets\n.env\n",
+		},
+		{
+			name:     "extend existing block",
+			existing: "# envsecrets\n.env\n\n# build\ndist/\n",
+			entry:    ".env.local",
+			expected: "# envsecrets\n.env\n.env.local\n\n# build\ndist/\n",
+		},
+		{
+			name:     "already listed",
+			existing: "# envsecrets\n.env\n",
+			entry:    ".env",
+			expected: "# envsecrets\n.env\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), ".gitignore")
+			if tt.existing != "" {
+				require.NoError(t, os.WriteFile(path, []byte(tt.existing), 0644))
+			}
+
+			require.NoError(t, AddToGitignoreMarker(path, tt.entry))
+
+			content, err := os.ReadFile(path)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, string(content))
+
+			// The block stays readable by the marker parser
+			files, err := ParseGitignoreMarker(path)
+			require.NoError(t, err)
+			require.Contains(t, files, tt.entry)
+		})
+	}
+}
+
+func TestDiscovery_IsIgnored(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, ".git", "info"), 0700))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "services", "api"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".gitignore"), []byte(".env\n*.secret\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "services", ".gitignore"), []byte("local.env\n"), 0644))
+	// Machine-local excludes do not protect teammates
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".git", "info", "exclude"), []byte("private.env\n"), 0644))
+
+	d, err := NewDiscovery(root)
+	require.NoError(t, err)
+
+	for path, want := range map[string]bool{
+		".env":                         true,
+		"services/api/.env":            true,
+		"config/db.secret":             true,
+		"services/api/local.env":       true,
+		"local.env":                    false,
+		"private.env":                  false,
+		"config/settings.yaml":         false,
+		"services/api/.env.production": false,
+	} {
+		ignored, err := d.IsIgnored(path)
+		require.NoError(t, err)
+		require.Equal(t, want, ignored, path)
+	}
+}
+
+func TestDiscovery_CommittedFiles(t *testing.T) {
+	root := t.TempDir()
+	repo, err := git.PlainInit(root, false)
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "services", "api"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "services", "api", ".env"), []byte("X=1"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".env"), []byte("X=1"), 0600))
+
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+	_, err = wt.Add("services/api/.env")
+	require.NoError(t, err)
+
+	d, err := NewDiscovery(root)
+	require.NoError(t, err)
+
+	committed, err := d.CommittedFiles("services/api/.env")
+	require.NoError(t, err)
+	require.Equal(t, []string{"services/api/.env"}, committed)
+
+	committed, err = d.CommittedFiles("services/*/.env")
+	require.NoError(t, err)
+	require.Equal(t, []string{"services/api/.env"}, committed)
+
+	committed, err = d.CommittedFiles(".env")
+	require.NoError(t, err)
+	require.Empty(t, committed)
+}
+
+func TestDiscovery_RelPath(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, ".git"), 0700))
+	d, err := NewDiscovery(root)
+	require.NoError(t, err)
+
+	rel, err := d.RelPath(filepath.Join(root, "services", "api", ".env"))
+	require.NoError(t, err)
+	require.Equal(t, "services/api/.env", rel)
+
+	_, err = d.RelPath(filepath.Dir(root))
+	require.Error(t, err)
+	_, err = d.RelPath(root)
+	require.Error(t, err)
+}
+
+func TestAddToTracked_RejectsInvalidPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".envsecrets")
+	require.Error(t, AddToTracked(path, "../outside.env"))
+	require.Error(t, AddToTracked(path, "/etc/passwd"))
+	require.NoError(t, AddToTracked(path, "services/*/.env"))
+}
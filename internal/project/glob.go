@@ -0,0 +1,170 @@
+package project
+
+import (
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charliek/envsecrets/internal/domain"
+)
+
+// IsGlobPattern reports whether a .envsecrets entry is a glob pattern rather
+// than a literal path
+func IsGlobPattern(entry string) bool {
+	return strings.ContainsAny(entry, "*?[")
+}
+
+// validatePattern checks that each segment of a glob pattern is well formed.
+// "**" is only allowed as a whole segment.
+func validatePattern(pattern string) error {
+	for _, seg := range strings.Split(pattern, "/") {
+		if seg == "**" {
+			continue
+		}
+		if strings.Contains(seg, "**") {
+			return domain.Errorf(domain.ErrInvalidArgs, "invalid pattern %q: ** must be a whole path segment", pattern)
+		}
+		if _, err := path.Match(seg, ""); err != nil {
+			return domain.Errorf(domain.ErrInvalidArgs, "invalid pattern %q: %v", pattern, err)
+		}
+	}
+	return nil
+}
+
+// MatchPattern reports whether a slash-separated relative path matches a
+// pattern. Patterns are anchored at the project root; "*", "?", and "[...]"
+// match within a segment and "**" matches any number of segments.
+func MatchPattern(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// Collapse repeated ** and try every possible split
+			rest := pattern[1:]
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(rest, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern = pattern[1:]
+		name = name[1:]
+	}
+	return len(name) == 0
+}
+
+// patternBase returns the literal directory prefix of a pattern, which is
+// the only part of the tree that needs to be walked
+func patternBase(pattern string) string {
+	segs := strings.Split(pattern, "/")
+	var base []string
+	for _, seg := range segs[:len(segs)-1] {
+		if IsGlobPattern(seg) {
+			break
+		}
+		base = append(base, seg)
+	}
+	return strings.Join(base, "/")
+}
+
+// expandPattern walks the working tree under the pattern's literal prefix
+// and returns matching regular files as sorted, slash-separated paths.
+// .git directories and nested namespaces are skipped and symlinked
+// directories are not followed.
+func (d *Discovery) expandPattern(pattern string) ([]string, error) {
+	base := patternBase(pattern)
+	root, err := d.secureJoinPath(base)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []string
+	err = filepath.WalkDir(root, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if p == root {
+				return fs.SkipDir // Prefix does not exist: no matches
+			}
+			return nil
+		}
+		if entry.IsDir() {
+			if entry.Name() == ".git" || d.isNamespaceBoundary(p) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(d.projectRoot, p)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if MatchPattern(pattern, rel) && validateEnvSecretPath(rel) == nil {
+			matches = append(matches, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, domain.Errorf(domain.ErrGitError, "failed to expand pattern %q: %v", pattern, err)
+	}
+
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// expandEntries resolves .envsecrets entries to file paths: literal entries
+// are kept as-is, patterns are expanded against the working tree and the
+// candidate paths (e.g. files already in the cache). The result is
+// de-duplicated, literals first in file order, then pattern matches.
+func (d *Discovery) expandEntries(entries, candidates []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var files []string
+	add := func(f string) {
+		if !seen[f] {
+			seen[f] = true
+			files = append(files, f)
+		}
+	}
+
+	var patterns []string
+	for _, e := range entries {
+		if IsGlobPattern(e) {
+			patterns = append(patterns, e)
+			continue
+		}
+		add(e)
+	}
+
+	var matched []string
+	for _, pattern := range patterns {
+		found, err := d.expandPattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+		matched = append(matched, found...)
+		for _, c := range candidates {
+			c = filepath.ToSlash(c)
+			if MatchPattern(pattern, c) && validateEnvSecretPath(c) == nil {
+				matched = append(matched, c)
+			}
+		}
+	}
+	sort.Strings(matched)
+	for _, f := range matched {
+		add(f)
+	}
+
+	return files, nil
+}
@@ -0,0 +1,113 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"services/*/.env", "services/api/.env", true},
+		{"services/*/.env", "services/api/nested/.env", false},
+		{"services/*/.env", "services/.env", false},
+		{"deploy/**/secrets.env", "deploy/secrets.env", true},
+		{"deploy/**/secrets.env", "deploy/prod/eu/secrets.env", true},
+		{"deploy/**/secrets.env", "other/prod/secrets.env", false},
+		{"**/.env", ".env", true},
+		{"**/.env", "a/b/c/.env", true},
+		{".env.*", ".env.local", true},
+		{".env.*", "sub/.env.local", false},
+		{"config/[ab].yaml", "config/a.yaml", true},
+		{"config/[ab].yaml", "config/c.yaml", false},
+		{"deploy/**", "deploy/x/y", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, MatchPattern(tt.pattern, tt.name))
+		})
+	}
+}
+
+func TestParseEnvSecretsFile_Patterns(t *testing.T) {
+	tests := []struct {
+		name         string
+		content      string
+		wantErrMatch string
+	}{
+		{name: "valid patterns", content: "services/*/.env\ndeploy/**/secrets.env\n"},
+		{name: "traversal in pattern", content: "../*/.env\n", wantErrMatch: "path traversal"},
+		{name: "absolute pattern", content: "/etc/*\n", wantErrMatch: "absolute path"},
+		{name: "partial double star", content: "deploy/a**/x\n", wantErrMatch: "whole path segment"},
+		{name: "malformed class", content: "config/[ab.yaml\n", wantErrMatch: "invalid pattern"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), ".envsecrets")
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0644))
+
+			_, err := ParseEnvSecretsFile(path)
+			if tt.wantErrMatch != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.wantErrMatch)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestDiscovery_EnvFilesExpandsPatterns(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, ".git"), 0700))
+	for _, f := range []string{
+		".env",
+		"services/api/.env",
+		"services/worker/.env",
+		"services/worker/.env.example",
+		"deploy/prod/eu/secrets.env",
+		"deploy/staging/secrets.env",
+		".git/secrets.env",
+	} {
+		full := filepath.Join(root, f)
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0700))
+		require.NoError(t, os.WriteFile(full, []byte("X=1"), 0600))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".envsecrets"),
+		[]byte(".env\nservices/*/.env\ndeploy/**/secrets.env\nmissing/*/.env\n.env\n"), 0600))
+
+	d, err := NewDiscovery(root)
+	require.NoError(t, err)
+
+	files, err := d.EnvFiles()
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		".env",
+		"deploy/prod/eu/secrets.env",
+		"deploy/staging/secrets.env",
+		"services/api/.env",
+		"services/worker/.env",
+	}, files)
+
+	// Cache candidates are unioned in for matching patterns only
+	files, err = d.EnvFilesWith([]string{
+		filepath.Join("services", "billing", ".env"),
+		"unrelated/.env",
+		"services/api/.env",
+	})
+	require.NoError(t, err)
+	require.Contains(t, files, "services/billing/.env")
+	require.NotContains(t, files, "unrelated/.env")
+	require.Len(t, files, 6)
+
+	require.Equal(t, "services/*/.env", d.MatchingPattern("services/api/.env"))
+	require.Equal(t, "", d.MatchingPattern(".env"))
+}
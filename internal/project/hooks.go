@@ -0,0 +1,190 @@
+package project
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charliek/envsecrets/internal/domain"
+	gitconfig "github.com/go-git/go-git/v5/config"
+)
+
+// Git hooks managed by envsecrets
+const (
+	HookPreCommit    = "pre-commit"
+	HookPostCheckout = "post-checkout"
+	HookPostMerge    = "post-merge"
+)
+
+// hookMarker identifies a hook script written by envsecrets
+const hookMarker = "# envsecrets-managed hook"
+
+// chainedSuffix is appended to a pre-existing hook that envsecrets replaced;
+// the managed hook runs it first
+const chainedSuffix = ".envsecrets-chained"
+
+// HookInstall describes the result of installing one hook
+type HookInstall struct {
+	// Name is the git hook name (e.g. pre-commit)
+	Name string `json:"name"`
+	// Path is the installed hook script
+	Path string `json:"path"`
+	// Chained is the path of a pre-existing hook the managed hook runs first
+	Chained string `json:"chained,omitempty"`
+	// Updated is true when an envsecrets hook was already installed
+	Updated bool `json:"updated,omitempty"`
+}
+
+// HooksDir returns the directory git runs hooks from for this project:
+// core.hooksPath when set (local or global config), otherwise the hooks
+// directory of the repository's common git dir
+func (d *Discovery) HooksDir() (string, error) {
+	if d.noGit {
+		return "", domain.Errorf(domain.ErrNotInRepo, "git hooks need a git repository")
+	}
+	repo, err := openRepo(d.gitRoot)
+	if err != nil {
+		return "", domain.Errorf(domain.ErrGitError, "failed to open repository: %v", err)
+	}
+	cfg, err := repo.ConfigScoped(gitconfig.GlobalScope)
+	if err != nil {
+		return "", domain.Errorf(domain.ErrGitError, "failed to read git config: %v", err)
+	}
+
+	if hooksPath := cfg.Raw.Section("core").Option("hooksPath"); hooksPath != "" {
+		if rest, ok := strings.CutPrefix(hooksPath, "~/"); ok {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", domain.Errorf(domain.ErrGitError, "failed to expand core.hooksPath: %v", err)
+			}
+			hooksPath = filepath.Join(home, rest)
+		}
+		// Relative paths are relative to the working tree root
+		if !filepath.IsAbs(hooksPath) {
+			hooksPath = filepath.Join(d.gitRoot, hooksPath)
+		}
+		return filepath.Clean(hooksPath), nil
+	}
+
+	gitDir, err := commonGitDir(d.gitRoot)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(gitDir, "hooks"), nil
+}
+
+// HookScript returns the shell script envsecrets installs for a hook. The
+// script runs any chained pre-existing hook first, then hands off to
+// "envsecrets hooks run". Only pre-commit can block; the post-checkout and
+// post-merge hooks are advisory and always succeed.
+func HookScript(name string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "#!/bin/sh\n%s: %s\n", hookMarker, name)
+	b.WriteString("# Installed by 'envsecrets hooks install'; remove with 'envsecrets hooks uninstall'.\n")
+	fmt.Fprintf(&b, "chained=\"$(dirname \"$0\")/%s%s\"\n", name, chainedSuffix)
+	b.WriteString("if [ -x \"$chained\" ]; then\n")
+	if name == HookPreCommit {
+		b.WriteString("  \"$chained\" \"$@\" || exit $?\n")
+	} else {
+		b.WriteString("  \"$chained\" \"$@\"\n")
+	}
+	b.WriteString("fi\n")
+	b.WriteString("command -v envsecrets >/dev/null 2>&1 || exit 0\n")
+	if name == HookPreCommit {
+		fmt.Fprintf(&b, "exec envsecrets hooks run %s \"$@\"\n", name)
+	} else {
+		fmt.Fprintf(&b, "envsecrets hooks run %s \"$@\"\n", name)
+		b.WriteString("exit 0\n")
+	}
+	return b.String()
+}
+
+// isManagedHook reports whether the hook script at path was written by envsecrets
+func isManagedHook(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	// The marker is on the second line; only look at the header
+	scanner := bufio.NewScanner(f)
+	for i := 0; i < 3 && scanner.Scan(); i++ {
+		if strings.HasPrefix(scanner.Text(), hookMarker) {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
+
+// InstallHook writes the envsecrets hook script for name into dir. A
+// pre-existing hook that envsecrets did not write is renamed to
+// <name>.envsecrets-chained and run by the new hook, so existing tooling
+// keeps working. Reinstalling rewrites the managed script in place.
+func InstallHook(dir, name string) (*HookInstall, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, domain.Errorf(domain.ErrGitError, "failed to create hooks directory: %v", err)
+	}
+
+	path := filepath.Join(dir, name)
+	chained := path + chainedSuffix
+	result := &HookInstall{Name: name, Path: path}
+
+	managed, err := isManagedHook(path)
+	switch {
+	case err == nil && managed:
+		result.Updated = true
+	case err == nil:
+		if _, err := os.Lstat(chained); err == nil {
+			return nil, domain.Errorf(domain.ErrConflict, "cannot chain %s: %s already exists", path, chained)
+		}
+		if err := os.Rename(path, chained); err != nil {
+			return nil, domain.Errorf(domain.ErrGitError, "failed to move existing hook: %v", err)
+		}
+	case !os.IsNotExist(err):
+		return nil, domain.Errorf(domain.ErrGitError, "failed to read existing hook: %v", err)
+	}
+
+	if _, err := os.Lstat(chained); err == nil {
+		result.Chained = chained
+	}
+
+	if err := os.WriteFile(path, []byte(HookScript(name)), 0755); err != nil {
+		return nil, domain.Errorf(domain.ErrGitError, "failed to write hook: %v", err)
+	}
+	// WriteFile keeps the mode of an existing file
+	if err := os.Chmod(path, 0755); err != nil {
+		return nil, domain.Errorf(domain.ErrGitError, "failed to make hook executable: %v", err)
+	}
+	return result, nil
+}
+
+// UninstallHook removes the envsecrets hook for name from dir and restores
+// the hook it chained, if any. It returns false when no envsecrets hook was
+// installed; hooks envsecrets did not write are never touched.
+func UninstallHook(dir, name string) (bool, error) {
+	path := filepath.Join(dir, name)
+	managed, err := isManagedHook(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, domain.Errorf(domain.ErrGitError, "failed to read hook: %v", err)
+	}
+	if !managed {
+		return false, nil
+	}
+
+	if err := os.Remove(path); err != nil {
+		return false, domain.Errorf(domain.ErrGitError, "failed to remove hook: %v", err)
+	}
+	chained := path + chainedSuffix
+	if _, err := os.Lstat(chained); err == nil {
+		if err := os.Rename(chained, path); err != nil {
+			return true, domain.Errorf(domain.ErrGitError, "failed to restore chained hook: %v", err)
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,105 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscovery_HooksDir(t *testing.T) {
+	t.Setenv("HOME", t.TempDir()) // Keep global git config out of the test
+
+	root := t.TempDir()
+	repo, err := git.PlainInit(root, false)
+	require.NoError(t, err)
+	d, err := NewDiscovery(root)
+	require.NoError(t, err)
+
+	dir, err := d.HooksDir()
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(root, ".git", "hooks"), dir)
+
+	cfg, err := repo.Config()
+	require.NoError(t, err)
+	cfg.Raw.Section("core").SetOption("hooksPath", ".githooks")
+	require.NoError(t, repo.SetConfig(cfg))
+
+	dir, err = d.HooksDir()
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(root, ".githooks"), dir)
+}
+
+func TestCommonGitDir_Worktree(t *testing.T) {
+	main := t.TempDir()
+	worktreeGitDir := filepath.Join(main, ".git", "worktrees", "feature")
+	require.NoError(t, os.MkdirAll(worktreeGitDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(worktreeGitDir, "commondir"), []byte("../..\n"), 0644))
+
+	wt := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(wt, ".git"), []byte("gitdir: "+worktreeGitDir+"\n"), 0644))
+
+	dir, err := commonGitDir(wt)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(main, ".git"), dir)
+}
+
+func TestInstallHook_ChainsAndRestoresExistingHook(t *testing.T) {
+	dir := t.TempDir()
+	existing := "#!/bin/sh\necho lint\n"
+	hookPath := filepath.Join(dir, HookPreCommit)
+	require.NoError(t, os.WriteFile(hookPath, []byte(existing), 0755))
+
+	result, err := InstallHook(dir, HookPreCommit)
+	require.NoError(t, err)
+	require.False(t, result.Updated)
+	require.Equal(t, hookPath+chainedSuffix, result.Chained)
+
+	data, err := os.ReadFile(hookPath)
+	require.NoError(t, err)
+	require.Equal(t, HookScript(HookPreCommit), string(data))
+	info, err := os.Stat(hookPath)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0755), info.Mode().Perm())
+
+	// Reinstalling updates in place and keeps the chain
+	result, err = InstallHook(dir, HookPreCommit)
+	require.NoError(t, err)
+	require.True(t, result.Updated)
+	require.Equal(t, hookPath+chainedSuffix, result.Chained)
+
+	removed, err := UninstallHook(dir, HookPreCommit)
+	require.NoError(t, err)
+	require.True(t, removed)
+	data, err = os.ReadFile(hookPath)
+	require.NoError(t, err)
+	require.Equal(t, existing, string(data))
+	require.NoFileExists(t, hookPath+chainedSuffix)
+}
+
+func TestUninstallHook_LeavesForeignHooks(t *testing.T) {
+	dir := t.TempDir()
+	hookPath := filepath.Join(dir, HookPostMerge)
+	require.NoError(t, os.WriteFile(hookPath, []byte("#!/bin/sh\n"), 0755))
+
+	removed, err := UninstallHook(dir, HookPostMerge)
+	require.NoError(t, err)
+	require.False(t, removed)
+	require.FileExists(t, hookPath)
+
+	removed, err = UninstallHook(dir, HookPostCheckout)
+	require.NoError(t, err)
+	require.False(t, removed)
+}
+
+func TestHookScript(t *testing.T) {
+	pre := HookScript(HookPreCommit)
+	require.Contains(t, pre, "|| exit $?")
+	require.Contains(t, pre, "exec envsecrets hooks run pre-commit")
+
+	post := HookScript(HookPostCheckout)
+	require.NotContains(t, post, "|| exit $?", "advisory hooks must not fail git")
+	require.Contains(t, post, "exit 0\n")
+}
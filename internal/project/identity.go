@@ -20,11 +20,39 @@ var (
 
 	// Valid name pattern: alphanumeric, hyphens, underscores, dots, slashes (for nested paths)
 	validNamePattern = regexp.MustCompile(`^[a-zA-Z0-9._/-]+$`)
+
+	// Valid host pattern: DNS labels separated by dots
+	validHostPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)*$`)
+)
+
+// Identity schemes for the "identity:" directive in .envsecrets
+const (
+	// IdentityOwner identifies a repository by owner/name (the default)
+	IdentityOwner = "owner"
+	// IdentityHost identifies a repository by host/owner/name
+	IdentityHost = "host"
 )
 
-// ParseRepoString parses "owner/name" format into RepoInfo
+// ParseRepoString parses "owner/name" format into RepoInfo. A leading
+// hostname ("github.com/owner/name") makes a host-qualified identity, the
+// same one identity: host derives from the remote.
 func ParseRepoString(repo string) (*domain.RepoInfo, error) {
 	repo = strings.TrimSpace(repo)
+	if first, rest, ok := strings.Cut(repo, "/"); ok && strings.Contains(first, ".") && strings.Contains(rest, "/") {
+		if host := strings.ToLower(first); validHostPattern.MatchString(host) {
+			info, err := parseOwnerName(rest)
+			if err != nil {
+				return nil, err
+			}
+			info.Host = host
+			return info, nil
+		}
+	}
+	return parseOwnerName(repo)
+}
+
+// parseOwnerName parses "owner/name" without looking for a host
+func parseOwnerName(repo string) (*domain.RepoInfo, error) {
 	parts := strings.SplitN(repo, "/", 2)
 	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
 		return nil, domain.Errorf(domain.ErrInvalidArgs, "invalid repo format: expected owner/name, got %q", repo)
@@ -88,3 +116,22 @@ func ParseRemoteURL(remoteURL string) (*domain.RepoInfo, error) {
 
 	return nil, domain.Errorf(domain.ErrNotInRepo, "failed to parse remote URL: %s", remoteURL)
 }
+
+// RemoteHost extracts the lowercased host of a git remote URL, without any
+// user or port, for host-qualified identities
+func RemoteHost(remoteURL string) (string, error) {
+	remoteURL = strings.TrimSpace(remoteURL)
+
+	var host string
+	if matches := sshPattern.FindStringSubmatch(remoteURL); matches != nil {
+		host = matches[1]
+	} else if u, err := url.Parse(remoteURL); err == nil && u.Host != "" {
+		host = u.Hostname()
+	}
+	host = strings.ToLower(host)
+
+	if host == "" || !validHostPattern.MatchString(host) {
+		return "", domain.Errorf(domain.ErrNotInRepo, "failed to parse host from remote URL: %s", remoteURL)
+	}
+	return host, nil
+}
@@ -10,6 +10,7 @@ func TestParseRepoString(t *testing.T) {
 	tests := []struct {
 		name      string
 		input     string
+		wantHost  string
 		wantOwner string
 		wantName  string
 		wantErr   bool
@@ -83,6 +84,25 @@ func TestParseRepoString(t *testing.T) {
 			wantOwner: "my-org_name.co",
 			wantName:  "my-repo_v2.0",
 		},
+		{
+			name:      "host-qualified",
+			input:     "GitHub.com/acme/myapp",
+			wantHost:  "github.com",
+			wantOwner: "acme",
+			wantName:  "myapp",
+		},
+		{
+			name:      "host-qualified with nested path",
+			input:     "gitlab.example.com/acme/myapp/subdir",
+			wantHost:  "gitlab.example.com",
+			wantOwner: "acme",
+			wantName:  "myapp/subdir",
+		},
+		{
+			name:    "host without name",
+			input:   "github.com/acme/",
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -95,6 +115,7 @@ func TestParseRepoString(t *testing.T) {
 			}
 
 			require.NoError(t, err)
+			require.Equal(t, tt.wantHost, info.Host)
 			require.Equal(t, tt.wantOwner, info.Owner)
 			require.Equal(t, tt.wantName, info.Name)
 		})
@@ -185,3 +206,29 @@ func TestParseRemoteURL(t *testing.T) {
 		})
 	}
 }
+
+func TestRemoteHost(t *testing.T) {
+	tests := []struct {
+		url     string
+		want    string
+		wantErr bool
+	}{
+		{url: "git@github.com:acme/myapp.git", want: "github.com"},
+		{url: "https://gitlab.example.com/acme/myapp.git", want: "gitlab.example.com"},
+		{url: "https://user@GitLab.Example.com:8443/acme/myapp.git", want: "gitlab.example.com"},
+		{url: "ssh://git@git.internal:2222/acme/myapp.git", want: "git.internal"},
+		{url: "not-a-valid-url", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			host, err := RemoteHost(tt.url)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, host)
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package project
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charliek/envsecrets/internal/constants"
+	"github.com/charliek/envsecrets/internal/domain"
+)
+
+// findNamespaceRoot walks up from startPath to gitRoot and returns the first
+// directory with a .envsecrets file, or gitRoot when there is none
+func findNamespaceRoot(startPath, gitRoot string) (string, error) {
+	dir, err := filepath.Abs(startPath)
+	if err != nil {
+		return "", domain.Errorf(domain.ErrNotInRepo, "invalid path: %v", err)
+	}
+
+	for dir != gitRoot {
+		if hasEnvSecretsFile(dir) {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return gitRoot, nil
+}
+
+// hasEnvSecretsFile reports whether dir contains a .envsecrets file
+func hasEnvSecretsFile(dir string) bool {
+	info, err := os.Stat(filepath.Join(dir, constants.EnvSecretsFile))
+	return err == nil && info.Mode().IsRegular()
+}
+
+// IsNested reports whether the project is a namespace below the git root
+func (d *Discovery) IsNested() bool {
+	return d.projectRoot != d.gitRoot
+}
+
+// Namespace returns the project root relative to the git root as a
+// slash-separated path, or "" for the git root itself
+func (d *Discovery) Namespace() string {
+	if !d.IsNested() {
+		return ""
+	}
+	rel, err := filepath.Rel(d.gitRoot, d.projectRoot)
+	if err != nil {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
+
+// gitPath converts a project-relative path to a path relative to the git
+// root, as used by the git index and trees
+func (d *Discovery) gitPath(rel string) string {
+	return path.Join(d.Namespace(), filepath.ToSlash(rel))
+}
+
+// fromGitPath converts a git-root-relative path to a project-relative one.
+// It returns false for paths outside the project.
+func (d *Discovery) fromGitPath(name string) (string, bool) {
+	ns := d.Namespace()
+	if ns == "" {
+		return name, true
+	}
+	rel, ok := strings.CutPrefix(name, ns+"/")
+	return rel, ok
+}
+
+// isNamespaceBoundary reports whether dir, below the project root, starts
+// another namespace and so is excluded from this project's glob patterns
+func (d *Discovery) isNamespaceBoundary(dir string) bool {
+	return dir != d.projectRoot && hasEnvSecretsFile(dir)
+}
+
+// Namespaces returns a Discovery for every namespace in the git repository:
+// the git root when it tracks files, and each directory with a .envsecrets
+// file. .git directories and nested git repositories are skipped. The
+// result is sorted by namespace path, root first. A project outside git is
+// its only namespace.
+func (d *Discovery) Namespaces() ([]*Discovery, error) {
+	if d.noGit {
+		return []*Discovery{d}, nil
+	}
+
+	var namespaces []*Discovery
+
+	root := &Discovery{projectRoot: d.gitRoot, gitRoot: d.gitRoot, remotes: d.remotes}
+	if _, err := root.trackedEntries(); err == nil {
+		namespaces = append(namespaces, root)
+	}
+
+	err := filepath.WalkDir(d.gitRoot, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if p == d.gitRoot {
+				return err
+			}
+			return nil
+		}
+		if !entry.IsDir() || p == d.gitRoot {
+			return nil
+		}
+		if entry.Name() == ".git" {
+			return fs.SkipDir
+		}
+		if _, err := os.Lstat(filepath.Join(p, ".git")); err == nil {
+			return fs.SkipDir // Nested repository or submodule
+		}
+		if hasEnvSecretsFile(p) {
+			namespaces = append(namespaces, &Discovery{projectRoot: p, gitRoot: d.gitRoot, remotes: d.remotes})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, domain.Errorf(domain.ErrGitError, "failed to find namespaces: %v", err)
+	}
+
+	sort.SliceStable(namespaces, func(i, j int) bool {
+		return namespaces[i].Namespace() < namespaces[j].Namespace()
+	})
+	return namespaces, nil
+}
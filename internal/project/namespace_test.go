@@ -0,0 +1,249 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charliek/envsecrets/internal/domain"
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/stretchr/testify/require"
+)
+
+// newMonorepo creates a git repository with a root namespace and two nested
+// namespaces, one with an explicit repo: directive
+func newMonorepo(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	_, err := git.PlainInit(root, false)
+	require.NoError(t, err)
+
+	write := func(name, content string) {
+		p := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, os.WriteFile(p, []byte(content), 0600))
+	}
+	write(".envsecrets", "repo: acme/mono\n**/.env\n")
+	write(".env", "ROOT=1")
+	write("tools/.env", "TOOLS=1")
+	write("services/api/.envsecrets", "profile: api\n.env\n")
+	write("services/api/.env", "API=1")
+	write("services/api/src/main.go", "package main")
+	write("services/web/.envsecrets", "repo: acme/web-secrets\n.env\n")
+	return root
+}
+
+func TestNewDiscovery_NestedNamespace(t *testing.T) {
+	root := newMonorepo(t)
+
+	d, err := NewDiscovery(filepath.Join(root, "services", "api", "src"))
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(root, "services", "api"), d.ProjectRoot())
+	require.Equal(t, root, d.GitRoot())
+	require.True(t, d.IsNested())
+	require.Equal(t, "services/api", d.Namespace())
+
+	info, err := d.RepoInfo()
+	require.NoError(t, err)
+	require.Equal(t, "acme/mono/services/api", info.String())
+
+	files, err := d.EnvFiles()
+	require.NoError(t, err)
+	require.Equal(t, []string{".env"}, files)
+
+	// Outside any nested namespace the git root is the project
+	d, err = NewDiscovery(filepath.Join(root, "tools"))
+	require.NoError(t, err)
+	require.False(t, d.IsNested())
+	require.Equal(t, "", d.Namespace())
+}
+
+func TestDiscovery_RootPatternsSkipNestedNamespaces(t *testing.T) {
+	root := newMonorepo(t)
+
+	d, err := NewDiscovery(root)
+	require.NoError(t, err)
+	files, err := d.EnvFiles()
+	require.NoError(t, err)
+	require.Equal(t, []string{".env", "tools/.env"}, files)
+}
+
+func TestDiscovery_ExplicitRepoInNestedNamespace(t *testing.T) {
+	root := newMonorepo(t)
+
+	d, err := NewDiscovery(filepath.Join(root, "services", "web"))
+	require.NoError(t, err)
+	info, err := d.RepoInfo()
+	require.NoError(t, err)
+	require.Equal(t, "acme/web-secrets", info.String())
+}
+
+func TestDiscovery_Namespaces(t *testing.T) {
+	root := newMonorepo(t)
+
+	// A nested git repository is not part of this repository
+	vendored := filepath.Join(root, "vendor", "lib")
+	_, err := git.PlainInit(vendored, false)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(vendored, ".envsecrets"), []byte(".env\n"), 0600))
+
+	d, err := NewDiscovery(filepath.Join(root, "services", "api"))
+	require.NoError(t, err)
+	namespaces, err := d.Namespaces()
+	require.NoError(t, err)
+
+	var names []string
+	for _, ns := range namespaces {
+		names = append(names, ns.Namespace())
+	}
+	require.Equal(t, []string{"", "services/api", "services/web"}, names)
+}
+
+func TestDiscovery_NestedGitPaths(t *testing.T) {
+	root := newMonorepo(t)
+	repo, err := git.PlainOpen(root)
+	require.NoError(t, err)
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+	_, err = wt.Add("services/api/.env")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".gitignore"), []byte("services/api/.env\n"), 0644))
+
+	d, err := NewDiscovery(filepath.Join(root, "services", "api"))
+	require.NoError(t, err)
+
+	ignored, err := d.IsIgnored(".env")
+	require.NoError(t, err)
+	require.True(t, ignored, "root .gitignore applies to nested namespaces")
+
+	committed, err := d.CommittedFiles(".env")
+	require.NoError(t, err)
+	require.Equal(t, []string{".env"}, committed)
+
+	staged, err := d.CheckStaged()
+	require.NoError(t, err)
+	require.Len(t, staged, 1)
+	require.Equal(t, "services/api/.env", staged[0].Path)
+}
+
+func TestDiscovery_HostQualifiedIdentity(t *testing.T) {
+	root := newMonorepo(t)
+	repo, err := git.PlainOpen(root)
+	require.NoError(t, err)
+	_, err = repo.CreateRemote(&gitconfig.RemoteConfig{Name: "origin", URLs: []string{"git@gitlab.example.com:acme/mono.git"}})
+	require.NoError(t, err)
+
+	d, err := NewDiscovery(filepath.Join(root, "services", "api"))
+	require.NoError(t, err)
+	info, err := d.RepoInfoWithHost(true)
+	require.NoError(t, err)
+	require.Equal(t, "gitlab.example.com/acme/mono/services/api", info.CachePath())
+
+	// The root's directive applies to nested namespaces without their own
+	require.NoError(t, SetIdentity(filepath.Join(root, ".envsecrets"), IdentityHost))
+	info, err = d.RepoInfo()
+	require.NoError(t, err)
+	require.Equal(t, "gitlab.example.com/acme/mono/services/api", info.String())
+
+	// Opting a nested namespace out needs an explicit directive
+	require.NoError(t, d.SetIdentityScheme(IdentityOwner))
+	config, err := ParseEnvSecretsFile(d.EnvSecretsFile())
+	require.NoError(t, err)
+	require.Equal(t, IdentityOwner, config.Identity)
+	require.Equal(t, "api", config.Profile)
+	info, err = d.RepoInfo()
+	require.NoError(t, err)
+	require.Equal(t, "acme/mono/services/api", info.String())
+
+	// Back to inheriting drops the directive
+	require.NoError(t, d.SetIdentityScheme(IdentityHost))
+	config, err = ParseEnvSecretsFile(d.EnvSecretsFile())
+	require.NoError(t, err)
+	require.Empty(t, config.Identity)
+}
+
+func TestDiscovery_HostQualifiedRepoDirective(t *testing.T) {
+	root := t.TempDir()
+	repo, err := git.PlainInit(root, false)
+	require.NoError(t, err)
+	_, err = repo.CreateRemote(&gitconfig.RemoteConfig{Name: "origin", URLs: []string{"git@github.com:acme/api.git"}})
+	require.NoError(t, err)
+	envSecrets := filepath.Join(root, ".envsecrets")
+
+	// identity: host derives the identity from the remote
+	require.NoError(t, os.WriteFile(envSecrets, []byte("identity: host\n.env\n"), 0644))
+	d, err := NewDiscovery(root)
+	require.NoError(t, err)
+	detected, err := d.RepoInfo()
+	require.NoError(t, err)
+
+	// repo: spells out the same identity
+	for _, content := range []string{"repo: github.com/acme/api\n.env\n", "identity: host\nrepo: github.com/acme/api\n.env\n"} {
+		require.NoError(t, os.WriteFile(envSecrets, []byte(content), 0644))
+		d, err = NewDiscovery(root)
+		require.NoError(t, err)
+		info, err := d.RepoInfo()
+		require.NoError(t, err)
+		require.Equal(t, detected.Host, info.Host)
+		require.Equal(t, detected.Owner, info.Owner)
+		require.Equal(t, detected.Name, info.Name)
+		require.Equal(t, "github.com/acme/api", info.CachePath())
+	}
+
+	// The owner/name identity stays distinct
+	require.NoError(t, os.WriteFile(envSecrets, []byte(".env\n"), 0644))
+	d, err = NewDiscovery(root)
+	require.NoError(t, err)
+	info, err := d.RepoInfo()
+	require.NoError(t, err)
+	require.Equal(t, "acme/api", info.CachePath())
+}
+
+func TestDiscovery_HostQualifiedIdentityNeedsRemote(t *testing.T) {
+	root := newMonorepo(t)
+
+	d, err := NewDiscovery(root)
+	require.NoError(t, err)
+	_, err = d.RepoInfoWithHost(true)
+	require.Error(t, err)
+}
+
+func TestNewDiscovery_OutsideGit(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".envsecrets"), []byte("repo: acme/deploy\n.env\n"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "modules", "vpc"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".env"), []byte("KEY=value"), 0600))
+
+	d, err := NewDiscovery(filepath.Join(root, "modules", "vpc"))
+	require.NoError(t, err)
+	require.False(t, d.IsGitRepo())
+	require.False(t, d.IsNested())
+	require.Equal(t, root, d.ProjectRoot())
+
+	info, err := d.RepoInfo()
+	require.NoError(t, err)
+	require.Equal(t, "acme/deploy", info.String())
+
+	files, err := d.EnvFiles()
+	require.NoError(t, err)
+	require.Equal(t, []string{".env"}, files)
+
+	issues, err := d.CheckSafety(files, SafetyOptions{History: true})
+	require.NoError(t, err)
+	require.Empty(t, issues)
+
+	_, err = d.HooksDir()
+	require.ErrorIs(t, err, domain.ErrNotInRepo)
+
+	// Without repo: there is nothing to identify the project
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".envsecrets"), []byte(".env\n"), 0644))
+	_, err = NewDiscovery(filepath.Join(root, "modules", "vpc"))
+	require.ErrorIs(t, err, domain.ErrNotInRepo)
+	require.ErrorContains(t, err, "repo:")
+}
+
+func TestNewDiscovery_NoProject(t *testing.T) {
+	_, err := NewDiscovery(t.TempDir())
+	require.ErrorIs(t, err, domain.ErrNotInRepo)
+}
@@ -0,0 +1,139 @@
+package project
+
+import (
+	"regexp"
+	"sort"
+
+	"github.com/charliek/envsecrets/internal/constants"
+	"github.com/charliek/envsecrets/internal/domain"
+)
+
+// validRemoteName restricts remote: directive entries to git remote names
+var validRemoteName = regexp.MustCompile(`^[a-zA-Z0-9._/-]+$`)
+
+// defaultRemote is the remote used when no preference matches
+const defaultRemote = "origin"
+
+// RemoteIdentity is the repository identity a git remote maps to
+type RemoteIdentity struct {
+	// Remote is the git remote name
+	Remote string `json:"remote"`
+	// Repo is the owner/name identity parsed from the remote URL
+	Repo string `json:"repo"`
+}
+
+// SetRemotePreference sets the user's preferred remotes (config "remotes").
+// They are tried after the .envsecrets remote: directive and before origin.
+func (d *Discovery) SetRemotePreference(remotes []string) {
+	d.remotes = remotes
+}
+
+// remotePreference returns the remote names to try, in order: the
+// namespace's remote: directive (else the git root's), then the user's
+// preference
+func (d *Discovery) remotePreference(config, rootConfig *domain.EnvSecretsConfig) []string {
+	var remotes []string
+	if len(config.Remotes) > 0 {
+		remotes = append(remotes, config.Remotes...)
+	} else {
+		remotes = append(remotes, rootConfig.Remotes...)
+	}
+	return append(remotes, d.remotes...)
+}
+
+// selectRemote returns the name and URL of the first remote in preference
+// that exists, else origin, else the first remote by name. explicit
+// reports whether a preference matched.
+func (d *Discovery) selectRemote(preference []string) (name, url string, explicit bool, err error) {
+	urls, err := d.remoteURLs()
+	if err != nil {
+		return "", "", false, err
+	}
+	if len(urls) == 0 {
+		return "", "", false, domain.Errorf(domain.ErrNotInRepo, "no remotes configured")
+	}
+
+	for _, name := range preference {
+		if url, ok := urls[name]; ok {
+			return name, url, true, nil
+		}
+	}
+	if url, ok := urls[defaultRemote]; ok {
+		return defaultRemote, url, false, nil
+	}
+	names := sortedKeys(urls)
+	return names[0], urls[names[0]], false, nil
+}
+
+// remoteURLs returns the first URL of every remote that has one, by name
+func (d *Discovery) remoteURLs() (map[string]string, error) {
+	if d.noGit {
+		return nil, domain.Errorf(domain.ErrNotInRepo, "%s is not in a git repository; add a repo: directive to %s", d.projectRoot, constants.EnvSecretsFile)
+	}
+	repo, err := openRepo(d.gitRoot)
+	if err != nil {
+		return nil, domain.Errorf(domain.ErrGitError, "failed to open repository: %v", err)
+	}
+
+	remotes, err := repo.Remotes()
+	if err != nil {
+		return nil, domain.Errorf(domain.ErrGitError, "failed to get remotes: %v", err)
+	}
+
+	urls := make(map[string]string, len(remotes))
+	for _, remote := range remotes {
+		if len(remote.Config().URLs) > 0 {
+			urls[remote.Config().Name] = remote.Config().URLs[0]
+		}
+	}
+	return urls, nil
+}
+
+// AmbiguousRemotes reports the identities of all git remotes when they
+// disagree and nothing chose between them: no repo: directive, and no
+// remote: directive or user preference naming an existing remote. It
+// returns the selected remote and the identity of every parsable remote,
+// or "" and nil when the identity is unambiguous.
+func (d *Discovery) AmbiguousRemotes() (string, []RemoteIdentity, error) {
+	config, rootConfig, err := d.envSecretsConfigs()
+	if err != nil {
+		return "", nil, err
+	}
+	if config.RepoOverride != "" || rootConfig.RepoOverride != "" {
+		return "", nil, nil
+	}
+
+	selected, _, explicit, err := d.selectRemote(d.remotePreference(config, rootConfig))
+	if err != nil || explicit {
+		return "", nil, err
+	}
+
+	urls, err := d.remoteURLs()
+	if err != nil {
+		return "", nil, err
+	}
+	var identities []RemoteIdentity
+	distinct := make(map[string]bool)
+	for _, name := range sortedKeys(urls) {
+		info, err := ParseRemoteURL(urls[name])
+		if err != nil {
+			continue
+		}
+		identities = append(identities, RemoteIdentity{Remote: name, Repo: info.String()})
+		distinct[info.String()] = true
+	}
+	if len(distinct) < 2 {
+		return "", nil, nil
+	}
+	return selected, identities, nil
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
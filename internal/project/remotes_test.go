@@ -0,0 +1,99 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/stretchr/testify/require"
+)
+
+// newFork creates a git repository with origin pointing at a personal fork
+// and upstream at the shared project
+func newFork(t *testing.T, envsecrets string) *Discovery {
+	t.Helper()
+	root := t.TempDir()
+	repo, err := git.PlainInit(root, false)
+	require.NoError(t, err)
+	for name, url := range map[string]string{
+		"origin":   "git@github.com:me/api.git",
+		"upstream": "git@github.com:acme/api.git",
+	} {
+		_, err := repo.CreateRemote(&gitconfig.RemoteConfig{Name: name, URLs: []string{url}})
+		require.NoError(t, err)
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".envsecrets"), []byte(envsecrets), 0644))
+
+	d, err := NewDiscovery(root)
+	require.NoError(t, err)
+	return d
+}
+
+func TestRepoInfo_RemotePreference(t *testing.T) {
+	tests := []struct {
+		name       string
+		envsecrets string
+		preference []string
+		want       string
+	}{
+		{name: "origin by default", envsecrets: ".env\n", want: "me/api"},
+		{name: "directive", envsecrets: "remote: upstream, origin\n.env\n", want: "acme/api"},
+		{name: "user preference", envsecrets: ".env\n", preference: []string{"upstream"}, want: "acme/api"},
+		{name: "directive before user preference", envsecrets: "remote: origin\n.env\n", preference: []string{"upstream"}, want: "me/api"},
+		{name: "missing remotes are skipped", envsecrets: "remote: canonical, upstream\n.env\n", want: "acme/api"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := newFork(t, tt.envsecrets)
+			d.SetRemotePreference(tt.preference)
+
+			info, err := d.RepoInfo()
+			require.NoError(t, err)
+			require.Equal(t, tt.want, info.String())
+		})
+	}
+}
+
+func TestAmbiguousRemotes(t *testing.T) {
+	d := newFork(t, ".env\n")
+	selected, remotes, err := d.AmbiguousRemotes()
+	require.NoError(t, err)
+	require.Equal(t, "origin", selected)
+	require.Equal(t, []RemoteIdentity{
+		{Remote: "origin", Repo: "me/api"},
+		{Remote: "upstream", Repo: "acme/api"},
+	}, remotes)
+
+	// An explicit choice silences the warning
+	d.SetRemotePreference([]string{"upstream"})
+	_, remotes, err = d.AmbiguousRemotes()
+	require.NoError(t, err)
+	require.Empty(t, remotes)
+
+	d = newFork(t, "repo: acme/api\n.env\n")
+	_, remotes, err = d.AmbiguousRemotes()
+	require.NoError(t, err)
+	require.Empty(t, remotes)
+}
+
+func TestParseEnvSecretsFile_RemoteDirective(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".envsecrets")
+	require.NoError(t, os.WriteFile(path, []byte("remote: upstream, origin\n.env\n"), 0644))
+
+	config, err := ParseEnvSecretsFile(path)
+	require.NoError(t, err)
+	require.Equal(t, []string{"upstream", "origin"}, config.Remotes)
+
+	// Directives survive a rewrite
+	require.NoError(t, AddToTracked(path, ".env.local"))
+	config, err = ParseEnvSecretsFile(path)
+	require.NoError(t, err)
+	require.Equal(t, []string{"upstream", "origin"}, config.Remotes)
+
+	require.NoError(t, os.WriteFile(path, []byte("remote: up stream\n"), 0644))
+	_, err = ParseEnvSecretsFile(path)
+	require.Error(t, err)
+}
@@ -0,0 +1,117 @@
+package project
+
+import (
+	"errors"
+
+	"github.com/charliek/envsecrets/internal/domain"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// SafetyOptions controls which accidental-commit checks CheckSafety runs
+type SafetyOptions struct {
+	// History also scans every commit reachable from any ref, which is
+	// slower on large repositories
+	History bool
+}
+
+// CheckSafety reports tracked files that could leak into the project's git:
+// files the project's .gitignore does not ignore, files in the git index,
+// and (with opts.History) files present in past commits. Projects outside
+// git have nothing to leak into.
+func (d *Discovery) CheckSafety(files []string, opts SafetyOptions) ([]domain.SafetyIssue, error) {
+	if d.noGit {
+		return nil, nil
+	}
+
+	var issues []domain.SafetyIssue
+
+	for _, f := range files {
+		ignored, err := d.IsIgnored(f)
+		if err != nil {
+			return nil, err
+		}
+		if !ignored {
+			issues = append(issues, domain.SafetyIssue{Path: f, Problem: domain.SafetyNotIgnored})
+		}
+	}
+
+	repo, err := openRepo(d.gitRoot)
+	if err != nil {
+		return nil, domain.Errorf(domain.ErrGitError, "failed to open repository: %v", err)
+	}
+
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, domain.Errorf(domain.ErrGitError, "failed to read git index: %v", err)
+	}
+	indexed := make(map[string]bool, len(idx.Entries))
+	for _, e := range idx.Entries {
+		indexed[e.Name] = true
+	}
+	for _, f := range files {
+		if indexed[d.gitPath(f)] {
+			issues = append(issues, domain.SafetyIssue{Path: f, Problem: domain.SafetyInIndex})
+		}
+	}
+
+	if opts.History {
+		gitPaths := make([]string, len(files))
+		for i, f := range files {
+			gitPaths[i] = d.gitPath(f)
+		}
+		found, err := filesInHistory(repo, gitPaths)
+		if err != nil {
+			return nil, err
+		}
+		for i, f := range files {
+			if commit, ok := found[gitPaths[i]]; ok {
+				issues = append(issues, domain.SafetyIssue{Path: f, Problem: domain.SafetyInHistory, Commit: commit})
+			}
+		}
+	}
+
+	return issues, nil
+}
+
+// filesInHistory returns, for each file present in any commit reachable from
+// any ref, the hash of the newest such commit seen
+func filesInHistory(repo *git.Repository, files []string) (map[string]string, error) {
+	found := make(map[string]string)
+
+	iter, err := repo.Log(&git.LogOptions{All: true, Order: git.LogOrderCommitterTime})
+	if err != nil {
+		// An empty repository has no history to leak
+		if errors.Is(err, plumbing.ErrReferenceNotFound) {
+			return found, nil
+		}
+		return nil, domain.Errorf(domain.ErrGitError, "failed to read git history: %v", err)
+	}
+	defer iter.Close()
+
+	err = iter.ForEach(func(c *object.Commit) error {
+		tree, err := c.Tree()
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			if _, seen := found[f]; seen {
+				continue
+			}
+			if _, err := tree.FindEntry(f); err == nil {
+				found[f] = c.Hash.String()
+			}
+		}
+		if len(found) == len(files) {
+			return storer.ErrStop
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, domain.Errorf(domain.ErrGitError, "failed to read git history: %v", err)
+	}
+
+	return found, nil
+}
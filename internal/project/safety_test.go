@@ -0,0 +1,75 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/charliek/envsecrets/internal/domain"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscovery_CheckSafety(t *testing.T) {
+	root := t.TempDir()
+	repo, err := git.PlainInit(root, false)
+	require.NoError(t, err)
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+
+	write := func(name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(root, name), []byte(content), 0600))
+	}
+	sig := &object.Signature{Name: "t", Email: "t@example.com", When: time.Now()}
+
+	// .env.old was committed once, then removed
+	write(".env.old", "X=1")
+	_, err = wt.Add(".env.old")
+	require.NoError(t, err)
+	oldCommit, err := wt.Commit("add secrets", &git.CommitOptions{Author: sig})
+	require.NoError(t, err)
+	_, err = wt.Remove(".env.old")
+	require.NoError(t, err)
+	_, err = wt.Commit("remove secrets", &git.CommitOptions{Author: sig})
+	require.NoError(t, err)
+
+	// .env.staged is staged; .env is ignored; .env.local is neither
+	write(".gitignore", ".env\n.env.old\n.env.staged\n")
+	write(".env", "A=1")
+	write(".env.local", "B=1")
+	write(".env.staged", "C=1")
+	_, err = wt.Add(".env.staged")
+	require.NoError(t, err)
+
+	d, err := NewDiscovery(root)
+	require.NoError(t, err)
+	files := []string{".env", ".env.local", ".env.staged", ".env.old"}
+
+	issues, err := d.CheckSafety(files, SafetyOptions{})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []domain.SafetyIssue{
+		{Path: ".env.local", Problem: domain.SafetyNotIgnored},
+		{Path: ".env.staged", Problem: domain.SafetyInIndex},
+	}, issues)
+
+	issues, err = d.CheckSafety(files, SafetyOptions{History: true})
+	require.NoError(t, err)
+	require.Contains(t, issues, domain.SafetyIssue{Path: ".env.old", Problem: domain.SafetyInHistory, Commit: oldCommit.String()})
+	require.Len(t, issues, 3, "staged-only files are not history")
+}
+
+func TestDiscovery_CheckSafety_EmptyRepo(t *testing.T) {
+	root := t.TempDir()
+	_, err := git.PlainInit(root, false)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".gitignore"), []byte(".env\n"), 0644))
+
+	d, err := NewDiscovery(root)
+	require.NoError(t, err)
+
+	issues, err := d.CheckSafety([]string{".env"}, SafetyOptions{History: true})
+	require.NoError(t, err)
+	require.Empty(t, issues)
+}
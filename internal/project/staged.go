@@ -0,0 +1,212 @@
+package project
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sort"
+
+	"github.com/charliek/envsecrets/internal/constants"
+	"github.com/charliek/envsecrets/internal/domain"
+	"github.com/charliek/envsecrets/internal/dotenv"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// minSecretLength is the shortest value treated as a secret when scanning
+// staged content; shorter values (ports, flags, "true") match too often
+const minSecretLength = 8
+
+// binarySniffLen is how much of a blob is checked for NUL bytes to skip binaries
+const binarySniffLen = 8000
+
+// CheckStaged reports staged changes that would leak secrets into the
+// project's git: tracked env files in the index (SafetyInIndex) and staged
+// files containing a value from a tracked env file (SafetySecretValue).
+// Only index entries that differ from HEAD are checked. Every staged file
+// in the repository is scanned, and paths are relative to the git root.
+func (d *Discovery) CheckStaged() ([]domain.SafetyIssue, error) {
+	if d.noGit {
+		return nil, nil
+	}
+	repo, err := openRepo(d.gitRoot)
+	if err != nil {
+		return nil, domain.Errorf(domain.ErrGitError, "failed to open repository: %v", err)
+	}
+
+	staged, err := stagedEntries(repo)
+	if err != nil {
+		return nil, err
+	}
+	if len(staged) == 0 {
+		return nil, nil
+	}
+
+	names := make([]string, 0, len(staged))
+	var candidates []string
+	for name := range staged {
+		names = append(names, name)
+		if rel, ok := d.fromGitPath(name); ok {
+			candidates = append(candidates, rel)
+		}
+	}
+	sort.Strings(names)
+
+	files, err := d.EnvFilesWith(candidates)
+	if err != nil {
+		if errors.Is(err, domain.ErrNoEnvFiles) || errors.Is(err, domain.ErrNoFilesTracked) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	tracked := make(map[string]bool, len(files))
+	for _, f := range files {
+		tracked[d.gitPath(f)] = true
+	}
+
+	var issues []domain.SafetyIssue
+	for _, name := range names {
+		if tracked[name] {
+			issues = append(issues, domain.SafetyIssue{Path: name, Problem: domain.SafetyInIndex})
+		}
+	}
+
+	secrets := d.knownSecrets(files)
+	if len(secrets) == 0 {
+		return issues, nil
+	}
+
+	for _, name := range names {
+		if tracked[name] {
+			continue
+		}
+		content, err := readBlob(repo, staged[name])
+		if err != nil {
+			return nil, err
+		}
+		if content == nil {
+			continue
+		}
+		for _, s := range secrets {
+			if bytes.Contains(content, []byte(s.value)) {
+				issues = append(issues, domain.SafetyIssue{
+					Path:    name,
+					Problem: domain.SafetySecretValue,
+					Key:     s.key,
+					Source:  s.source,
+				})
+			}
+		}
+	}
+
+	return issues, nil
+}
+
+// stagedEntries returns the index entries whose content differs from HEAD,
+// keyed by path. Submodule entries are skipped.
+func stagedEntries(repo *git.Repository) (map[string]plumbing.Hash, error) {
+	idx, err := repo.Storer.Index()
+	if err != nil {
+		return nil, domain.Errorf(domain.ErrGitError, "failed to read git index: %v", err)
+	}
+
+	headTree, err := headTree(repo)
+	if err != nil {
+		return nil, err
+	}
+
+	staged := make(map[string]plumbing.Hash)
+	for _, e := range idx.Entries {
+		if e.Mode == filemode.Submodule {
+			continue
+		}
+		if headTree != nil {
+			if entry, err := headTree.FindEntry(e.Name); err == nil && entry.Hash == e.Hash {
+				continue
+			}
+		}
+		staged[e.Name] = e.Hash
+	}
+	return staged, nil
+}
+
+// headTree returns the tree of the HEAD commit, or nil before the first commit
+func headTree(repo *git.Repository) (*object.Tree, error) {
+	head, err := repo.Head()
+	if err != nil {
+		if errors.Is(err, plumbing.ErrReferenceNotFound) {
+			return nil, nil
+		}
+		return nil, domain.Errorf(domain.ErrGitError, "failed to resolve HEAD: %v", err)
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, domain.Errorf(domain.ErrGitError, "failed to read HEAD commit: %v", err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, domain.Errorf(domain.ErrGitError, "failed to read HEAD tree: %v", err)
+	}
+	return tree, nil
+}
+
+// readBlob returns a staged blob's content, or nil for blobs too large or
+// binary to be worth scanning
+func readBlob(repo *git.Repository, hash plumbing.Hash) ([]byte, error) {
+	blob, err := repo.BlobObject(hash)
+	if err != nil {
+		return nil, domain.Errorf(domain.ErrGitError, "failed to read staged blob %s: %v", hash, err)
+	}
+	if blob.Size > constants.MaxEnvFileSize {
+		return nil, nil
+	}
+	r, err := blob.Reader()
+	if err != nil {
+		return nil, domain.Errorf(domain.ErrGitError, "failed to read staged blob %s: %v", hash, err)
+	}
+	defer r.Close()
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, domain.Errorf(domain.ErrGitError, "failed to read staged blob %s: %v", hash, err)
+	}
+	if bytes.IndexByte(content[:min(len(content), binarySniffLen)], 0) >= 0 {
+		return nil, nil
+	}
+	return content, nil
+}
+
+// knownSecret is a value from a tracked env file
+type knownSecret struct {
+	key    string
+	value  string
+	source string
+}
+
+// knownSecrets collects the values of the tracked files present in the
+// working tree. Unreadable or unparsable files are skipped: they cannot
+// leak what is not there, and their values cannot be told apart.
+func (d *Discovery) knownSecrets(files []string) []knownSecret {
+	seen := make(map[string]bool)
+	var secrets []knownSecret
+	for _, f := range files {
+		content, err := d.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		parsed, err := dotenv.Parse(content)
+		if err != nil {
+			continue
+		}
+		for _, key := range parsed.Keys() {
+			value, _ := parsed.Lookup(key)
+			if len(value) < minSecretLength || seen[value] {
+				continue
+			}
+			seen[value] = true
+			secrets = append(secrets, knownSecret{key: key, value: value, source: d.gitPath(f)})
+		}
+	}
+	return secrets
+}
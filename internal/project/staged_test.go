@@ -0,0 +1,105 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/charliek/envsecrets/internal/domain"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscovery_CheckStaged(t *testing.T) {
+	root := t.TempDir()
+	repo, err := git.PlainInit(root, false)
+	require.NoError(t, err)
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+
+	write := func(name, content string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(root, name)), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(root, name), []byte(content), 0600))
+	}
+	stage := func(name string) {
+		_, err := wt.Add(name)
+		require.NoError(t, err)
+	}
+
+	write(".envsecrets", ".env\nconfig/*.env\n")
+	write(".env", "API_KEY=\"sk-live-0123456789\"\nPORT=8080\n")
+	write("config/prod.env", "export DB_PASSWORD=hunter2hunter2 # rotated\n")
+
+	// Files already committed unchanged are not re-scanned
+	write("README.md", "sk-live-0123456789 in history is out of scope\n")
+	stage("README.md")
+	_, err = wt.Commit("init", &git.CommitOptions{Author: &object.Signature{Name: "t", Email: "t@example.com", When: time.Now()}})
+	require.NoError(t, err)
+
+	d, err := NewDiscovery(root)
+	require.NoError(t, err)
+
+	issues, err := d.CheckStaged()
+	require.NoError(t, err)
+	require.Empty(t, issues)
+
+	write("app.go", "const key = \"sk-live-0123456789\"\nconst port = 8080\n")
+	write("deploy.sh", "psql -W hunter2hunter2\n")
+	stage("app.go")
+	stage("deploy.sh")
+	stage("config/prod.env")
+
+	issues, err = d.CheckStaged()
+	require.NoError(t, err)
+	require.ElementsMatch(t, []domain.SafetyIssue{
+		{Path: "config/prod.env", Problem: domain.SafetyInIndex},
+		{Path: "app.go", Problem: domain.SafetySecretValue, Key: "API_KEY", Source: ".env"},
+		{Path: "deploy.sh", Problem: domain.SafetySecretValue, Key: "DB_PASSWORD", Source: "config/prod.env"},
+	}, issues)
+}
+
+func TestDiscovery_CheckStaged_NoTrackedFiles(t *testing.T) {
+	root := t.TempDir()
+	repo, err := git.PlainInit(root, false)
+	require.NoError(t, err)
+	wt, err := repo.Worktree()
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0644))
+	_, err = wt.Add("main.go")
+	require.NoError(t, err)
+
+	d, err := NewDiscovery(root)
+	require.NoError(t, err)
+	issues, err := d.CheckStaged()
+	require.NoError(t, err)
+	require.Empty(t, issues)
+}
+
+func TestDiscovery_KnownSecrets(t *testing.T) {
+	root := t.TempDir()
+	_, err := git.PlainInit(root, false)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".envsecrets"), []byte(".env\n.env.bad\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".env"), []byte(`# comment
+export A='single # not a comment'
+B="escaped \"quote\" value"
+C=unquoted-value # trailing
+D="-----BEGIN KEY-----
+abcdefgh
+-----END KEY-----"
+SHORT=8080
+A='overridden-value'
+`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(root, ".env.bad"), []byte("not an assignment\n"), 0600))
+
+	d, err := NewDiscovery(root)
+	require.NoError(t, err)
+	require.Equal(t, []knownSecret{
+		{key: "A", value: "overridden-value", source: ".env"},
+		{key: "B", value: `escaped "quote" value`, source: ".env"},
+		{key: "C", value: "unquoted-value", source: ".env"},
+		{key: "D", value: "-----BEGIN KEY-----\nabcdefgh\n-----END KEY-----", source: ".env"},
+	}, d.knownSecrets([]string{".env", ".env.bad", ".env.missing"}))
+}
@@ -0,0 +1,49 @@
+package sync
+
+import "github.com/charliek/envsecrets/internal/dotenv"
+
+// Mergeable reports whether the versions merged key by key, so Merged can
+// pick a side per key
+func (c *Conflict) Mergeable() bool {
+	return c.merge != nil
+}
+
+// Merged returns the key-level merge with the given conflicting keys taken
+// from the remote version and the others kept local. It returns nil when
+// the conflict is not Mergeable.
+func (c *Conflict) Merged(remoteKeys ...string) []byte {
+	if c.merge == nil {
+		return nil
+	}
+	merged := c.merge.merged.Clone()
+	for _, key := range remoteKeys {
+		merged.Adopt(c.merge.remote, key)
+	}
+	return merged.Bytes()
+}
+
+// Marked returns content for resolving the conflict by hand: the key-level
+// merge with conflict markers around each conflicting key, or the whole
+// local and remote versions between markers when the conflict is not
+// Mergeable
+func (c *Conflict) Marked() []byte {
+	if c.merge == nil {
+		return dotenv.MarkConflict(c.Local, c.Remote)
+	}
+	return dotenv.MarkConflicts(c.merge.merged, c.merge.remote, c.Keys)
+}
+
+// MergeVersions merges two edits of a file made from base, key by key. It
+// returns the merged content, or a Conflict for a ConflictResolver when a
+// key changed on both sides or a version cannot be merged key by key.
+// A nil version means the file does not exist on that side.
+func MergeVersions(file string, base, local, remote []byte) ([]byte, *Conflict) {
+	var merge *keyMerge
+	if base != nil && local != nil && remote != nil {
+		merge = mergeKeys(base, local, remote)
+		if merge != nil && len(merge.conflicts) == 0 {
+			return merge.merged.Bytes(), nil
+		}
+	}
+	return nil, newConflict(file, base, base != nil, local, local != nil, remote, remote != nil, merge)
+}
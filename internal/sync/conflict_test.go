@@ -12,70 +12,71 @@ func TestConflictAction_Constants(t *testing.T) {
 	require.NotEqual(t, ConflictOverwrite, ConflictSkip)
 	require.NotEqual(t, ConflictOverwrite, ConflictAbort)
 	require.NotEqual(t, ConflictSkip, ConflictAbort)
+	require.NotEqual(t, ConflictMerge, ConflictOverwrite)
 }
 
 func TestConflictResolver_SkipAll(t *testing.T) {
-	resolver := func(filename string) (ConflictAction, error) {
-		return ConflictSkip, nil
+	resolver := func(c *Conflict) (Resolution, error) {
+		return Resolution{Action: ConflictSkip}, nil
 	}
 
-	action, err := resolver("test.env")
+	resolution, err := resolver(&Conflict{File: "test.env"})
 	require.NoError(t, err)
-	require.Equal(t, ConflictSkip, action)
+	require.Equal(t, ConflictSkip, resolution.Action)
 }
 
 func TestConflictResolver_OverwriteAll(t *testing.T) {
-	resolver := func(filename string) (ConflictAction, error) {
-		return ConflictOverwrite, nil
+	resolver := func(c *Conflict) (Resolution, error) {
+		return Resolution{Action: ConflictOverwrite}, nil
 	}
 
-	action, err := resolver("test.env")
+	resolution, err := resolver(&Conflict{File: "test.env"})
 	require.NoError(t, err)
-	require.Equal(t, ConflictOverwrite, action)
+	require.Equal(t, ConflictOverwrite, resolution.Action)
 }
 
 func TestConflictResolver_Abort(t *testing.T) {
-	resolver := func(filename string) (ConflictAction, error) {
-		return ConflictAbort, nil
+	resolver := func(c *Conflict) (Resolution, error) {
+		return Resolution{Action: ConflictAbort}, nil
 	}
 
-	action, err := resolver("test.env")
+	resolution, err := resolver(&Conflict{File: "test.env"})
 	require.NoError(t, err)
-	require.Equal(t, ConflictAbort, action)
+	require.Equal(t, ConflictAbort, resolution.Action)
 }
 
 func TestConflictResolver_Error(t *testing.T) {
 	testErr := errors.New("user cancelled")
-	resolver := func(filename string) (ConflictAction, error) {
-		return ConflictAbort, testErr
+	resolver := func(c *Conflict) (Resolution, error) {
+		return Resolution{Action: ConflictAbort}, testErr
 	}
 
-	_, err := resolver("test.env")
+	_, err := resolver(&Conflict{File: "test.env"})
 	require.ErrorIs(t, err, testErr)
 }
 
 func TestConflictResolver_PerFile(t *testing.T) {
 	// Resolver that handles different files differently
-	resolver := func(filename string) (ConflictAction, error) {
-		switch filename {
+	resolver := func(c *Conflict) (Resolution, error) {
+		switch c.File {
 		case ".env":
-			return ConflictOverwrite, nil
+			return Resolution{Action: ConflictOverwrite}, nil
 		case ".env.local":
-			return ConflictSkip, nil
+			return Resolution{Action: ConflictSkip}, nil
 		default:
-			return ConflictAbort, nil
+			return Resolution{Action: ConflictAbort}, nil
 		}
 	}
 
-	action, err := resolver(".env")
+	resolution, err := resolver(&Conflict{File: ".env"})
 	require.NoError(t, err)
-	require.Equal(t, ConflictOverwrite, action)
+	require.Equal(t, ConflictOverwrite, resolution.Action)
 
-	action, err = resolver(".env.local")
+	resolution, err = resolver(&Conflict{File: ".env.local"})
 	require.NoError(t, err)
-	require.Equal(t, ConflictSkip, action)
+	require.Equal(t, ConflictSkip, resolution.Action)
 
-	action, err = resolver(".env.production")
+	resolution, err = resolver(&Conflict{File: ".env.production"})
 	require.NoError(t, err)
-	require.Equal(t, ConflictAbort, action)
+	require.Equal(t, ConflictAbort, resolution.Action)
 }
@@ -0,0 +1,151 @@
+package sync
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/charliek/envsecrets/internal/domain"
+)
+
+// RemoteVersion is a tracked file as it is at the remote HEAD
+type RemoteVersion struct {
+	File string
+	// Head is the remote commit the content was read at; empty when the
+	// remote has no commits yet
+	Head string
+	// Content is nil when the file is not in the remote
+	Content []byte
+}
+
+// PushFileOptions configures a single-file push.
+type PushFileOptions struct {
+	// Message is the commit message
+	Message string
+	// Base is the remote HEAD the new content was derived from. The push is
+	// refused with ErrRemoteChanged when the remote has moved since.
+	Base string
+}
+
+// ReadRemoteVersion syncs the cache and returns a file at the remote HEAD
+func (s *Syncer) ReadRemoteVersion(ctx context.Context, file string) (*RemoteVersion, error) {
+	if err := s.syncBeforePush(ctx, true); err != nil {
+		return nil, err
+	}
+	v := &RemoteVersion{File: file}
+	if head, err := s.cache.GetRemoteHead(ctx); err == nil {
+		v.Head = head
+	}
+	if v.Head == "" {
+		return v, nil
+	}
+	content, err := s.ReadFileAt(file, "")
+	if err != nil && !errors.Is(err, domain.ErrFileNotFound) {
+		return nil, err
+	}
+	v.Content = content
+	return v, nil
+}
+
+// PushFile publishes new content for one tracked file as a single commit,
+// without reading the working tree or touching other files. The working
+// tree and this machine's sync baseline are left alone, so a later pull
+// brings the change into the working tree.
+func (s *Syncer) PushFile(ctx context.Context, file string, content []byte, opts PushFileOptions) (*domain.PushResult, error) {
+	if err := s.syncBeforePush(ctx, false); err != nil {
+		return nil, err
+	}
+	if err := s.checkRemoteHead(ctx, opts.Base); err != nil {
+		return nil, err
+	}
+
+	result := &domain.PushResult{}
+	existing, err := s.cache.ReadEncrypted(file)
+	switch {
+	case err == nil:
+		plain, err := s.encrypter.Decrypt(existing)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt existing %s: %w", file, err)
+		}
+		if bytes.Equal(plain, content) {
+			return nil, domain.ErrNothingToCommit
+		}
+		result.FilesUpdated++
+	case errors.Is(err, domain.ErrFileNotFound):
+		existing = nil
+		result.FilesAdded++
+	default:
+		return nil, fmt.Errorf("failed to read %s from cache: %w", file, err)
+	}
+
+	encrypted, err := s.encrypter.Encrypt(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt %s: %w", file, err)
+	}
+	if err := s.cache.WriteEncrypted(file, encrypted); err != nil {
+		return nil, fmt.Errorf("failed to write %s to cache: %w", file, err)
+	}
+	if err := s.cache.StageAll(); err != nil {
+		return nil, fmt.Errorf("failed to stage changes: %w", err)
+	}
+
+	// Verify again right before committing (optimistic locking), leaving
+	// the cache as it was when refused
+	if err := s.checkRemoteHead(ctx, opts.Base); err != nil {
+		if restoreErr := s.restoreCacheFile(file, existing); restoreErr != nil {
+			return nil, errors.Join(err, restoreErr)
+		}
+		return nil, err
+	}
+
+	message := opts.Message
+	if message == "" {
+		message = generateCommitMessage(result)
+	}
+	hash, err := s.cache.Commit(message)
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit: %w", err)
+	}
+	result.CommitHash = hash
+
+	if err := s.cache.SyncToStorage(ctx); err != nil {
+		return nil, fmt.Errorf("failed to sync to storage: %w", err)
+	}
+	return result, nil
+}
+
+// restoreCacheFile undoes an uncommitted write of file to the cache: the
+// previous encrypted content is staged again, or the file removed when
+// previous is nil
+func (s *Syncer) restoreCacheFile(file string, previous []byte) error {
+	if previous == nil {
+		if err := s.cache.RemoveEncrypted(file); err != nil {
+			return fmt.Errorf("failed to restore %s in cache: %w", file, err)
+		}
+		return nil
+	}
+	if err := s.cache.WriteEncrypted(file, previous); err != nil {
+		return fmt.Errorf("failed to restore %s in cache: %w", file, err)
+	}
+	if err := s.cache.StageAll(); err != nil {
+		return fmt.Errorf("failed to restore %s in cache: %w", file, err)
+	}
+	return nil
+}
+
+// checkRemoteHead returns ErrRemoteChanged unless the remote HEAD is base
+func (s *Syncer) checkRemoteHead(ctx context.Context, base string) error {
+	head, err := s.cache.GetRemoteHead(ctx)
+	if err != nil {
+		if base != "" {
+			return fmt.Errorf("failed to read remote HEAD: %w", err)
+		}
+		// No remote yet
+		head = ""
+	}
+	if head != base {
+		return domain.Errorf(domain.ErrRemoteChanged, "remote changed since it was read (expected %s, got %s)", truncHash(base), truncHash(head))
+	}
+	return nil
+}
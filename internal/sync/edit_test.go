@@ -0,0 +1,105 @@
+package sync
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/charliek/envsecrets/internal/crypto"
+	"github.com/charliek/envsecrets/internal/domain"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPushFile(t *testing.T) {
+	ctx := context.Background()
+	env := newTestEnv()
+	a := env.newMachine(t, []string{".env", ".env.local"})
+	b := env.newMachine(t, []string{".env", ".env.local"})
+
+	a.writeFile(".env", "A=1\nB=1\n")
+	a.writeFile(".env.local", "L=1\n")
+	a.push()
+
+	// B edits the remote version without a working tree copy
+	v, err := b.syncer.ReadRemoteVersion(ctx, ".env")
+	require.NoError(t, err)
+	require.Equal(t, "A=1\nB=1\n", string(v.Content))
+	require.NotEmpty(t, v.Head)
+
+	res, err := b.syncer.PushFile(ctx, ".env", []byte("A=2\nB=1\n"), PushFileOptions{Message: "Edit .env", Base: v.Head})
+	require.NoError(t, err)
+	require.Equal(t, 1, res.FilesUpdated)
+	_, err = os.Stat(filepath.Join(b.projectDir, ".env"))
+	require.True(t, os.IsNotExist(err), "working tree must not be written")
+
+	// Other files are untouched, and A sees a plain remote change
+	got, err := b.syncer.ReadFileAt(".env.local", "")
+	require.NoError(t, err)
+	require.Equal(t, "L=1\n", string(got))
+	require.Equal(t, domain.SyncActionPull, a.status().Action)
+
+	// Pushing from a stale base is refused
+	_, err = b.syncer.PushFile(ctx, ".env", []byte("A=3\n"), PushFileOptions{Base: v.Head})
+	require.ErrorIs(t, err, domain.ErrRemoteChanged)
+
+	// Unchanged content is nothing to commit
+	v, err = b.syncer.ReadRemoteVersion(ctx, ".env")
+	require.NoError(t, err)
+	_, err = b.syncer.PushFile(ctx, ".env", v.Content, PushFileOptions{Base: v.Head})
+	require.ErrorIs(t, err, domain.ErrNothingToCommit)
+}
+
+// hookEncrypter runs onEncrypt before each encryption
+type hookEncrypter struct {
+	crypto.Encrypter
+	onEncrypt func()
+}
+
+func (e *hookEncrypter) Encrypt(plaintext []byte) ([]byte, error) {
+	e.onEncrypt()
+	return e.Encrypter.Encrypt(plaintext)
+}
+
+func TestPushFile_RemoteChangedWhileWriting(t *testing.T) {
+	for _, file := range []string{".env", ".env.local"} {
+		t.Run(file, func(t *testing.T) {
+			ctx := context.Background()
+			env := newTestEnv()
+			a := env.newMachine(t, []string{".env", ".env.local"})
+			b := env.newMachine(t, []string{".env", ".env.local"})
+			a.writeFile(".env", "A=1\n")
+			a.push()
+
+			v, err := b.syncer.ReadRemoteVersion(ctx, file)
+			require.NoError(t, err)
+
+			// A pushes after B passed the first remote check
+			encrypter := &hookEncrypter{Encrypter: env.encrypter, onEncrypt: func() {
+				a.writeFile(".env", "A=2\n")
+				a.push()
+			}}
+			syncer := NewSyncer(b.discovery, env.repoInfo, env.storage, encrypter, b.cache)
+			_, err = syncer.PushFile(ctx, file, []byte("B=1\n"), PushFileOptions{Base: v.Head})
+			require.ErrorIs(t, err, domain.ErrRemoteChanged)
+
+			changed, err := b.cache.HasChanges()
+			require.NoError(t, err)
+			require.False(t, changed, "the refused write must not stay in the cache")
+		})
+	}
+}
+
+func TestMergeVersions(t *testing.T) {
+	merged, conflict := MergeVersions(".env", []byte("A=1\nB=1\n"), []byte("A=2\nB=1\n"), []byte("A=1\nB=2\n"))
+	require.Nil(t, conflict)
+	require.Equal(t, "A=2\nB=2\n", string(merged))
+
+	_, conflict = MergeVersions(".env", []byte("A=1\n"), []byte("A=2\n"), []byte("A=3\n"))
+	require.Equal(t, []string{"A"}, conflict.Keys)
+	require.True(t, conflict.Mergeable())
+
+	_, conflict = MergeVersions(".env", []byte("A=1\n"), []byte("A=2\n"), nil)
+	require.False(t, conflict.Mergeable())
+	require.Nil(t, conflict.Remote)
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 
 	"github.com/charliek/envsecrets/internal/domain"
+	"github.com/charliek/envsecrets/internal/dotenv"
 )
 
 // Pull downloads and decrypts environment files
@@ -50,7 +51,7 @@ func (s *Syncer) Pull(ctx context.Context, opts PullOptions) (*domain.PullResult
 	}
 
 	// Get list of files to pull
-	files, err := s.discovery.EnvFiles()
+	files, err := s.discoveryEnvFiles()
 	if err != nil {
 		return nil, err
 	}
@@ -79,9 +80,12 @@ func (s *Syncer) Pull(ctx context.Context, opts PullOptions) (*domain.PullResult
 		file      string
 		decrypted []byte
 		isNew     bool
+		merged    bool
 	}
 	var filesToWrite []fileToWrite
 	var filesToDelete []string
+	// The versions of each file in FilesWithConflicts, for the resolver
+	conflicts := make(map[string]*Conflict)
 
 	for _, file := range files {
 		// Remote state (cache@HEAD).
@@ -176,6 +180,15 @@ func (s *Syncer) Pull(ctx context.Context, opts PullOptions) (*domain.PullResult
 				result.FilesSkipped++
 				continue
 			}
+			// Both sides edited the file: merge key by key against the
+			// baseline so only keys changed on both sides need a decision
+			var merge *keyMerge
+			if fileExists && remoteExists && baseExists && !opts.Force {
+				if merge = mergeKeys(basePlain, existingContent, decrypted); merge != nil && len(merge.conflicts) == 0 {
+					filesToWrite = append(filesToWrite, fileToWrite{file: file, decrypted: merge.merged.Bytes(), merged: true})
+					continue
+				}
+			}
 			// Real conflict only when the working tree has state that
 			// could be lost or revived. A remote add against a working
 			// tree that simply never had the file is NOT a conflict — the
@@ -185,6 +198,7 @@ func (s *Syncer) Pull(ctx context.Context, opts PullOptions) (*domain.PullResult
 			// the remote edit would resurrect the file.)
 			if fileExists || baseExists {
 				result.FilesWithConflicts = append(result.FilesWithConflicts, file)
+				conflicts[file] = newConflict(file, basePlain, baseExists, existingContent, fileExists, decrypted, remoteExists, merge)
 			}
 			if remoteExists {
 				filesToWrite = append(filesToWrite, fileToWrite{file: file, decrypted: decrypted, isNew: !fileExists})
@@ -201,6 +215,7 @@ func (s *Syncer) Pull(ctx context.Context, opts PullOptions) (*domain.PullResult
 			// file is never a conflict — there's nothing to overwrite.
 			if fileExists {
 				result.FilesWithConflicts = append(result.FilesWithConflicts, file)
+				conflicts[file] = newConflict(file, nil, false, existingContent, fileExists, decrypted, remoteExists, nil)
 			}
 			if remoteExists {
 				filesToWrite = append(filesToWrite, fileToWrite{file: file, decrypted: decrypted, isNew: !fileExists})
@@ -219,33 +234,41 @@ func (s *Syncer) Pull(ctx context.Context, opts PullOptions) (*domain.PullResult
 
 		// Resolve each conflict
 		resolvedSkips := make(map[string]bool)
+		resolvedContent := make(map[string][]byte)
 		for _, file := range result.FilesWithConflicts {
-			action, err := opts.ConflictResolver(file)
+			resolution, err := opts.ConflictResolver(conflicts[file])
 			if err != nil {
 				return result, fmt.Errorf("conflict resolution failed for %s: %w", file, err)
 			}
-			switch action {
+			switch resolution.Action {
 			case ConflictAbort:
 				return result, domain.ErrUserCancelled
 			case ConflictSkip:
 				resolvedSkips[file] = true
 			case ConflictOverwrite:
 				// Do nothing, file will be written / deleted
+			case ConflictMerge:
+				resolvedContent[file] = resolution.Content
 			default:
-				return result, fmt.Errorf("invalid conflict action %d for %s", action, file)
+				return result, fmt.Errorf("invalid conflict action %d for %s", resolution.Action, file)
 			}
 		}
 
-		// Filter both write and delete lists to exclude skipped files
+		// Filter both write and delete lists to exclude skipped files, and
+		// write resolved content in place of the remote version
 		var filteredWrites []fileToWrite
 		for _, ftw := range filesToWrite {
 			if resolvedSkips[ftw.file] {
 				result.FilesSkippedConflict++
 				continue
 			}
+			if content, ok := resolvedContent[ftw.file]; ok {
+				ftw.decrypted = content
+				ftw.merged = true
+				delete(resolvedContent, ftw.file)
+			}
 			filteredWrites = append(filteredWrites, ftw)
 		}
-		filesToWrite = filteredWrites
 
 		var filteredDeletes []string
 		for _, f := range filesToDelete {
@@ -253,8 +276,14 @@ func (s *Syncer) Pull(ctx context.Context, opts PullOptions) (*domain.PullResult
 				result.FilesSkippedConflict++
 				continue
 			}
+			if content, ok := resolvedContent[f]; ok {
+				// Remote deleted the file; the resolution keeps it
+				filteredWrites = append(filteredWrites, fileToWrite{file: f, decrypted: content, merged: true})
+				continue
+			}
 			filteredDeletes = append(filteredDeletes, f)
 		}
+		filesToWrite = filteredWrites
 		filesToDelete = filteredDeletes
 
 		result.FilesWithConflicts = nil
@@ -264,7 +293,9 @@ func (s *Syncer) Pull(ctx context.Context, opts PullOptions) (*domain.PullResult
 	// actually touching the working tree.
 	if opts.DryRun {
 		for _, ftw := range filesToWrite {
-			if ftw.isNew {
+			if ftw.merged {
+				result.FilesMerged++
+			} else if ftw.isNew {
 				result.FilesCreated++
 			} else {
 				result.FilesUpdated++
@@ -280,7 +311,9 @@ func (s *Syncer) Pull(ctx context.Context, opts PullOptions) (*domain.PullResult
 			return nil, fmt.Errorf("failed to write %s: %w", ftw.file, err)
 		}
 
-		if ftw.isNew {
+		if ftw.merged {
+			result.FilesMerged++
+		} else if ftw.isNew {
 			result.FilesCreated++
 		} else {
 			result.FilesUpdated++
@@ -323,14 +356,64 @@ func (s *Syncer) Pull(ctx context.Context, opts PullOptions) (*domain.PullResult
 	return result, nil
 }
 
+// keyMerge is a key-level 3-way merge of one file
+type keyMerge struct {
+	merged    *dotenv.File
+	remote    *dotenv.File
+	conflicts []string
+}
+
+// mergeKeys merges the local and remote versions of a file against the
+// baseline, or returns nil when any version is not a dotenv file
+func mergeKeys(base, local, remote []byte) *keyMerge {
+	baseFile, err := dotenv.Parse(base)
+	if err != nil {
+		return nil
+	}
+	localFile, err := dotenv.Parse(local)
+	if err != nil {
+		return nil
+	}
+	remoteFile, err := dotenv.Parse(remote)
+	if err != nil {
+		return nil
+	}
+	merged, conflicts := dotenv.Merge(baseFile, localFile, remoteFile)
+	return &keyMerge{merged: merged, remote: remoteFile, conflicts: conflicts}
+}
+
+// newConflict describes a conflicting file; a version whose exists flag is
+// false is recorded as nil
+func newConflict(file string, base []byte, baseExists bool, local []byte, localExists bool, remote []byte, remoteExists bool, merge *keyMerge) *Conflict {
+	c := &Conflict{File: file, merge: merge}
+	if baseExists {
+		c.Base = base
+	}
+	if localExists {
+		c.Local = local
+	}
+	if remoteExists {
+		c.Remote = remote
+	}
+	if merge != nil {
+		c.Keys = merge.conflicts
+	}
+	return c
+}
+
 // PullFile downloads and decrypts a single file
 func (s *Syncer) PullFile(ctx context.Context, filename string, ref string) ([]byte, error) {
 	// Ensure cache is synced
 	if err := s.cache.SyncFromStorage(ctx); err != nil {
 		return nil, err
 	}
+	return s.ReadFileAt(filename, ref)
+}
 
-	// Read from specific ref or working tree
+// ReadFileAt decrypts a file from the cache at ref, or at HEAD when ref is
+// empty, without syncing the cache first
+func (s *Syncer) ReadFileAt(filename string, ref string) ([]byte, error) {
+	// Read from specific ref or HEAD
 	var encrypted []byte
 	var err error
 
@@ -40,7 +40,7 @@ func (s *Syncer) Push(ctx context.Context, opts PushOptions) (*domain.PushResult
 	}
 
 	// Get files to track
-	files, err := s.discovery.EnvFiles()
+	files, err := s.discoveryEnvFiles()
 	if err != nil {
 		if !errors.Is(err, domain.ErrNoFilesTracked) {
 			return nil, err
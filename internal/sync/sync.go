@@ -58,10 +58,34 @@ const (
 	ConflictSkip
 	// ConflictAbort cancels the entire pull operation
 	ConflictAbort
+	// ConflictMerge writes the resolution's content to the local file
+	ConflictMerge
 )
 
+// Conflict is a file changed both locally and remotely, as handed to a
+// ConflictResolver. Each version is nil when the file does not exist on
+// that side; Base is also nil when this machine has no baseline.
+type Conflict struct {
+	File   string
+	Base   []byte
+	Local  []byte
+	Remote []byte
+	// Keys are the keys changed differently on both sides; empty when the
+	// versions could not be merged key by key
+	Keys []string
+
+	merge *keyMerge
+}
+
+// Resolution is how a ConflictResolver settled one conflicting file
+type Resolution struct {
+	Action ConflictAction
+	// Content replaces the local file when Action is ConflictMerge
+	Content []byte
+}
+
 // ConflictResolver is called for each conflicting file to determine the action
-type ConflictResolver func(filename string) (ConflictAction, error)
+type ConflictResolver func(c *Conflict) (Resolution, error)
 
 // PullOptions configures a pull operation.
 type PullOptions struct {
@@ -73,6 +97,8 @@ type PullOptions struct {
 	// DryRun shows what would be pulled without actually pulling
 	DryRun bool
 	// ConflictResolver is called for each conflicting file when Force is false.
+	// Files both sides edited are first merged key by key, so only files
+	// with keys changed on both sides reach it.
 	// If nil and conflicts exist, the pull will abort with ErrConflict.
 	ConflictResolver ConflictResolver
 }
@@ -290,11 +316,17 @@ func sameContent(a []byte, aExists bool, b []byte, bExists bool) bool {
 
 // discoveryEnvFiles returns the tracked file list, propagating ErrNoFilesTracked
 // distinctly so callers can treat "nothing to track" as a soft state, not an error.
+// Glob patterns are also matched against files already in the cache, so a
+// pattern-tracked file deleted locally or added on another machine is seen.
 func (s *Syncer) discoveryEnvFiles() ([]string, error) {
 	if s.discovery == nil {
 		return nil, domain.ErrNotInRepo
 	}
-	return s.discovery.EnvFiles()
+	cached, err := s.cache.ListTrackedFiles()
+	if err != nil {
+		return nil, err
+	}
+	return s.discovery.EnvFilesWith(cached)
 }
 
 // EnsureCacheInitialized ensures the cache is initialized
@@ -715,6 +715,123 @@ func TestPull_LocallyEditedIsStillConflict(t *testing.T) {
 	require.ErrorIs(t, err, domain.ErrConflict)
 }
 
+// TestPull_MergesDisjointKeyEdits: both machines edited the same file but
+// different keys. Pull merges key by key without asking, and the merged
+// file is ready to push.
+func TestPull_MergesDisjointKeyEdits(t *testing.T) {
+	env := newTestEnv()
+	a := env.newMachine(t, []string{".env"})
+	b := env.newMachine(t, []string{".env"})
+
+	a.writeFile(".env", "# keys\nAPI_KEY=1\nDB_URL=db1\n")
+	a.push()
+	b.pull()
+
+	a.writeFile(".env", "# keys\nAPI_KEY=1\nDB_URL=db2\nNEW=n\n")
+	a.push()
+	b.writeFile(".env", "# keys\nAPI_KEY=b\nDB_URL=db1\n")
+	require.Equal(t, domain.SyncActionReconcile, b.status().Action)
+
+	res, err := b.syncer.Pull(context.Background(), PullOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 1, res.FilesMerged)
+	require.Empty(t, res.FilesWithConflicts)
+
+	onDisk, err := os.ReadFile(filepath.Join(b.projectDir, ".env"))
+	require.NoError(t, err)
+	require.Equal(t, "# keys\nAPI_KEY=b\nDB_URL=db2\nNEW=n\n", string(onDisk))
+
+	require.Equal(t, domain.SyncActionPush, b.status().Action)
+	b.push()
+	require.Equal(t, domain.SyncActionPull, a.status().Action)
+}
+
+// TestPull_ConflictResolverSeesVersions: only files with keys both sides
+// changed reach the resolver, which sees every version and can write a
+// per-key resolution.
+func TestPull_ConflictResolverSeesVersions(t *testing.T) {
+	env := newTestEnv()
+	a := env.newMachine(t, []string{".env"})
+	b := env.newMachine(t, []string{".env"})
+
+	a.writeFile(".env", "A=1\nB=1\nC=1\n")
+	a.push()
+	b.pull()
+
+	a.writeFile(".env", "A=a\nB=a\nC=a\n")
+	a.push()
+	b.writeFile(".env", "A=b\nB=b\nC=1\n")
+
+	var seen *Conflict
+	res, err := b.syncer.Pull(context.Background(), PullOptions{
+		ConflictResolver: func(c *Conflict) (Resolution, error) {
+			seen = c
+			return Resolution{Action: ConflictMerge, Content: c.Merged("A")}, nil
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, ".env", seen.File)
+	require.Equal(t, []string{"A", "B"}, seen.Keys)
+	require.Equal(t, "A=1\nB=1\nC=1\n", string(seen.Base))
+	require.Equal(t, "A=b\nB=b\nC=1\n", string(seen.Local))
+	require.Equal(t, "A=a\nB=a\nC=a\n", string(seen.Remote))
+	require.True(t, seen.Mergeable())
+	require.Equal(t, "<<<<<<< local\nA=b\n=======\nA=a\n>>>>>>> remote\n<<<<<<< local\nB=b\n=======\nB=a\n>>>>>>> remote\nC=a\n", string(seen.Marked()))
+	require.Equal(t, 1, res.FilesMerged)
+
+	onDisk, err := os.ReadFile(filepath.Join(b.projectDir, ".env"))
+	require.NoError(t, err)
+	require.Equal(t, "A=a\nB=b\nC=a\n", string(onDisk))
+	require.Equal(t, domain.SyncActionPush, b.status().Action)
+
+	// Aborting leaves the working tree alone
+	a.writeFile(".env", "A=a2\nB=a\nC=a\n")
+	a.push()
+	b.writeFile(".env", "A=b2\nB=b\nC=a\n")
+	_, err = b.syncer.Pull(context.Background(), PullOptions{
+		ConflictResolver: func(c *Conflict) (Resolution, error) {
+			return Resolution{Action: ConflictAbort}, nil
+		},
+	})
+	require.ErrorIs(t, err, domain.ErrUserCancelled)
+	onDisk, err = os.ReadFile(filepath.Join(b.projectDir, ".env"))
+	require.NoError(t, err)
+	require.Equal(t, "A=b2\nB=b\nC=a\n", string(onDisk))
+}
+
+// TestPull_ConflictMergeKeepsRemotelyDeletedFile: a hand-resolved file is
+// written even though the remote deleted it.
+func TestPull_ConflictMergeKeepsRemotelyDeletedFile(t *testing.T) {
+	env := newTestEnv()
+	a := env.newMachine(t, []string{".env", ".env.local"})
+	b := env.newMachine(t, []string{".env", ".env.local"})
+
+	a.writeFile(".env", "A=1\n")
+	a.writeFile(".env.local", "L=1\n")
+	a.push()
+	b.pull()
+
+	require.NoError(t, os.Remove(filepath.Join(a.projectDir, ".env.local")))
+	a.push()
+	b.writeFile(".env.local", "L=2\n")
+
+	res, err := b.syncer.Pull(context.Background(), PullOptions{
+		ConflictResolver: func(c *Conflict) (Resolution, error) {
+			require.Nil(t, c.Remote)
+			require.False(t, c.Mergeable())
+			require.Equal(t, "<<<<<<< local\nL=2\n=======\n>>>>>>> remote\n", string(c.Marked()))
+			return Resolution{Action: ConflictMerge, Content: []byte("L=3\n")}, nil
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, res.FilesMerged)
+	require.Zero(t, res.FilesDeleted)
+
+	onDisk, err := os.ReadFile(filepath.Join(b.projectDir, ".env.local"))
+	require.NoError(t, err)
+	require.Equal(t, "L=3\n", string(onDisk))
+}
+
 // TestSameContent verifies the small helper that both classification and
 // overlap detection lean on.
 func TestSameContent(t *testing.T) {
@@ -739,3 +856,106 @@ func TestSameContent(t *testing.T) {
 		})
 	}
 }
+
+// TestGlobPatterns_PullCreatesAndPushDeletes: files matched by a pattern on
+// one machine reach a machine that has never seen them, and a local
+// deletion of a pattern-matched file is pushed as a deletion.
+func TestGlobPatterns_PullCreatesAndPushDeletes(t *testing.T) {
+	env := newTestEnv()
+	a := env.newMachine(t, []string{"services/*/.env"})
+	b := env.newMachine(t, []string{"services/*/.env"})
+
+	for _, svc := range []string{"api", "worker"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(a.projectDir, "services", svc), 0700))
+		a.writeFile(filepath.Join("services", svc, ".env"), "SVC="+svc)
+	}
+	res := a.push()
+	require.Equal(t, 2, res.FilesAdded)
+
+	// B has no matching files in its tree; the cache supplies them
+	pullRes, err := b.syncer.Pull(context.Background(), PullOptions{})
+	require.NoError(t, err)
+	require.Equal(t, 2, pullRes.FilesCreated)
+	content, err := os.ReadFile(filepath.Join(b.projectDir, "services", "worker", ".env"))
+	require.NoError(t, err)
+	require.Equal(t, "SVC=worker", string(content))
+
+	// A deletes one service's file; push must remove it from the cache
+	a.deleteFile(filepath.Join("services", "worker", ".env"))
+	res = a.push()
+	require.Equal(t, 1, res.FilesDeleted)
+
+	s := b.status()
+	require.Equal(t, domain.SyncActionPull, s.Action)
+	require.Equal(t, []string{"services/worker/.env"}, s.RemoteChanges)
+}
+
+// TestSync_ProjectOutsideGit: a deploy directory with only a .envsecrets
+// round-trips like a git project.
+func TestSync_ProjectOutsideGit(t *testing.T) {
+	env := newTestEnv()
+
+	projectDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, ".envsecrets"), []byte("repo: owner/repo\n.env\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, ".env"), []byte("KEY=v1"), 0600))
+	disc, err := project.NewDiscovery(projectDir)
+	require.NoError(t, err)
+	require.False(t, disc.IsGitRepo())
+
+	cacheDir := t.TempDir()
+	gitRepo, err := git.NewGoGitRepository(cacheDir)
+	require.NoError(t, err)
+	require.NoError(t, gitRepo.Init())
+	c := cache.NewCacheWithRepo(env.repoInfo, env.storage, gitRepo, cacheDir)
+	syncer := NewSyncer(disc, env.repoInfo, env.storage, env.encrypter, c)
+
+	_, err = syncer.Push(context.Background(), PushOptions{Message: "test"})
+	require.NoError(t, err)
+
+	b := env.newMachine(t, []string{".env"})
+	b.pull()
+	data, err := os.ReadFile(filepath.Join(b.projectDir, ".env"))
+	require.NoError(t, err)
+	require.Equal(t, "KEY=v1", string(data))
+}
+
+// TestSyncStatus_WorktreesKeepSeparateBaselines: two worktrees of one
+// project share a cache. Pulling in one must not make the other's stale
+// files look like local edits.
+func TestSyncStatus_WorktreesKeepSeparateBaselines(t *testing.T) {
+	env := newTestEnv()
+	other := env.newMachine(t, []string{".env"})
+	other.writeFile(".env", "KEY=v1")
+	other.push()
+
+	main := env.newMachine(t, []string{".env"})
+	main.pull()
+
+	// A linked worktree of main's checkout, sharing its cache
+	wtDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(wtDir, ".git"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(wtDir, ".envsecrets"), []byte(".env\n"), 0600))
+	wtDiscovery, err := project.NewDiscovery(wtDir)
+	require.NoError(t, err)
+	wtCache := cache.NewCacheWithRepo(env.repoInfo, env.storage, mustOpenRepo(t, main.cache.Path()), main.cache.Path())
+	wtCache.SetWorktree("feature")
+	wt := &testMachine{t: t, env: env, projectDir: wtDir, cache: wtCache, discovery: wtDiscovery,
+		syncer: NewSyncer(wtDiscovery, env.repoInfo, env.storage, env.encrypter, wtCache)}
+	wt.pull()
+	require.Equal(t, domain.SyncActionInSync, wt.status().Action)
+
+	other.writeFile(".env", "KEY=v2")
+	other.push()
+	main.pull()
+
+	require.Equal(t, domain.SyncActionInSync, main.status().Action)
+	require.Equal(t, domain.SyncActionPull, wt.status().Action)
+}
+
+// mustOpenRepo opens an existing cache git repository
+func mustOpenRepo(t *testing.T, dir string) git.Repository {
+	t.Helper()
+	repo, err := git.NewGoGitRepository(dir)
+	require.NoError(t, err)
+	return repo
+}
@@ -206,5 +206,8 @@ func (o *Output) PrintFileStatusDetailed(status domain.FileStatus) {
 	default:
 		indicator = "(unchanged)"
 	}
+	if status.Pattern != "" {
+		indicator += " via " + status.Pattern
+	}
 	fmt.Fprintf(o.out, "  %-30s %s\n", status.Path, indicator)
 }
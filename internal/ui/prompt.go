@@ -3,6 +3,7 @@ package ui
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"sync/atomic"
@@ -39,6 +40,13 @@ func NewPrompt() *Prompt {
 	}
 }
 
+// NewPromptWithReader creates a prompt handler that reads answers from r
+func NewPromptWithReader(r io.Reader) *Prompt {
+	return &Prompt{
+		reader: bufio.NewReader(r),
+	}
+}
+
 // Confirm asks for a yes/no confirmation
 func (p *Prompt) Confirm(message string, defaultYes bool) (bool, error) {
 	defaultStr := "y/N"
@@ -140,11 +148,25 @@ func IsInteractive() bool {
 	return term.IsTerminal(int(os.Stdin.Fd()))
 }
 
-// ConflictChoice prompts the user to choose how to handle a file conflict
-// Returns "o" for overwrite, "s" for skip, or "a" for abort
-func (p *Prompt) ConflictChoice(filename string) (string, error) {
-	fmt.Fprintf(os.Stderr, "%s exists locally and differs from remote.\n", filename)
-	fmt.Fprint(os.Stderr, "  [o]verwrite / [s]kip / [a]bort? ")
+// IsOutputTerminal returns true if stdout is a terminal rather than a
+// pipe, file, or CI log
+func IsOutputTerminal() bool {
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// ConflictChoice prompts the user to choose how to handle a file conflict.
+// keys are the keys changed on both sides; when there are none the file
+// cannot be resolved key by key.
+// Returns "k" for per-key choices, "d" for diff, "e" for edit, "o" for
+// overwrite, "s" for skip, or "a" for abort
+func (p *Prompt) ConflictChoice(filename string, keys []string) (string, error) {
+	if len(keys) > 0 {
+		fmt.Fprintf(os.Stderr, "%s: %d key(s) changed both locally and remotely: %s\n", filename, len(keys), strings.Join(keys, ", "))
+		fmt.Fprint(os.Stderr, "  [k]eys / [d]iff / [e]dit / [o]verwrite / [s]kip / [a]bort? ")
+	} else {
+		fmt.Fprintf(os.Stderr, "%s exists locally and differs from remote.\n", filename)
+		fmt.Fprint(os.Stderr, "  [d]iff / [e]dit / [o]verwrite / [s]kip / [a]bort? ")
+	}
 
 	input, err := p.reader.ReadString('\n')
 	if err != nil {
@@ -152,6 +174,15 @@ func (p *Prompt) ConflictChoice(filename string) (string, error) {
 	}
 
 	switch strings.TrimSpace(strings.ToLower(input)) {
+	case "k", "keys":
+		if len(keys) > 0 {
+			return "k", nil
+		}
+		return "a", nil
+	case "d", "diff":
+		return "d", nil
+	case "e", "edit":
+		return "e", nil
 	case "o", "overwrite":
 		return "o", nil
 	case "s", "skip":
@@ -160,3 +191,25 @@ func (p *Prompt) ConflictChoice(filename string) (string, error) {
 		return "a", nil
 	}
 }
+
+// KeyConflictChoice prompts the user to choose which side's value to keep
+// for a key changed both locally and remotely. Values are not shown.
+// Returns "l" for local, "r" for remote, or "a" for abort
+func (p *Prompt) KeyConflictChoice(filename, key string) (string, error) {
+	fmt.Fprintf(os.Stderr, "%s: %s was changed both locally and remotely.\n", filename, key)
+	fmt.Fprint(os.Stderr, "  keep [l]ocal / take [r]emote / [a]bort? ")
+
+	input, err := p.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+
+	switch strings.TrimSpace(strings.ToLower(input)) {
+	case "l", "local":
+		return "l", nil
+	case "r", "remote":
+		return "r", nil
+	default:
+		return "a", nil
+	}
+}